@@ -94,6 +94,9 @@ type APIJob struct {
 	TotalHashes   int64     `json:"totalhashes"`
 	Progress      float64   `json:"progress"`
 	ToolID        string    `json:"toolid"`
+	ParentID      string    `json:"parentid"`
+	Priority      int       `json:"priority"`
+	Waiting       string    `json:"waiting"`
 }
 
 type APIJobDetail struct {
@@ -113,6 +116,41 @@ type APIJobDetail struct {
 	PerformanceData  map[string]string `json:"performancedata"`
 	OutputTitles     []string          `json:"outputtitles"`
 	OutputData       [][]string        `json:"outputdata"`
+	Keyspace         int64             `json:"keyspace"`
+	Priority         int               `json:"priority"`
+	Chunks           []APIJob          `json:"chunks"`
+	Dependencies     []APIDependency   `json:"dependencies"`
+	FeedFrom         string            `json:"feedfrom"`
+	Error            string            `json:"error"`
+	NotBefore        time.Time         `json:"notbefore"`
+	MaxRuntime       int64             `json:"maxruntime"` // Seconds
+	Deadline         time.Time         `json:"deadline"`
+	RunTime          int64             `json:"runtime"` // Seconds
+	Attempts         []APIAttempt      `json:"attempts"`
+	RetryAfter       time.Time         `json:"retryafter"`
+	Placement        APIPlacement      `json:"placement"`
+	Waiting          string            `json:"waiting"` // Why the job is being held back, such as a quota
+}
+
+// Resources a job may be placed on, by resource label
+type APIPlacement struct {
+	Require map[string]string `json:"require"`
+	Prefer  map[string]string `json:"prefer"`
+	Avoid   []string          `json:"avoid"`
+}
+
+// A run of a job on a resource
+type APIAttempt struct {
+	ResourceID string    `json:"resourceid"`
+	Started    time.Time `json:"started"`
+	Ended      time.Time `json:"ended"`
+	Error      string    `json:"error"`
+}
+
+// Dependency of a job on another job
+type APIDependency struct {
+	JobID     string `json:"jobid"`
+	Condition string `json:"condition"`
 }
 
 // Get Jobs structure
@@ -124,16 +162,95 @@ type GetJobsResp struct {
 
 // Create Jobs request
 type JobCreateReq struct {
-	ToolID string                 `json:"toolid"`
-	Name   string                 `json:"name"`
-	Params map[string]interface{} `json:"params"`
+	ToolID       string                 `json:"toolid"`
+	Name         string                 `json:"name"`
+	Params       map[string]interface{} `json:"params"`
+	Dependencies []APIDependency        `json:"dependencies"`
+	FeedFrom     string                 `json:"feedfrom"`
+	NotBefore    time.Time              `json:"notbefore"`
+	MaxRuntime   int64                  `json:"maxruntime"` // Seconds
+	Deadline     time.Time              `json:"deadline"`
+	Placement    APIPlacement           `json:"placement"`
 }
 
-// Create Job response
-type JobCreateResp struct {
-	Status  int    `json:"status"`
+// A problem with one parameter of a job
+type APIFieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
+}
+
+// Create Job response, Errors are set when the parameters do not match the tool
+type JobCreateResp struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	JobID   string          `json:"jobid"`
+	Errors  []APIFieldError `json:"errors,omitempty"`
+}
+
+// Validate Job response
+type JobValidateResp struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Valid   bool            `json:"valid"`
+	Errors  []APIFieldError `json:"errors"`
+}
+
+// How long a job would take on one resource, Speed is in hashes a second and
+// Seconds is 0 when no speed has been recorded
+type APIResourceEstimate struct {
+	ResourceID string  `json:"resourceid"`
+	Name       string  `json:"name"`
+	Speed      float64 `json:"speed"`
+	Seconds    float64 `json:"seconds"`
+}
+
+// Estimate Job response. MinSeconds is with every resource working on a job
+// that can be split and MaxSeconds on the slowest resource.
+type JobEstimateResp struct {
+	Status     int                   `json:"status"`
+	Message    string                `json:"message"`
+	Candidates float64               `json:"candidates"`
+	Mode       string                `json:"mode"`
+	MinSeconds float64               `json:"minseconds"`
+	MaxSeconds float64               `json:"maxseconds"`
+	Resources  []APIResourceEstimate `json:"resources"`
+}
+
+// Clone Job request. Params replace those of the job being cloned.
+type JobCloneReq struct {
+	Name      string                 `json:"name"`
+	Params    map[string]interface{} `json:"params"`
+	Remaining bool                   `json:"remaining"` // Only run the hashes the job left uncracked
+}
+
+// Jobs picked by a bulk request. Name may use * and ? as wildcards.
+type APIJobFilter struct {
+	Owner  string `json:"owner"`
+	Status string `json:"status"`
+	Tool   string `json:"tool"`
+	Name   string `json:"name"`
+}
+
+// Bulk job request. Jobs are picked by ID, by filter or by both.
+type JobBulkReq struct {
+	IDs      []string     `json:"ids"`
+	Filter   APIJobFilter `json:"filter"`
+	Action   string       `json:"action"`   // pause, quit, delete or priority
+	Priority int          `json:"priority"` // New priority for the priority action
+}
+
+// Result of a bulk action on one job
+type APIBulkResult struct {
 	JobID   string `json:"jobid"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// Bulk job response
+type JobBulkResp struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Results []APIBulkResult `json:"results"`
 }
 
 // Read Job resposne
@@ -143,9 +260,27 @@ type JobReadResp struct {
 	Job     APIJobDetail `json:"job"`
 }
 
+// A change in the state of a job
+type APIJobEvent struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	ResourceID string    `json:"resourceid"`
+	User       string    `json:"user"`
+	Message    string    `json:"message"`
+}
+
+// Job events response
+type JobEventsResp struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Events  []APIJobEvent `json:"events"`
+}
+
 // Update Job Request
 type JobUpdateReq struct {
 	APIJob
+	Priority *int `json:"priority"`
 }
 
 // Update Job Response
@@ -170,6 +305,7 @@ type APIResource struct {
 	Params  map[string]string `json:"params"`
 	Status  string            `json:"status"`
 	Tools   []APITool         `json:"tools"`
+	Labels  map[string]string `json:"labels"`
 }
 
 // List resource structs
@@ -226,6 +362,28 @@ type ResDeleteResp struct {
 	Message string `json:"message"`
 }
 
+// Benchmark API structure, Speed is in hashes a second
+type APIBenchmark struct {
+	Tool    string    `json:"tool"`
+	Mode    string    `json:"mode"`
+	Speed   float64   `json:"speed"`
+	Source  string    `json:"source"`
+	Updated time.Time `json:"updated"`
+}
+
+// List resource benchmarks response
+type ResBenchmarksResp struct {
+	Status     int            `json:"status"`
+	Message    string         `json:"message"`
+	Benchmarks []APIBenchmark `json:"benchmarks"`
+}
+
+// Benchmark a resource request, every mode the tool benchmarks is run if Mode is empty
+type ResBenchmarkReq struct {
+	ToolID string `json:"toolid"`
+	Mode   string `json:"mode"`
+}
+
 type QueueUpdateReq struct {
 	JobOrder []string `json:"joborder"`
 }
@@ -234,3 +392,199 @@ type QueueUpdateResp struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
 }
+
+// Pipeline API structure
+type APIPipeline struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Owner   string    `json:"owner"`
+	Created time.Time `json:"created"`
+	Jobs    []APIJob  `json:"jobs"`
+}
+
+// Stage of a pipeline to create
+type PipelineStageReq struct {
+	ToolID    string                 `json:"toolid"`
+	Name      string                 `json:"name"`
+	Params    map[string]interface{} `json:"params"`
+	Condition string                 `json:"condition"`
+	Feed      bool                   `json:"feed"`
+}
+
+// Create pipeline request
+type PipelineCreateReq struct {
+	Name   string             `json:"name"`
+	Stages []PipelineStageReq `json:"stages"`
+}
+
+// Create pipeline response
+type PipelineCreateResp struct {
+	Status     int      `json:"status"`
+	Message    string   `json:"message"`
+	PipelineID string   `json:"pipelineid"`
+	JobIDs     []string `json:"jobids"`
+}
+
+// List pipelines response
+type PipelineListResp struct {
+	Status    int           `json:"status"`
+	Message   string        `json:"message"`
+	Pipelines []APIPipeline `json:"pipelines"`
+}
+
+// Read pipeline response
+type PipelineReadResp struct {
+	Status   int         `json:"status"`
+	Message  string      `json:"message"`
+	Pipeline APIPipeline `json:"pipeline"`
+}
+
+// Schedule API structure
+type APISchedule struct {
+	ID      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	Owner   string                 `json:"owner"`
+	Cron    string                 `json:"cron"`
+	Enabled bool                   `json:"enabled"`
+	ToolID  string                 `json:"toolid"`
+	JobName string                 `json:"jobname"`
+	Params  map[string]interface{} `json:"params"`
+	Created time.Time              `json:"created"`
+	NextRun time.Time              `json:"nextrun"`
+	LastRun time.Time              `json:"lastrun"`
+	LastJob string                 `json:"lastjob"`
+}
+
+// List schedules response
+type ScheduleListResp struct {
+	Status    int           `json:"status"`
+	Message   string        `json:"message"`
+	Schedules []APISchedule `json:"schedules"`
+}
+
+// Create and update schedule request
+type ScheduleReq struct {
+	APISchedule
+}
+
+// Create, read and update schedule response
+type ScheduleResp struct {
+	Status   int         `json:"status"`
+	Message  string      `json:"message"`
+	Schedule APISchedule `json:"schedule"`
+}
+
+// Delete schedule response
+type ScheduleDeleteResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// Template API structure. Stale templates were saved against a different
+// version or parameter form of their tool.
+type APITemplate struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Owner       string                 `json:"owner"`
+	Shared      bool                   `json:"shared"`
+	ToolID      string                 `json:"toolid"`
+	ToolName    string                 `json:"toolname"`
+	ToolVersion string                 `json:"toolversion"`
+	JobName     string                 `json:"jobname"`
+	Params      map[string]interface{} `json:"params"`
+	Created     time.Time              `json:"created"`
+	Updated     time.Time              `json:"updated"`
+	Stale       bool                   `json:"stale"`
+	StaleReason string                 `json:"stalereason"`
+}
+
+// List templates response
+type TemplateListResp struct {
+	Status    int           `json:"status"`
+	Message   string        `json:"message"`
+	Templates []APITemplate `json:"templates"`
+}
+
+// Create and update template request. The ToolID ties the template to that
+// tool's name and version, on update it can be left out to keep the current one.
+type TemplateReq struct {
+	APITemplate
+}
+
+// Create, read and update template response
+type TemplateResp struct {
+	Status   int         `json:"status"`
+	Message  string      `json:"message"`
+	Template APITemplate `json:"template"`
+}
+
+// Delete template response
+type TemplateDeleteResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// Create a job from a template request. Params replace those of the template.
+type TemplateJobReq struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// Archived job API structure
+type APIArchivedJob struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Status        string            `json:"status"`
+	Owner         string            `json:"owner"`
+	ToolID        string            `json:"toolid"`
+	ToolName      string            `json:"toolname"`
+	ToolVersion   string            `json:"toolversion"`
+	Params        map[string]string `json:"params"`
+	StartTime     time.Time         `json:"starttime"`
+	RunTime       int64             `json:"runtime"`
+	Archived      time.Time         `json:"archived"`
+	CrackedHashes int64             `json:"crackedhashes"`
+	TotalHashes   int64             `json:"totalhashes"`
+	OutputTitles  []string          `json:"outputtitles"`
+	OutputData    [][]string        `json:"outputdata"`
+	Error         string            `json:"error"`
+}
+
+// Archive search response
+type ArchiveSearchResp struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Jobs    []APIArchivedJob `json:"jobs"`
+}
+
+// Queue statistics API structure. Times are in seconds and utilisation is the
+// share of each hardware type in use from 0 to 1.
+type APIStats struct {
+	JobsCreated   int64              `json:"jobscreated"`
+	JobsByTool    map[string]int64   `json:"jobsbytool"`
+	JobsByOwner   map[string]int64   `json:"jobsbyowner"`
+	JobsByStatus  map[string]int64   `json:"jobsbystatus"`
+	JobsFinished  map[string]int64   `json:"jobsfinished"`
+	RunTime       map[string]int64   `json:"runtime"`
+	CrackedHashes int64              `json:"crackedhashes"`
+	AverageWait   int64              `json:"averagewait"`
+	Utilisation   map[string]float64 `json:"utilisation"`
+	Series        []APIStatsPoint    `json:"series"`
+}
+
+// One period of the statistics series
+type APIStatsPoint struct {
+	Start         time.Time          `json:"start"`
+	JobsCreated   int64              `json:"jobscreated"`
+	JobsFinished  int64              `json:"jobsfinished"`
+	CrackedHashes int64              `json:"crackedhashes"`
+	AverageWait   int64              `json:"averagewait"`
+	Utilisation   map[string]float64 `json:"utilisation"`
+}
+
+// Queue statistics response
+type StatsResp struct {
+	Status  int      `json:"status"`
+	Message string   `json:"message"`
+	Stats   APIStats `json:"stats"`
+}
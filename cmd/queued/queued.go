@@ -7,7 +7,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/negroni"
@@ -32,6 +35,34 @@ func processHookSection(section map[string]string) []string {
 	return results
 }
 
+// processQuotaSection reads the limits of a quota, leaving out any that are not
+// valid numbers
+func processQuotaSection(name string, section map[string]string) queue.Quota {
+	var quota queue.Quota
+	var err error
+
+	if v, ok := section["maxrunning"]; ok {
+		quota.MaxRunning, err = strconv.Atoi(common.StripQuotes(v))
+		if err != nil {
+			log.WithField("quota", name).Error("Max running jobs was provided, but is not a valid integer.")
+		}
+	}
+	if v, ok := section["maxqueued"]; ok {
+		quota.MaxQueued, err = strconv.Atoi(common.StripQuotes(v))
+		if err != nil {
+			log.WithField("quota", name).Error("Max queued jobs was provided, but is not a valid integer.")
+		}
+	}
+	if v, ok := section["maxgpuhours"]; ok {
+		quota.MaxGPUHours, err = strconv.ParseFloat(common.StripQuotes(v), 64)
+		if err != nil {
+			log.WithField("quota", name).Error("Max GPU hours was provided, but is not a valid number.")
+		}
+	}
+
+	return quota
+}
+
 func main() {
 	// Define the flags
 	var confPath = flag.String("conf", "", "Configuration file to use")
@@ -152,6 +183,16 @@ func main() {
 	var statefile string
 	statefile = common.StripQuotes(genConf["StateFile"])
 
+	// The state is kept in a state store, which older configurations only gave
+	// a state file for. In that case the store goes alongside the file.
+	queue.StateStoreLocation = common.StripQuotes(genConf["StateStore"])
+	if queue.StateStoreLocation == "" && statefile != "" {
+		queue.StateStoreLocation = strings.TrimSuffix(statefile, filepath.Ext(statefile)) + ".db"
+	}
+	if backend := common.StripQuotes(genConf["StateBackend"]); backend != "" {
+		queue.StateBackend = backend
+	}
+
 	var updatetime int
 	var resourcetimeout int
 	utconf := common.StripQuotes(genConf["UpdateTime"])
@@ -176,6 +217,15 @@ func main() {
 	} else {
 		resourcetimeout = 5
 	}
+	graceconf := common.StripQuotes(genConf["ReconnectGrace"])
+	if graceconf != "" {
+		grace, err := strconv.Atoi(graceconf)
+		if err != nil {
+			log.WithField("error", err.Error()).Error("Unable to parse reconnect grace in config file.")
+			grace = 600
+		}
+		queue.ReconnectGrace = time.Duration(grace) * time.Second
+	}
 
 	log.WithFields(log.Fields{
 		"ip":   runIP,
@@ -298,6 +348,7 @@ func main() {
 	hooks.JobStart = processHookSection(confFile.Section("Hooks.JobStart"))
 	hooks.ResourceConnect = processHookSection(confFile.Section("Hooks.ResourceConnect"))
 	hooks.QueueReorder = processHookSection(confFile.Section("Hooks.QueueReorder"))
+	hooks.JobPreempt = processHookSection(confFile.Section("Hooks.JobPreempt"))
 
 	purgeConf := confFile.Section("JobPurge")
 	purgeTime, ok := purgeConf["purgetime"]
@@ -311,12 +362,101 @@ func main() {
 		log.Errorf("Purge time was provided, but not parsable to a integer. %s\n", err.Error())
 		purgeTimeInt = 30
 	}
+	if retention, ok := purgeConf["archiveretention"]; ok {
+		retentionInt, err := strconv.Atoi(common.StripQuotes(retention))
+		if err != nil || retentionInt < 0 {
+			log.Error("Archive retention was provided, but is not a valid integer.")
+			retentionInt = 0
+		}
+		queue.ArchiveRetention = time.Duration(retentionInt*24) * time.Hour
+	}
+
+	// Keyspace splitting of jobs across resources
+	distConf := confFile.Section("Distribute")
+	if chunks, ok := distConf["chunksperresource"]; ok {
+		queue.ChunksPerResource, err = strconv.Atoi(common.StripQuotes(chunks))
+		if err != nil || queue.ChunksPerResource < 1 {
+			log.Error("Chunks per resource was provided, but is not a positive integer.")
+			queue.ChunksPerResource = 2
+		}
+	}
+	if retries, ok := distConf["chunkretries"]; ok {
+		queue.MaxChunkRetries, err = strconv.Atoi(common.StripQuotes(retries))
+		if err != nil || queue.MaxChunkRetries < 0 {
+			log.Error("Chunk retries was provided, but is not a valid integer.")
+			queue.MaxChunkRetries = 3
+		}
+	}
+
+	// Retry policy for jobs that lose their resource or fail to start
+	retryConf := confFile.Section("Retry")
+	if attempts, ok := retryConf["maxattempts"]; ok {
+		queue.RetryMaxAttempts, err = strconv.Atoi(common.StripQuotes(attempts))
+		if err != nil || queue.RetryMaxAttempts < 1 {
+			log.Error("Retry max attempts was provided, but is not a positive integer.")
+			queue.RetryMaxAttempts = 3
+		}
+	}
+	if backoff, ok := retryConf["backoff"]; ok {
+		backoffInt, err := strconv.Atoi(common.StripQuotes(backoff))
+		if err != nil || backoffInt < 0 {
+			log.Error("Retry backoff was provided, but is not a valid integer.")
+			backoffInt = 30
+		}
+		queue.RetryBackoff = time.Duration(backoffInt) * time.Second
+	}
+	if exclude, ok := retryConf["excludefailed"]; ok {
+		queue.RetryExcludeFailed = common.StripQuotes(exclude) != "false"
+	}
+
+	// Scheduling policy used by the queue keeper
+	schedConf := confFile.Section("Scheduling")
+	weights := map[string]int{}
+	for owner, w := range confFile.Section("Scheduling.Weights") {
+		weights[owner], err = strconv.Atoi(common.StripQuotes(w))
+		if err != nil {
+			log.WithField("owner", owner).Error("Scheduling weight was provided, but not parsable to a integer.")
+			weights[owner] = 1
+		}
+	}
+	queue.Scheduler, err = queue.NewSchedulingPolicy(common.StripQuotes(schedConf["policy"]), weights)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Unable to setup the scheduling policy, using fifo.")
+		queue.Scheduler = queue.FIFOPolicy{}
+	}
+	queue.Preemption = common.StripQuotes(schedConf["preemption"]) == "true"
+	log.WithFields(log.Fields{
+		"policy":     queue.Scheduler.Name(),
+		"preemption": queue.Preemption,
+	}).Info("Scheduling policy configured.")
+
+	// Quotas by role and by user
+	for section, role := range map[string]string{"Quotas.StandardUser": StandardUser, "Quotas.Administrator": Administrator} {
+		if conf, ok := confFile[section]; ok {
+			queue.RoleQuotas[role] = processQuotaSection(section, conf)
+		}
+	}
+	for section, conf := range confFile {
+		if strings.HasPrefix(section, "Quotas.User.") {
+			queue.UserQuotas[strings.TrimPrefix(section, "Quotas.User.")] = processQuotaSection(section, conf)
+		}
+	}
 
 	// Configure the TokenStore
 	server.T = NewTokenStore()
 
 	// Configure the Queue
-	server.Q = queue.NewQueue(statefile, updatetime, resourcetimeout, hooks, purgeTimeInt)
+	server.Q, err = queue.NewQueue(statefile, updatetime, resourcetimeout, hooks, purgeTimeInt)
+	if err != nil {
+		log.Fatalf("Failed to start the queue. %s\n", err.Error())
+	}
+	server.Q.StartKeeper()
+
+	// Prometheus metrics are served without a login so are off unless turned on
+	server.Metrics = common.StripQuotes(confFile.Section("Metrics")["enabled"]) == "true"
+	if server.Metrics {
+		server.Q.RegisterMetrics()
+	}
 
 	caBytes, err := ioutil.ReadFile(caCertPath)
 	if err != nil {
@@ -394,7 +534,7 @@ func main() {
 
 	// First, let's setup the direct connect manager if we have anything there
 	if resDC, ok := confResMgr["directconnect"]; ok {
-		resmgr_dc, err := directconnectresourcemanager.Setup(resDC, &server.Q, qandrTLSConfig)
+		resmgr_dc, err := directconnectresourcemanager.Setup(resDC, server.Q, qandrTLSConfig)
 		if err != nil {
 			log.WithField("error", err.Error()).Error("Unable to setup direct connect resource manager.")
 		} else {
@@ -404,7 +544,7 @@ func main() {
 
 	// Now let's setup the AWS manager if we have a config file
 	if resAWS, ok := confResMgr["aws"]; ok {
-		resmgr_aws, err := awsresourcemanager.Setup(resAWS, &server.Q, qandrTLSConfig, caCertPath, caKeyPath)
+		resmgr_aws, err := awsresourcemanager.Setup(resAWS, server.Q, qandrTLSConfig, caCertPath, caKeyPath)
 		if err != nil {
 			log.WithField("error", err.Error()).Error("Unable to setup AWS resource manager.")
 		} else {
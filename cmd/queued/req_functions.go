@@ -7,12 +7,16 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/gorilla/mux"
 	"github.com/jmmcatee/cracklord/common"
+	"github.com/jmmcatee/cracklord/common/metrics"
 	"github.com/jmmcatee/cracklord/common/queue"
 )
 
@@ -21,12 +25,15 @@ import (
 // expandablility related to adding a database or other dependencies much easier
 // for future development.
 type AppController struct {
-	T    TokenStore
-	Auth Authenticator
-	Q    queue.Queue
-	TLS  *tls.Config
+	T       TokenStore
+	Auth    Authenticator
+	Q       *queue.Queue
+	TLS     *tls.Config
+	Metrics bool // Serve Prometheus metrics at /metrics
 }
 
+var loginFailures = metrics.NewCounter("cracklord_login_failures_total", "Logins refused for a bad username or password.")
+
 func (a *AppController) Router() *mux.Router {
 	r := mux.NewRouter().StrictSlash(false)
 
@@ -45,6 +52,8 @@ func (a *AppController) Router() *mux.Router {
 	// Resource endpoints
 	r.Path("/api/resources").Methods("GET").HandlerFunc(a.ListResource)
 	r.Path("/api/resources").Methods("POST").HandlerFunc(a.CreateResource)
+	r.Path("/api/resources/{id}/benchmarks").Methods("GET").HandlerFunc(a.ListBenchmarks)
+	r.Path("/api/resources/{id}/benchmarks").Methods("POST").HandlerFunc(a.CreateBenchmark)
 	r.Path("/api/resources/{manager}/{id}").Methods("GET").HandlerFunc(a.ReadResource)
 	r.Path("/api/resources/{id}").Methods("PUT").HandlerFunc(a.UpdateResource)
 	r.Path("/api/resources/{id}").Methods("DELETE").HandlerFunc(a.DeleteResources)
@@ -52,13 +61,49 @@ func (a *AppController) Router() *mux.Router {
 	// Jobs endpoints
 	r.Path("/api/jobs").Methods("GET").HandlerFunc(a.GetJobs)
 	r.Path("/api/jobs").Methods("POST").HandlerFunc(a.CreateJob)
+	r.Path("/api/jobs/bulk").Methods("POST").HandlerFunc(a.BulkJobs)
+	r.Path("/api/jobs/validate").Methods("POST").HandlerFunc(a.ValidateJob)
+	r.Path("/api/jobs/estimate").Methods("POST").HandlerFunc(a.EstimateJob)
 	r.Path("/api/jobs/{id}").Methods("GET").HandlerFunc(a.ReadJob)
 	r.Path("/api/jobs/{id}").Methods("PUT").HandlerFunc(a.UpdateJob)
 	r.Path("/api/jobs/{id}").Methods("DELETE").HandlerFunc(a.DeleteJob)
+	r.Path("/api/jobs/{id}/events").Methods("GET").HandlerFunc(a.ReadJobEvents)
+	r.Path("/api/jobs/{id}/clone").Methods("POST").HandlerFunc(a.CloneJob)
+
+	// Pipeline endpoints
+	r.Path("/api/pipelines").Methods("GET").HandlerFunc(a.ListPipelines)
+	r.Path("/api/pipelines").Methods("POST").HandlerFunc(a.CreatePipeline)
+	r.Path("/api/pipelines/{id}").Methods("GET").HandlerFunc(a.ReadPipeline)
+
+	// Schedule endpoints
+	r.Path("/api/schedules").Methods("GET").HandlerFunc(a.ListSchedules)
+	r.Path("/api/schedules").Methods("POST").HandlerFunc(a.CreateSchedule)
+	r.Path("/api/schedules/{id}").Methods("GET").HandlerFunc(a.ReadSchedule)
+	r.Path("/api/schedules/{id}").Methods("PUT").HandlerFunc(a.UpdateSchedule)
+	r.Path("/api/schedules/{id}").Methods("DELETE").HandlerFunc(a.DeleteSchedule)
+
+	// Template endpoints
+	r.Path("/api/templates").Methods("GET").HandlerFunc(a.ListTemplates)
+	r.Path("/api/templates").Methods("POST").HandlerFunc(a.CreateTemplate)
+	r.Path("/api/templates/{id}").Methods("GET").HandlerFunc(a.ReadTemplate)
+	r.Path("/api/templates/{id}").Methods("PUT").HandlerFunc(a.UpdateTemplate)
+	r.Path("/api/templates/{id}").Methods("DELETE").HandlerFunc(a.DeleteTemplate)
+	r.Path("/api/templates/{id}/jobs").Methods("POST").HandlerFunc(a.CreateJobFromTemplate)
 
 	// Queue endpoints
 	r.Path("/api/queue").Methods("PUT").HandlerFunc(a.ReorderQueue)
 
+	// Archive endpoints
+	r.Path("/api/archive").Methods("GET").HandlerFunc(a.SearchArchive)
+
+	// Statistics endpoints
+	r.Path("/api/stats").Methods("GET").HandlerFunc(a.GetStats)
+
+	// Prometheus metrics
+	if a.Metrics {
+		r.Path("/metrics").Methods("GET").Handler(metrics.Handler())
+	}
+
 	log.Debug("Application router handlers configured.")
 
 	return r
@@ -96,6 +141,7 @@ func (a *AppController) Login(rw http.ResponseWriter, r *http.Request) {
 		resp.Token = ""
 
 		log.WithField("username", req.Username).Warn("Login failed.")
+		loginFailures.Inc()
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
@@ -440,6 +486,11 @@ func (a *AppController) GetJobs(rw http.ResponseWriter, r *http.Request) {
 
 	// Get the list of jobs and populate a return structure
 	for _, j := range a.Q.AllJobs() {
+		// Keyspace chunks are shown as part of the job they were split from
+		if j.ParentUUID != "" {
+			continue
+		}
+
 		var job APIJob
 
 		job.ID = j.UUID
@@ -453,6 +504,8 @@ func (a *AppController) GetJobs(rw http.ResponseWriter, r *http.Request) {
 		job.TotalHashes = j.TotalHashes
 		job.Progress = j.Progress
 		job.ToolID = j.ToolUUID
+		job.Priority = j.Priority
+		job.Waiting = j.Waiting
 
 		resp.Jobs = append(resp.Jobs, job)
 		log.WithFields(log.Fields{
@@ -517,28 +570,31 @@ func (a *AppController) CreateJob(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Some types might not be strings so let's build a map for the params input
-	params := map[string]string{}
-	for key, value := range req.Params {
-		switch v := value.(type) {
-		case string:
-			params[key] = v
-		case bool:
-			params[key] = strconv.FormatBool(v)
-		case int:
-			params[key] = strconv.Itoa(v)
-		case float64:
-			params[key] = strconv.FormatFloat(v, 'g', -1, 64)
-		case float32:
-			params[key] = strconv.FormatFloat(float64(v), 'g', -1, 32)
-
-		}
-	}
-
 	// Build a job structure
-	job := common.NewJob(req.ToolID, req.Name, user.Username, params)
+	job := newJobFromReq(req, user)
 
 	err = a.Q.AddJob(job)
+	if _, ok := err.(*queue.QuotaError); ok {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = err.Error()
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+		log.WithFields(log.Fields{
+			"user":  user.Username,
+			"error": err.Error(),
+		}).Warn("Job refused by quota.")
+		return
+	}
+	if verr, ok := err.(*queue.ValidationError); ok {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "The job parameters do not match what the tool accepts."
+		resp.Errors = newAPIFieldErrors(verr.Fields)
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
 	if err != nil {
 		log.Println(err.Error())
 		resp.Status = RESP_CODE_BADREQ
@@ -563,12 +619,15 @@ func (a *AppController) CreateJob(rw http.ResponseWriter, r *http.Request) {
 	}).Info("New job created.")
 }
 
-// Read an individual Job (GET - /api/jobs/{id})
-func (a *AppController) ReadJob(rw http.ResponseWriter, r *http.Request) {
+// Check a new job without creating it (POST - /api/jobs/validate). The request
+// is the same as for creating a job.
+func (a *AppController) ValidateJob(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var resp JobReadResp
+	var req JobCreateReq
+	var resp JobValidateResp
 
 	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
 	respJSON := json.NewEncoder(rw)
 
 	// Get the authorization header
@@ -580,52 +639,68 @@ func (a *AppController) ReadJob(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
+		log.Warn("An unknown token attempted to validate a job.")
+		return
+	}
 
-		log.WithField("token", token).Warn("An unknown user token attempted to read job data.")
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to validate a job.")
 		return
 	}
 
-	// Get the ID of the job we want
-	jobid := mux.Vars(r)["id"]
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		log.WithField("err", err).Error("Error parsing the request.")
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
 
-	// Pull Job info from the Queue
-	job := a.Q.JobInfo(jobid)
+	err = a.Q.ValidateJob(newJobFromReq(req, user))
+	if verr, ok := err.(*queue.ValidationError); ok {
+		resp.Status = RESP_CODE_OK
+		resp.Message = "The job parameters do not match what the tool accepts."
+		resp.Errors = newAPIFieldErrors(verr.Fields)
+
+		rw.WriteHeader(RESP_CODE_OK)
+		respJSON.Encode(resp)
+		return
+	}
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to validate the job: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
 
-	// Build the response structure
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
-	resp.Job.ID = job.UUID
-	resp.Job.Name = job.Name
-	resp.Job.Status = job.Status
-	resp.Job.ResourceID = job.ResAssigned
-	resp.Job.Owner = job.Owner
-	resp.Job.StartTime = job.StartTime
-	resp.Job.ETC = job.ETC
-	resp.Job.CrackedHashes = job.CrackedHashes
-	resp.Job.TotalHashes = job.TotalHashes
-	resp.Job.Progress = job.Progress
-	resp.Job.Params = job.Parameters
-	resp.Job.ToolID = job.ToolUUID
-	resp.Job.PerformanceTitle = job.PerformanceTitle
-	resp.Job.PerformanceData = job.PerformanceData
-	resp.Job.OutputTitles = job.OutputTitles
-	resp.Job.OutputData = job.OutputData
+	resp.Valid = true
+	resp.Errors = []APIFieldError{}
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
-
-	log.WithFields(log.Fields{
-		"uuid": job.UUID,
-		"name": job.Name,
-	}).Info("Job detailed information gathered.")
 }
 
-// Update a job
-func (a *AppController) UpdateJob(rw http.ResponseWriter, r *http.Request) {
+// Estimate how long a new job would take without creating it (POST -
+// /api/jobs/estimate). The request is the same as for creating a job.
+func (a *AppController) EstimateJob(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var req JobUpdateReq
-	var resp JobUpdateResp
+	var req JobCreateReq
+	var resp JobEstimateResp
 
 	// JSON Encoder and Decoder
 	reqJSON := json.NewDecoder(r.Body)
@@ -640,9 +715,7 @@ func (a *AppController) UpdateJob(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("token", token).Warn("An unknown user token attempted to update job data.")
-
+		log.Warn("An unknown token attempted to estimate a job.")
 		return
 	}
 
@@ -654,87 +727,98 @@ func (a *AppController) UpdateJob(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("user", user).Warn("An unauthorized user attempted to update job data.")
-
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to estimate a job.")
 		return
 	}
 
 	// Decode the request
 	err := reqJSON.Decode(&req)
 	if err != nil {
+		log.WithField("err", err).Error("Error parsing the request.")
 		resp.Status = RESP_CODE_BADREQ
 		resp.Message = RESP_CODE_BADREQ_T
 
 		rw.WriteHeader(RESP_CODE_BADREQ)
 		respJSON.Encode(resp)
-
-		log.Error("An error occured while trying to decode updated job data.")
-
 		return
 	}
 
-	// Get the ID of the job we want
-	jobid := mux.Vars(r)["id"]
-
-	// Get the action requested
-	switch req.Status {
-	case "pause":
-		// Pause the job
-		err = a.Q.PauseJob(jobid)
-		if err != nil {
-			resp.Status = RESP_CODE_ERROR
-			resp.Message = "Unable to pause the job: " + err.Error()
-
-			rw.WriteHeader(RESP_CODE_ERROR)
-			respJSON.Encode(resp)
-			return
-		}
-	case "quit":
-		// Stop the job
-		err = a.Q.QuitJob(jobid)
-		if err != nil {
-			resp.Status = RESP_CODE_ERROR
-			resp.Message = "Unable to stop the job: " + err.Error()
+	est, err := a.Q.EstimateJob(newJobFromReq(req, user))
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to estimate the job: " + err.Error()
 
-			rw.WriteHeader(RESP_CODE_ERROR)
-			respJSON.Encode(resp)
-			return
-		}
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
 	}
 
-	// Now return everything is good and the job info
-	j := a.Q.JobInfo(jobid)
-
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
-	resp.Job.ID = j.UUID
-	resp.Job.Name = j.Name
-	resp.Job.Status = j.Status
-	resp.Job.ResourceID = j.ResAssigned
-	resp.Job.Owner = j.Owner
-	resp.Job.StartTime = j.StartTime
-	resp.Job.ETC = j.ETC
-	resp.Job.CrackedHashes = j.CrackedHashes
-	resp.Job.TotalHashes = j.TotalHashes
-	resp.Job.Progress = j.Progress
-	resp.Job.ToolID = j.ToolUUID
+	resp.Candidates = est.Candidates
+	resp.Mode = est.Mode
+	resp.MinSeconds = est.Fastest.Seconds()
+	resp.MaxSeconds = est.Slowest.Seconds()
+	resp.Resources = []APIResourceEstimate{}
+	for _, re := range est.Resources {
+		resp.Resources = append(resp.Resources, APIResourceEstimate{
+			ResourceID: re.Resource,
+			Name:       re.Name,
+			Speed:      re.Speed,
+			Seconds:    re.Duration.Seconds(),
+		})
+	}
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
 	log.WithFields(log.Fields{
-		"uuid":   j.UUID,
-		"name":   j.Name,
-		"status": j.Status,
-	}).Info("Job information updated.")
+		"user":       user.Username,
+		"candidates": est.Candidates,
+		"fastest":    est.Fastest,
+	}).Debug("Job estimated.")
 }
 
-func (a *AppController) DeleteJob(rw http.ResponseWriter, r *http.Request) {
+// Build a job from a create job request
+func newJobFromReq(req JobCreateReq, user User) common.Job {
+	// Some types might not be strings so let's build a map for the params input
+	params := paramsToStrings(req.Params)
+
+	job := common.NewJob(req.ToolID, req.Name, user.Username, params)
+	job.Benchmark = false // Benchmarks are only made by the Queue (See Queue.Benchmark)
+	job.OwnerRole = user.EffectiveRole()
+	job.FeedFrom = req.FeedFrom
+	job.NotBefore = req.NotBefore
+	job.Deadline = req.Deadline
+	job.Placement = common.Placement{Require: req.Placement.Require, Prefer: req.Placement.Prefer, Avoid: req.Placement.Avoid}
+	if req.MaxRuntime > 0 {
+		job.MaxRuntime = time.Duration(req.MaxRuntime) * time.Second
+	}
+	for _, d := range req.Dependencies {
+		job.Dependencies = append(job.Dependencies, common.Dependency{JobUUID: d.JobID, Condition: d.Condition})
+	}
+
+	return job
+}
+
+// Build the API structure of the problems with a job's parameters
+func newAPIFieldErrors(fields []common.FieldError) []APIFieldError {
+	errs := make([]APIFieldError, 0, len(fields))
+	for _, f := range fields {
+		errs = append(errs, APIFieldError{Field: f.Field, Message: f.Message})
+	}
+
+	return errs
+}
+
+// Create a new job from an existing one (POST - /api/jobs/{id}/clone)
+func (a *AppController) CloneJob(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var resp JobDeleteResp
+	var req JobCloneReq
+	var resp JobCreateResp
 
-	// JSON Encoders and Decoders
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
 	respJSON := json.NewEncoder(rw)
 
 	// Get the authorization header
@@ -746,9 +830,7 @@ func (a *AppController) DeleteJob(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("token", token).Warn("An unknown user token attempted to delete a job.")
-
+		log.Warn("An unknown token attempted to clone a job.")
 		return
 	}
 
@@ -760,50 +842,89 @@ func (a *AppController) DeleteJob(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to clone a job.")
+		return
+	}
 
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to delete a job.")
+	// Decode the request, an empty body clones the job as it was
+	err := reqJSON.Decode(&req)
+	if err != nil && err != io.EOF {
+		log.WithField("err", err).Error("Error parsing the request.")
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
 
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
 		return
 	}
 
-	// Get the ID of the job we want
+	// Get the ID of the job to clone
 	jobid := mux.Vars(r)["id"]
 
-	// Remove the job
-	err := a.Q.RemoveJob(jobid)
+	job, err := a.Q.CloneJob(jobid, user.Username, paramsToStrings(req.Params), req.Remaining)
 	if err != nil {
-		resp.Status = RESP_CODE_ERROR
-		resp.Message = "An error occured while trying to delete a job: " + err.Error()
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to clone the job: " + err.Error()
 
-		rw.WriteHeader(RESP_CODE_ERROR)
+		rw.WriteHeader(RESP_CODE_BADREQ)
 		respJSON.Encode(resp)
+		return
+	}
+	job.OwnerRole = user.EffectiveRole()
+	if req.Name != "" {
+		job.Name = req.Name
+	}
 
-		log.WithFields(log.Fields{
-			"jobid": jobid,
-			"error": err.Error(),
-		}).Error("An error occured while trying to delete a job.")
+	err = a.Q.AddJob(job)
+	if _, ok := err.(*queue.QuotaError); ok {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = err.Error()
 
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
 		return
 	}
+	if verr, ok := err.(*queue.ValidationError); ok {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "The job parameters do not match what the tool accepts."
+		resp.Errors = newAPIFieldErrors(verr.Fields)
 
-	// Job should now be removed, so return all OK
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the job: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	// Job was created so populate the response structure and return
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
+	resp.JobID = job.UUID
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
 	log.WithFields(log.Fields{
-		"jobid": jobid,
-	}).Info("Job deleted.")
+		"uuid":      job.UUID,
+		"from":      jobid,
+		"remaining": req.Remaining,
+	}).Info("Job cloned.")
 }
 
-// List Resource API function
-func (a *AppController) ListResource(rw http.ResponseWriter, r *http.Request) {
-	// Response and Request structure
-	var resp ResListResp
+// Pause, quit, delete or change the priority of many jobs (POST - /api/jobs/bulk)
+func (a *AppController) BulkJobs(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req JobBulkReq
+	var resp JobBulkResp
 
-	// JSON Encoders and Decoders
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
 	respJSON := json.NewEncoder(rw)
 
 	// Get the authorization header
@@ -815,9 +936,7 @@ func (a *AppController) ListResource(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("token", token).Warn("An unknown user token attempted to list resources.")
-
+		log.Warn("An unknown token attempted a bulk job action.")
 		return
 	}
 
@@ -829,62 +948,1971 @@ func (a *AppController) ListResource(rw http.ResponseWriter, r *http.Request) {
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted a bulk job action.")
+		return
+	}
 
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to list resources.")
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		log.WithField("err", err).Error("Error parsing the request.")
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
 
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
 		return
 	}
 
-	// First we need to loop through all resource managers
-	for managerid, manager := range a.Q.AllResourceManagers() {
-		//Then  we need to loop through all resources controlled by the manager
-		for _, resourceid := range manager.GetManagedResources() {
-			resource, params, err := manager.GetResource(resourceid)
+	filter := queue.JobFilter{
+		Owner:  req.Filter.Owner,
+		Status: req.Filter.Status,
+		Tool:   req.Filter.Tool,
+		Name:   req.Filter.Name,
+	}
 
-			if err != nil {
-				log.WithField("resourceid", resourceid).Error("Unable to find resource in queue when provided by manager while gathering API resource list.")
+	// Never act on every job by accident
+	if len(req.IDs) == 0 && filter.Empty() {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "Job IDs or a filter must be given for a bulk action."
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	switch req.Action {
+	case "pause", "quit", "delete":
+	case "priority":
+		// Only administrators can change the priority of jobs
+		if !user.Allowed(Administrator) {
+			resp.Status = RESP_CODE_UNAUTHORIZED
+			resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+			rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+			respJSON.Encode(resp)
+			log.WithField("user", user.Username).Warn("A non-administrator attempted to change job priority.")
+			return
+		}
+	default:
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "Unknown bulk action " + req.Action + "."
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	// Pick out the jobs, keeping to the IDs given if there are any
+	matched := a.Q.FindJobs(filter)
+	var jobs []common.Job
+	if len(req.IDs) == 0 {
+		jobs = matched
+	} else {
+		byID := map[string]common.Job{}
+		for _, j := range matched {
+			byID[j.UUID] = j
+		}
+
+		for _, id := range req.IDs {
+			j, ok := byID[id]
+			if !ok {
+				resp.Results = append(resp.Results, APIBulkResult{JobID: id, Error: "Job does not exist or does not match the filter."})
+				continue
+			}
+			jobs = append(jobs, j)
+		}
+	}
+
+	var done int
+	for _, j := range jobs {
+		result := APIBulkResult{JobID: j.UUID}
+
+		// Users can only act on their own jobs unless they are an administrator
+		if j.Owner != user.Username && !user.Allowed(Administrator) {
+			result.Error = RESP_CODE_UNAUTHORIZED_T
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		switch req.Action {
+		case "pause":
+			err = a.Q.PauseJob(j.UUID, user.Username)
+		case "quit":
+			err = a.Q.QuitJob(j.UUID, user.Username)
+		case "delete":
+			err = a.Q.RemoveJob(j.UUID, user.Username)
+		case "priority":
+			err = a.Q.SetJobPriority(j.UUID, req.Priority)
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+			done++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = fmt.Sprintf("%d of %d jobs updated.", done, len(resp.Results))
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"user":   user.Username,
+		"action": req.Action,
+		"jobs":   len(resp.Results),
+		"done":   done,
+	}).Info("Bulk job action completed.")
+}
+
+// Read an individual Job (GET - /api/jobs/{id})
+func (a *AppController) ReadJob(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp JobReadResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to read job data.")
+
+		return
+	}
+
+	// Get the ID of the job we want
+	jobid := mux.Vars(r)["id"]
+
+	// Pull Job info from the Queue. The chunks come from the same snapshot so
+	// they agree with the job.
+	snap := a.Q.Snapshot()
+	job := snap.Job(jobid)
+
+	// Build the response structure
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Job.ID = job.UUID
+	resp.Job.Name = job.Name
+	resp.Job.Status = job.Status
+	resp.Job.ResourceID = job.ResAssigned
+	resp.Job.Owner = job.Owner
+	resp.Job.StartTime = job.StartTime
+	resp.Job.ETC = job.ETC
+	resp.Job.CrackedHashes = job.CrackedHashes
+	resp.Job.TotalHashes = job.TotalHashes
+	resp.Job.Progress = job.Progress
+	resp.Job.Params = job.Parameters
+	resp.Job.ToolID = job.ToolUUID
+	resp.Job.Priority = job.Priority
+	resp.Job.PerformanceTitle = job.PerformanceTitle
+	resp.Job.PerformanceData = job.PerformanceData
+	resp.Job.OutputTitles = job.OutputTitles
+	resp.Job.OutputData = job.OutputData
+	resp.Job.Keyspace = job.Keyspace
+	resp.Job.FeedFrom = job.FeedFrom
+	resp.Job.NotBefore = job.NotBefore
+	resp.Job.MaxRuntime = int64(job.MaxRuntime / time.Second)
+	resp.Job.Deadline = job.Deadline
+	resp.Job.RunTime = int64(job.RunTime / time.Second)
+	resp.Job.RetryAfter = job.RetryAfter
+	resp.Job.Placement = APIPlacement{Require: job.Placement.Require, Prefer: job.Placement.Prefer, Avoid: job.Placement.Avoid}
+	for _, a := range job.Attempts {
+		resp.Job.Attempts = append(resp.Job.Attempts, APIAttempt{ResourceID: a.ResourceUUID, Started: a.Started, Ended: a.Ended, Error: a.Error})
+	}
+	resp.Job.Error = job.Error
+	resp.Job.Waiting = job.Waiting
+	for _, d := range job.Dependencies {
+		resp.Job.Dependencies = append(resp.Job.Dependencies, APIDependency{JobID: d.JobUUID, Condition: d.Condition})
+	}
+
+	// Add the keyspace chunks if this job was split
+	if job.Chunked {
+		for _, c := range snap.Jobs {
+			if c.ParentUUID != job.UUID {
 				continue
 			}
 
-			var outresource APIResource
-			outresource.Manager = managerid
-			outresource.ID = resourceid
-			outresource.Name = resource.Name
-			outresource.Status = resource.Status
-			outresource.Address = resource.Address
-			outresource.Params = params
+			resp.Job.Chunks = append(resp.Job.Chunks, newAPIJob(c))
+		}
+	}
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid": job.UUID,
+		"name": job.Name,
+	}).Info("Job detailed information gathered.")
+}
+
+// Read the events of a job (GET - /api/jobs/{id}/events)
+func (a *AppController) ReadJobEvents(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp JobEventsResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to read job events.")
+
+		return
+	}
+
+	// Get the ID of the job we want
+	jobid := mux.Vars(r)["id"]
+
+	events, ok := a.Q.JobEvents(jobid)
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Events = []APIJobEvent{}
+	for _, e := range events {
+		resp.Events = append(resp.Events, APIJobEvent{
+			Time:       e.Time,
+			Type:       e.Type,
+			Status:     e.Status,
+			ResourceID: e.Resource,
+			User:       e.User,
+			Message:    e.Message,
+		})
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithField("job", jobid).Debug("Job events read.")
+}
+
+// Update a job
+func (a *AppController) UpdateJob(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req JobUpdateReq
+	var resp JobUpdateResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to update job data.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user).Warn("An unauthorized user attempted to update job data.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.Error("An error occured while trying to decode updated job data.")
+
+		return
+	}
+
+	// Get the ID of the job we want
+	jobid := mux.Vars(r)["id"]
+
+	// Only administrators can change the priority of a job
+	if req.Priority != nil {
+		if !user.Allowed(Administrator) {
+			resp.Status = RESP_CODE_UNAUTHORIZED
+			resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+			rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+			respJSON.Encode(resp)
+
+			log.WithField("user", user).Warn("A non-administrator attempted to change job priority.")
+
+			return
+		}
+
+		err = a.Q.SetJobPriority(jobid, *req.Priority)
+		if err != nil {
+			resp.Status = RESP_CODE_ERROR
+			resp.Message = "Unable to set the job priority: " + err.Error()
+
+			rw.WriteHeader(RESP_CODE_ERROR)
+			respJSON.Encode(resp)
+			return
+		}
+	}
+
+	// Get the action requested
+	switch req.Status {
+	case "pause":
+		// Pause the job
+		err = a.Q.PauseJob(jobid, user.Username)
+		if err != nil {
+			resp.Status = RESP_CODE_ERROR
+			resp.Message = "Unable to pause the job: " + err.Error()
+
+			rw.WriteHeader(RESP_CODE_ERROR)
+			respJSON.Encode(resp)
+			return
+		}
+	case "quit":
+		// Stop the job
+		err = a.Q.QuitJob(jobid, user.Username)
+		if err != nil {
+			resp.Status = RESP_CODE_ERROR
+			resp.Message = "Unable to stop the job: " + err.Error()
+
+			rw.WriteHeader(RESP_CODE_ERROR)
+			respJSON.Encode(resp)
+			return
+		}
+	}
+
+	// Now return everything is good and the job info
+	j := a.Q.JobInfo(jobid)
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Job.ID = j.UUID
+	resp.Job.Name = j.Name
+	resp.Job.Status = j.Status
+	resp.Job.ResourceID = j.ResAssigned
+	resp.Job.Owner = j.Owner
+	resp.Job.StartTime = j.StartTime
+	resp.Job.ETC = j.ETC
+	resp.Job.CrackedHashes = j.CrackedHashes
+	resp.Job.TotalHashes = j.TotalHashes
+	resp.Job.Progress = j.Progress
+	resp.Job.ToolID = j.ToolUUID
+	resp.Job.Priority = j.Priority
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid":   j.UUID,
+		"name":   j.Name,
+		"status": j.Status,
+	}).Info("Job information updated.")
+}
+
+func (a *AppController) DeleteJob(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp JobDeleteResp
+
+	// JSON Encoders and Decoders
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to delete a job.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to delete a job.")
+
+		return
+	}
+
+	// Get the ID of the job we want
+	jobid := mux.Vars(r)["id"]
+
+	// Remove the job
+	err := a.Q.RemoveJob(jobid, user.Username)
+	if err != nil {
+		resp.Status = RESP_CODE_ERROR
+		resp.Message = "An error occured while trying to delete a job: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_ERROR)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"jobid": jobid,
+			"error": err.Error(),
+		}).Error("An error occured while trying to delete a job.")
+
+		return
+	}
+
+	// Job should now be removed, so return all OK
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"jobid": jobid,
+	}).Info("Job deleted.")
+}
+
+// List Resource API function
+func (a *AppController) ListResource(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structure
+	var resp ResListResp
+
+	// JSON Encoders and Decoders
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to list resources.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to list resources.")
+
+		return
+	}
+
+	// Every resource is listed from the same snapshot of the queue
+	snap := a.Q.Snapshot()
+
+	// First we need to loop through all resource managers
+	for managerid, manager := range a.Q.AllResourceManagers() {
+		//Then  we need to loop through all resources controlled by the manager
+		for _, resourceid := range manager.GetManagedResources() {
+			resource, params, err := manager.GetResource(resourceid)
+
+			if err != nil {
+				log.WithField("resourceid", resourceid).Error("Unable to find resource in queue when provided by manager while gathering API resource list.")
+				continue
+			}
+
+			if res, ok := snap.Resources[resourceid]; ok {
+				resource = &res
+			}
+
+			var outresource APIResource
+			outresource.Manager = managerid
+			outresource.ID = resourceid
+			outresource.Name = resource.Name
+			outresource.Status = resource.Status
+			outresource.Address = resource.Address
+			outresource.Params = params
+			outresource.Labels = resource.AllLabels()
+
+			for _, t := range resource.Tools {
+				outresource.Tools = append(outresource.Tools, APITool{t.UUID, t.Name, t.Version})
+			}
+
+			resp.Resources = append(resp.Resources, outresource)
+
+			log.WithFields(log.Fields{
+				"id":      resourceid,
+				"name":    resource.Name,
+				"addr":    resource.Address,
+				"manager": managerid,
+			}).Debug("Gathered resource information.")
+		}
+	}
+
+	// Job should now be removed, so return all OK
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.Info("Listing of resources provided to API.")
+}
+
+func (a *AppController) CreateResource(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req ResCreateReq
+	var resp ResCreateResp
+
+	// JSON Encoders and Decoders
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to connect to a resource.")
+
+		return
+	}
+
+	// Check for Administrators user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(Administrator) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to connect to a resource.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("An error occured while trying to decode resource creation information.")
+
+		return
+	}
+
+	//First we need to get the appropriate resource manager
+	manager, ok := a.Q.GetResourceManager(req.Manager)
+	//If that resource manager doesn't exist, return a not found error
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "That resource manager does not exist."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"manager": req.Manager,
+		}).Warn("Unable to find requested resource manager.")
+
+		return
+	}
+
+	// Now let's try and add the resource itself.
+	err = manager.AddResource(req.Params)
+
+	// If there was an error returned by the resource manager, let's go ahead and return an error to the user.
+	if err != nil {
+		resp.Status = RESP_CODE_ERROR
+		resp.Message = "An error occured when trying to add the resource: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_ERROR)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"error":   err.Error(),
+			"manager": req.Manager,
+		}).Error("An error occured adding a resource.")
+
+		return
+	}
+
+	// At this point, the resource should be added, we can return success.
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"manager": req.Manager,
+	}).Info("Resource successfully added.")
+}
+
+func (a *AppController) ReadResource(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ResReadResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to get resource information.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to get resource information.")
+
+		return
+	}
+
+	// Get the resource ID and manager name from URL
+	resID := mux.Vars(r)["id"]
+	managerName := mux.Vars(r)["manager"]
+
+	// Get the resource manager as defined in the URL
+	manager, ok := a.Q.GetResourceManager(managerName)
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "The requested resource manager was not found."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithField("resource", resID).Warn("Resource manager details could not be found.")
+	}
+
+	// Get the resource
+	resource, params, err := manager.GetResource(resID)
+	if err != nil {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "The requested resource was not found."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithField("resource", resID).Warn("Resource details were requested and could not be found.")
+	}
+
+	// Found the resource so set it to the response
+	resp.Resource.ID = resID
+	resp.Resource.Name = resource.Name
+	resp.Resource.Address = resource.Address
+	resp.Resource.Status = resource.Status
+	resp.Resource.Params = params
+	resp.Resource.Manager = manager.SystemName()
+	resp.Resource.Labels = resource.AllLabels()
+
+	log.WithFields(log.Fields{
+		"uuid":    resID,
+		"name":    resource.Name,
+		"addr":    resource.Address,
+		"manager": manager.SystemName(),
+	}).Debug("Gathered resource information.")
+
+	for _, t := range resource.Tools {
+		resp.Resource.Tools = append(resp.Resource.Tools, APITool{t.UUID, t.Name, t.Version})
+		log.WithFields(log.Fields{
+			"uuid": t.UUID,
+			"name": t.Name,
+			"ver":  t.Version,
+		}).Debug("Tool configured on resource gathered.")
+	}
+
+	// TODO (mcatee): Add a check for no found resource and return correct status codes
+
+	// Build good response
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithField("name", resp.Resource.Name).Info("Information gathered on resource.")
+}
+
+func (a *AppController) ListBenchmarks(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ResBenchmarksResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to get resource benchmarks.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to get resource benchmarks.")
+
+		return
+	}
+
+	// Get the resource ID from the URL
+	resID := mux.Vars(r)["id"]
+
+	// A resource that has not run anything yet has no speeds but still exists
+	speeds, ok := a.Q.ResourceSpeeds(resID)
+	if _, exists := a.Q.GetResource(resID); !ok && !exists {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "The requested resource was not found."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithField("resource", resID).Warn("Benchmarks were requested for a resource that could not be found.")
+
+		return
+	}
+
+	resp.Benchmarks = []APIBenchmark{}
+	for _, s := range speeds {
+		resp.Benchmarks = append(resp.Benchmarks, APIBenchmark{
+			Tool:    s.Tool,
+			Mode:    s.Mode,
+			Speed:   s.Speed,
+			Source:  s.Source,
+			Updated: s.Updated,
+		})
+	}
+
+	// Build good response
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"resource": resID,
+		"speeds":   len(speeds),
+	}).Debug("Gathered resource benchmarks.")
+}
+
+func (a *AppController) CreateBenchmark(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req ResBenchmarkReq
+	var resp JobCreateResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to benchmark a resource.")
+
+		return
+	}
+
+	// Check for Administrator user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(Administrator) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to benchmark a resource.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("An error occured while trying to decode benchmark data.")
+
+		return
+	}
+
+	// Get the resource ID
+	resID := mux.Vars(r)["id"]
+
+	job, err := a.Q.Benchmark(resID, req.ToolID, req.Mode, user.Username)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to benchmark the resource: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"resource": resID,
+			"error":    err.Error(),
+		}).Warn("Unable to build a benchmark job.")
+
+		return
+	}
+	job.OwnerRole = user.EffectiveRole()
+
+	err = a.Q.AddJob(job)
+	if _, ok := err.(*queue.QuotaError); ok {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = err.Error()
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+		log.WithFields(log.Fields{
+			"user":  user.Username,
+			"error": err.Error(),
+		}).Warn("Benchmark job refused by quota.")
+		return
+	}
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the benchmark job: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("Error adding a benchmark job to the queue.")
+
+		return
+	}
+
+	// Job was created so populate the response structure and return
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.JobID = job.UUID
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"resource": resID,
+		"job":      job.UUID,
+		"mode":     req.Mode,
+	}).Info("Benchmark job created.")
+}
+
+func (a *AppController) UpdateResource(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req ResUpdateReq
+	var resp ResUpdateResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to update resource information.")
+
+		return
+	}
+
+	// Check for Administrator user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(Administrator) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to update resource information.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("An error occured while trying to decode resource update data.")
+
+		return
+	}
+
+	// Get the resource ID
+	resID := mux.Vars(r)["id"]
+	managerName := req.Manager
+
+	// Get the manager for the resource
+	manager, manok := a.Q.GetResourceManager(managerName)
+
+	//If that resource manager doesn't exist, return a not found error
+	if !manok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "That resource manager does not exist."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"manager":  managerName,
+			"resource": resID,
+		}).Warn("Unable to find requested manager to update resource.")
+
+		return
+	}
+
+	switch req.Status {
+	case common.STATUS_QUIT:
+		log.WithFields(log.Fields{
+			"manager":  manager.SystemName(),
+			"resource": resID,
+			"status":   req.Status,
+		}).Info("Quiting resource status.")
+
+		// Quit the resource
+		err = manager.DeleteResource(resID)
+		if err != nil {
+			resp.Status = RESP_CODE_ERROR
+			resp.Message = "An error occured while trying to quit that resource: " + err.Error()
+
+			rw.WriteHeader(RESP_CODE_ERROR)
+			respJSON.Encode(resp)
+
+			log.WithFields(log.Fields{
+				"manager":  manager.SystemName(),
+				"error":    err.Error(),
+				"resource": resID,
+			}).Error("An error occured while trying to quit a resource.")
+
+			return
+		}
+	case common.STATUS_PAUSED, common.STATUS_RUNNING:
+		log.WithFields(log.Fields{
+			"manager":  manager.SystemName(),
+			"resource": resID,
+			"status":   req.Status,
+		}).Info("Updating resource status.")
+
+		// Pause or resume the resource
+		err = manager.UpdateResource(resID, req.Status, req.Params)
+		if err != nil {
+			resp.Status = RESP_CODE_ERROR
+			resp.Message = "An error occured while trying to update that resource: " + err.Error()
+
+			rw.WriteHeader(RESP_CODE_ERROR)
+			respJSON.Encode(resp)
+
+			log.WithFields(log.Fields{
+				"manager":  manager.SystemName(),
+				"error":    err.Error(),
+				"resource": resID,
+			}).Error("An error occured while trying to update a resource.")
+
+			return
+		}
+	}
+
+	// Build good response because we were able to get here
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"resource": resID,
+		"status":   req.Status,
+	}).Info("Resource updated.")
+}
+
+func (a *AppController) DeleteResources(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ResDeleteResp
+	var req ResDeleteReq
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+	reqJSON := json.NewDecoder(r.Body)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to delete a resource.")
+
+		return
+	}
+
+	// Check for Administrator user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(Administrator) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to delete a resource.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("An error occured while trying to decode resource delete data.")
+
+		return
+	}
+
+	// Get the resource ID
+	resID := mux.Vars(r)["id"]
+	managerName := req.Manager
+
+	// Get the manager for the resource
+	manager, manok := a.Q.GetResourceManager(managerName)
+
+	//If that resource manager doesn't exist, return a not found error
+	if !manok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "That resource manager does not exist."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"manager":  managerName,
+			"resource": resID,
+		}).Warn("Unable to find requested manager to update resource.")
+
+		return
+	}
+
+	// Get the resource
+	resource, _, err := manager.GetResource(resID)
+
+	// If that resource doesn't exist, let's throw an error
+	if err != nil {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = "That resource does not exist."
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"manager": manager.SystemName(),
+			"addr":    resource.Address,
+			"name":    resource.Name,
+		}).Warn("Unable to find requested resource to update.")
+
+		return
+	}
+
+	// Remove the resource
+	err = manager.DeleteResource(resID)
+	if err != nil {
+		resp.Status = RESP_CODE_ERROR
+		resp.Message = "An error occured while trying to delete that resource: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_ERROR)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"error":    err.Error(),
+			"resource": resID,
+		}).Error("An error occured while trying to delete a resource.")
+
+		return
+	}
+
+	// Build good response
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithField("resource", resID).Info("Resource disconnected.")
+}
+
+/*
+	Handler for the PUT /api/queue function in our API that is used, for now to
+	handle updates to the order of jobs in the queue.
+*/
+func (a *AppController) ReorderQueue(rw http.ResponseWriter, r *http.Request) {
+	// Structurs to hold our request and response from Negroni, see api_struct.go
+	var req QueueUpdateReq
+	var resp QueueUpdateResp
+
+	// A decoder to take the JSON information passed by the API and return it
+	reqJSON := json.NewDecoder(r.Body)
+	// An encoder to take our response and give it back to the user
+	respJSON := json.NewEncoder(rw)
+
+	// First, we handle authentication through the header
+	token := r.Header.Get("AuthorizationToken")
+	if !a.T.CheckToken(token) {
+		//If the token is unknown, send back an unauthenticated message
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to reorder the queue.")
+
+		return
+	}
+
+	// Let's then check to make sure the user has the right group, in this case standard
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		//If not, send back the proper response.
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to reorder the queue.")
+
+		return
+	}
+
+	// Decode the request data that we recieved into our struct
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		// If there is an error, let the API know via HTTP
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("An error occured while trying to decode queue update data.")
+
+		return
+	}
+
+	// Let's try and actually reorder the stack
+	err = a.Q.StackReorder(req.JobOrder)
+	if err != nil {
+		//If there was an error, send the code to the API
+		resp.Status = RESP_CODE_ERROR
+		resp.Message = err.Error()
+
+		rw.WriteHeader(RESP_CODE_ERROR)
+		respJSON.Encode(resp)
+
+		log.WithField("error", err.Error()).Error("An error occured while trying to update the queue order.")
+
+		return
+	}
+
+	// Finally, we did it successfully!
+	log.Info("Queue reodered successfully")
+}
+
+// List all pipelines (GET - /api/pipelines)
+func (a *AppController) ListPipelines(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp PipelineListResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to list pipelines.")
+
+		return
+	}
+
+	for _, p := range a.Q.AllPipelines() {
+		resp.Pipelines = append(resp.Pipelines, a.newAPIPipeline(p))
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+}
+
+// Create a new pipeline of chained jobs (POST - /api/pipelines)
+func (a *AppController) CreatePipeline(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req PipelineCreateReq
+	var resp PipelineCreateResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to create a pipeline.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to create a pipeline.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil || len(req.Stages) == 0 {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.Error("An error occured while trying to decode pipeline data.")
+
+		return
+	}
+
+	// Build a job for each stage
+	var stages []queue.PipelineStage
+	for _, s := range req.Stages {
+		job := common.NewJob(s.ToolID, s.Name, user.Username, paramsToStrings(s.Params))
+		job.OwnerRole = user.EffectiveRole()
+
+		stages = append(stages, queue.PipelineStage{
+			Job:       job,
+			Condition: s.Condition,
+			Feed:      s.Feed,
+		})
+	}
+
+	p, err := a.Q.AddPipeline(req.Name, user.Username, stages)
+	if _, ok := err.(*queue.QuotaError); ok {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = err.Error()
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+		return
+	}
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the pipeline: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.PipelineID = p.UUID
+	resp.JobIDs = p.Jobs
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid":   p.UUID,
+		"name":   p.Name,
+		"stages": len(p.Jobs),
+	}).Info("New pipeline created.")
+}
+
+// Read an individual pipeline (GET - /api/pipelines/{id})
+func (a *AppController) ReadPipeline(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp PipelineReadResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to read pipeline data.")
+
+		return
+	}
+
+	// Get the ID of the pipeline we want
+	p, ok := a.Q.PipelineInfo(mux.Vars(r)["id"])
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Pipeline = a.newAPIPipeline(p)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+}
+
+// Build the API structure of a pipeline with the current state of its jobs
+func (a *AppController) newAPIPipeline(p queue.Pipeline) APIPipeline {
+	out := APIPipeline{
+		ID:      p.UUID,
+		Name:    p.Name,
+		Owner:   p.Owner,
+		Created: p.Created,
+	}
+
+	for _, id := range p.Jobs {
+		if j := a.Q.JobInfo(id); j.UUID != "" {
+			out.Jobs = append(out.Jobs, newAPIJob(j))
+		}
+	}
+
+	return out
+}
+
+// Build the API listing structure of a job
+func newAPIJob(j common.Job) APIJob {
+	return APIJob{
+		ID:            j.UUID,
+		Name:          j.Name,
+		Status:        j.Status,
+		ResourceID:    j.ResAssigned,
+		Owner:         j.Owner,
+		StartTime:     j.StartTime,
+		ETC:           j.ETC,
+		CrackedHashes: j.CrackedHashes,
+		TotalHashes:   j.TotalHashes,
+		Progress:      j.Progress,
+		ToolID:        j.ToolUUID,
+		ParentID:      j.ParentUUID,
+		Priority:      j.Priority,
+	}
+}
+
+// Some parameter types might not be strings so build a string map for the tools
+func paramsToStrings(in map[string]interface{}) map[string]string {
+	params := map[string]string{}
+	for key, value := range in {
+		switch v := value.(type) {
+		case string:
+			params[key] = v
+		case bool:
+			params[key] = strconv.FormatBool(v)
+		case int:
+			params[key] = strconv.Itoa(v)
+		case float64:
+			params[key] = strconv.FormatFloat(v, 'g', -1, 64)
+		case float32:
+			params[key] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+
+		}
+	}
+
+	return params
+}
+
+// List all schedules (GET - /api/schedules)
+func (a *AppController) ListSchedules(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ScheduleListResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to list schedules.")
+
+		return
+	}
+
+	for _, s := range a.Q.AllSchedules() {
+		resp.Schedules = append(resp.Schedules, newAPISchedule(s))
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+}
+
+// Create a new recurring job schedule (POST - /api/schedules)
+func (a *AppController) CreateSchedule(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req ScheduleReq
+	var resp ScheduleResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to create a schedule.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to create a schedule.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.Error("An error occured while trying to decode schedule data.")
+
+		return
+	}
+
+	s := queue.Schedule{
+		Name:       req.Name,
+		Owner:      user.Username,
+		OwnerRole:  user.EffectiveRole(),
+		Cron:       req.Cron,
+		Enabled:    req.Enabled,
+		ToolUUID:   req.ToolID,
+		JobName:    req.JobName,
+		Parameters: paramsToStrings(req.Params),
+	}
+
+	s, err = a.Q.AddSchedule(s)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the schedule: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Schedule = newAPISchedule(s)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid": s.UUID,
+		"name": s.Name,
+		"cron": s.Cron,
+	}).Info("New schedule created.")
+}
+
+// Read an individual schedule (GET - /api/schedules/{id})
+func (a *AppController) ReadSchedule(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ScheduleResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to read schedule data.")
+
+		return
+	}
+
+	s, ok := a.Q.ScheduleInfo(mux.Vars(r)["id"])
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Schedule = newAPISchedule(s)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+}
+
+// Update a schedule (PUT - /api/schedules/{id})
+func (a *AppController) UpdateSchedule(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req ScheduleReq
+	var resp ScheduleResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to update a schedule.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to update a schedule.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.Error("An error occured while trying to decode schedule data.")
+
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	old, ok := a.Q.ScheduleInfo(id)
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	if !scheduleEditable(user, old) {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = "Only the owner of a schedule or an administrator can change it."
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"user":     user.Username,
+			"schedule": id,
+		}).Warn("A user attempted to update a schedule they do not own.")
+
+		return
+	}
+
+	s := queue.Schedule{
+		UUID:       id,
+		Name:       req.Name,
+		Cron:       req.Cron,
+		Enabled:    req.Enabled,
+		ToolUUID:   req.ToolID,
+		JobName:    req.JobName,
+		Parameters: paramsToStrings(req.Params),
+	}
+
+	s, err = a.Q.UpdateSchedule(s)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to update the schedule: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Schedule = newAPISchedule(s)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid": s.UUID,
+		"name": s.Name,
+	}).Info("Schedule updated.")
+}
+
+// Delete a schedule (DELETE - /api/schedules/{id})
+func (a *AppController) DeleteSchedule(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp ScheduleDeleteResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to delete a schedule.")
+
+		return
+	}
+
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to delete a schedule.")
+
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	s, ok := a.Q.ScheduleInfo(id)
+	if !ok {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	if !scheduleEditable(user, s) {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = "Only the owner of a schedule or an administrator can delete it."
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+
+		log.WithFields(log.Fields{
+			"user":     user.Username,
+			"schedule": id,
+		}).Warn("A user attempted to delete a schedule they do not own.")
+
+		return
+	}
+
+	err := a.Q.RemoveSchedule(id)
+	if err != nil {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = err.Error()
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
 
-			for _, t := range resource.Tools {
-				outresource.Tools = append(outresource.Tools, APITool{t.UUID, t.Name, t.Version})
-			}
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
 
-			resp.Resources = append(resp.Resources, outresource)
+	log.WithField("uuid", id).Info("Schedule deleted.")
+}
 
-			log.WithFields(log.Fields{
-				"id":      resourceid,
-				"name":    resource.Name,
-				"addr":    resource.Address,
-				"manager": managerid,
-			}).Debug("Gathered resource information.")
+// Schedules can only be changed by their owner and administrators
+func scheduleEditable(user User, s queue.Schedule) bool {
+	return s.Owner == user.Username || user.Allowed(Administrator)
+}
+
+// Build the API structure of a schedule
+func newAPISchedule(s queue.Schedule) APISchedule {
+	params := map[string]interface{}{}
+	for k, v := range s.Parameters {
+		params[k] = v
+	}
+
+	return APISchedule{
+		ID:      s.UUID,
+		Name:    s.Name,
+		Owner:   s.Owner,
+		Cron:    s.Cron,
+		Enabled: s.Enabled,
+		ToolID:  s.ToolUUID,
+		JobName: s.JobName,
+		Params:  params,
+		Created: s.Created,
+		NextRun: s.NextRun,
+		LastRun: s.LastRun,
+		LastJob: s.LastJob,
+	}
+}
+
+// Templates can be seen and used by their owner and administrators, or by
+// everyone when they are shared
+func templateVisible(user User, t queue.Template) bool {
+	return t.Shared || t.Owner == user.Username || user.Allowed(Administrator)
+}
+
+// Templates can only be changed by their owner and administrators
+func templateEditable(user User, t queue.Template) bool {
+	return t.Owner == user.Username || user.Allowed(Administrator)
+}
+
+// List all templates the user can use (GET - /api/templates)
+func (a *AppController) ListTemplates(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp TemplateListResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to list templates.")
+
+		return
+	}
+
+	user, _ := a.T.GetUser(token)
+
+	templates, status := a.Q.AllTemplates()
+	for _, t := range templates {
+		if templateVisible(user, t) {
+			resp.Templates = append(resp.Templates, newAPITemplate(t, status[t.UUID]))
 		}
 	}
 
-	// Job should now be removed, so return all OK
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
-
-	log.Info("Listing of resources provided to API.")
 }
 
-func (a *AppController) CreateResource(rw http.ResponseWriter, r *http.Request) {
+// Create a new job template (POST - /api/templates)
+func (a *AppController) CreateTemplate(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var req ResCreateReq
-	var resp ResCreateResp
+	var req TemplateReq
+	var resp TemplateResp
 
-	// JSON Encoders and Decoders
+	// JSON Encoder and Decoder
 	reqJSON := json.NewDecoder(r.Body)
 	respJSON := json.NewEncoder(rw)
 
@@ -898,21 +2926,21 @@ func (a *AppController) CreateResource(rw http.ResponseWriter, r *http.Request)
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("token", token).Warn("An unknown user token attempted to connect to a resource.")
+		log.WithField("token", token).Warn("An unknown user token attempted to create a template.")
 
 		return
 	}
 
-	// Check for Administrators user level at least
+	// Check for standard user level at least
 	user, _ := a.T.GetUser(token)
-	if !user.Allowed(Administrator) {
+	if !user.Allowed(StandardUser) {
 		resp.Status = RESP_CODE_UNAUTHORIZED
 		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to connect to a resource.")
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to create a template.")
 
 		return
 	}
@@ -926,64 +2954,204 @@ func (a *AppController) CreateResource(rw http.ResponseWriter, r *http.Request)
 		rw.WriteHeader(RESP_CODE_BADREQ)
 		respJSON.Encode(resp)
 
-		log.WithFields(log.Fields{
-			"error": err.Error(),
-		}).Error("An error occured while trying to decode resource creation information.")
+		log.Error("An error occured while trying to decode template data.")
 
 		return
 	}
 
-	//First we need to get the appropriate resource manager
-	manager, ok := a.Q.GetResourceManager(req.Manager)
-	//If that resource manager doesn't exist, return a not found error
-	if !ok {
+	t := queue.Template{
+		Name:       req.Name,
+		Owner:      user.Username,
+		Shared:     req.Shared,
+		JobName:    req.JobName,
+		Parameters: paramsToStrings(req.Params),
+	}
+
+	t, err = a.Q.AddTemplate(t, req.ToolID)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the template: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	_, status, _ := a.Q.TemplateInfo(t.UUID)
+
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Template = newAPITemplate(t, status)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+
+	log.WithFields(log.Fields{
+		"uuid": t.UUID,
+		"name": t.Name,
+		"tool": t.ToolName,
+	}).Info("New template created.")
+}
+
+// Read an individual template (GET - /api/templates/{id})
+func (a *AppController) ReadTemplate(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var resp TemplateResp
+
+	// JSON Encoder and Decoder
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to read template data.")
+
+		return
+	}
+
+	user, _ := a.T.GetUser(token)
+
+	t, status, ok := a.Q.TemplateInfo(mux.Vars(r)["id"])
+	if !ok || !templateVisible(user, t) {
 		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "That resource manager does not exist."
+		resp.Message = RESP_CODE_NOTFOUND_T
 
 		rw.WriteHeader(RESP_CODE_NOTFOUND)
 		respJSON.Encode(resp)
+		return
+	}
 
-		log.WithFields(log.Fields{
-			"manager": req.Manager,
-		}).Warn("Unable to find requested resource manager.")
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+	resp.Template = newAPITemplate(t, status)
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
+}
+
+// Update a template (PUT - /api/templates/{id})
+func (a *AppController) UpdateTemplate(rw http.ResponseWriter, r *http.Request) {
+	// Response and Request structures
+	var req TemplateReq
+	var resp TemplateResp
+
+	// JSON Encoder and Decoder
+	reqJSON := json.NewDecoder(r.Body)
+	respJSON := json.NewEncoder(rw)
+
+	// Get the authorization header
+	token := r.Header.Get("AuthorizationToken")
+
+	if !a.T.CheckToken(token) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
+
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("token", token).Warn("An unknown user token attempted to update a template.")
 
 		return
 	}
 
-	// Now let's try and add the resource itself.
-	err = manager.AddResource(req.Params)
+	// Check for standard user level at least
+	user, _ := a.T.GetUser(token)
+	if !user.Allowed(StandardUser) {
+		resp.Status = RESP_CODE_UNAUTHORIZED
+		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
-	// If there was an error returned by the resource manager, let's go ahead and return an error to the user.
+		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
+		respJSON.Encode(resp)
+
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to update a template.")
+
+		return
+	}
+
+	// Decode the request
+	err := reqJSON.Decode(&req)
 	if err != nil {
-		resp.Status = RESP_CODE_ERROR
-		resp.Message = "An error occured when trying to add the resource: " + err.Error()
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = RESP_CODE_BADREQ_T
 
-		rw.WriteHeader(RESP_CODE_ERROR)
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+
+		log.Error("An error occured while trying to decode template data.")
+
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	old, _, ok := a.Q.TemplateInfo(id)
+	if !ok || !templateVisible(user, old) {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = RESP_CODE_NOTFOUND_T
+
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
+	}
+
+	if !templateEditable(user, old) {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = "Only the owner of a template or an administrator can change it."
+
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
 		respJSON.Encode(resp)
 
 		log.WithFields(log.Fields{
-			"error":   err.Error(),
-			"manager": req.Manager,
-		}).Error("An error occured adding a resource.")
+			"user":     user.Username,
+			"template": id,
+		}).Warn("A user attempted to update a template they do not own.")
 
 		return
 	}
 
-	// At this point, the resource should be added, we can return success.
+	t := queue.Template{
+		UUID:       id,
+		Name:       req.Name,
+		Shared:     req.Shared,
+		JobName:    req.JobName,
+		Parameters: paramsToStrings(req.Params),
+	}
+
+	t, err = a.Q.UpdateTemplate(t, req.ToolID)
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to update the template: " + err.Error()
+
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+
+	_, status, _ := a.Q.TemplateInfo(t.UUID)
+
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
+	resp.Template = newAPITemplate(t, status)
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
 	log.WithFields(log.Fields{
-		"manager": req.Manager,
-	}).Info("Resource successfully added.")
+		"uuid": t.UUID,
+		"name": t.Name,
+	}).Info("Template updated.")
 }
 
-func (a *AppController) ReadResource(rw http.ResponseWriter, r *http.Request) {
+// Delete a template (DELETE - /api/templates/{id})
+func (a *AppController) DeleteTemplate(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var resp ResReadResp
+	var resp TemplateDeleteResp
 
 	// JSON Encoder and Decoder
 	respJSON := json.NewEncoder(rw)
@@ -998,7 +3166,7 @@ func (a *AppController) ReadResource(rw http.ResponseWriter, r *http.Request) {
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("token", token).Warn("An unknown user token attempted to get resource information.")
+		log.WithField("token", token).Warn("An unknown user token attempted to delete a template.")
 
 		return
 	}
@@ -1012,79 +3180,61 @@ func (a *AppController) ReadResource(rw http.ResponseWriter, r *http.Request) {
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to get resource information.")
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to delete a template.")
 
 		return
 	}
 
-	// Get the resource ID and manager name from URL
-	resID := mux.Vars(r)["id"]
-	managerName := mux.Vars(r)["manager"]
-
-	// Get the resource manager as defined in the URL
-	manager, ok := a.Q.GetResourceManager(managerName)
-	if !ok {
+	id := mux.Vars(r)["id"]
+	t, _, ok := a.Q.TemplateInfo(id)
+	if !ok || !templateVisible(user, t) {
 		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "The requested resource manager was not found."
+		resp.Message = RESP_CODE_NOTFOUND_T
 
 		rw.WriteHeader(RESP_CODE_NOTFOUND)
 		respJSON.Encode(resp)
-
-		log.WithField("resource", resID).Warn("Resource manager details could not be found.")
+		return
 	}
 
-	// Get the resource
-	resource, params, err := manager.GetResource(resID)
-	if err != nil {
-		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "The requested resource was not found."
+	if !templateEditable(user, t) {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = "Only the owner of a template or an administrator can delete it."
 
-		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
 		respJSON.Encode(resp)
 
-		log.WithField("resource", resID).Warn("Resource details were requested and could not be found.")
-	}
+		log.WithFields(log.Fields{
+			"user":     user.Username,
+			"template": id,
+		}).Warn("A user attempted to delete a template they do not own.")
 
-	// Found the resource so set it to the response
-	resp.Resource.ID = resID
-	resp.Resource.Name = resource.Name
-	resp.Resource.Address = resource.Address
-	resp.Resource.Status = resource.Status
-	resp.Resource.Params = params
-	resp.Resource.Manager = manager.SystemName()
+		return
+	}
 
-	log.WithFields(log.Fields{
-		"uuid":    resID,
-		"name":    resource.Name,
-		"addr":    resource.Address,
-		"manager": manager.SystemName(),
-	}).Debug("Gathered resource information.")
+	err := a.Q.RemoveTemplate(id)
+	if err != nil {
+		resp.Status = RESP_CODE_NOTFOUND
+		resp.Message = err.Error()
 
-	for _, t := range resource.Tools {
-		resp.Resource.Tools = append(resp.Resource.Tools, APITool{t.UUID, t.Name, t.Version})
-		log.WithFields(log.Fields{
-			"uuid": t.UUID,
-			"name": t.Name,
-			"ver":  t.Version,
-		}).Debug("Tool configured on resource gathered.")
+		rw.WriteHeader(RESP_CODE_NOTFOUND)
+		respJSON.Encode(resp)
+		return
 	}
 
-	// TODO (mcatee): Add a check for no found resource and return correct status codes
-
-	// Build good response
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
-	log.WithField("name", resp.Resource.Name).Info("Information gathered on resource.")
+	log.WithField("uuid", id).Info("Template deleted.")
 }
 
-func (a *AppController) UpdateResource(rw http.ResponseWriter, r *http.Request) {
+// Create a job from a template (POST - /api/templates/{id}/jobs)
+func (a *AppController) CreateJobFromTemplate(rw http.ResponseWriter, r *http.Request) {
 	// Response and Request structures
-	var req ResUpdateReq
-	var resp ResUpdateResp
+	var req TemplateJobReq
+	var resp JobCreateResp
 
 	// JSON Encoder and Decoder
 	reqJSON := json.NewDecoder(r.Body)
@@ -1099,135 +3249,130 @@ func (a *AppController) UpdateResource(rw http.ResponseWriter, r *http.Request)
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("token", token).Warn("An unknown user token attempted to update resource information.")
-
+		log.Warn("An unknown token attempted to create a job from a template.")
 		return
 	}
 
-	// Check for Administrator user level at least
+	// Check for standard user level at least
 	user, _ := a.T.GetUser(token)
-	if !user.Allowed(Administrator) {
+	if !user.Allowed(StandardUser) {
 		resp.Status = RESP_CODE_UNAUTHORIZED
 		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
-
-		log.WithField("user", user.Username).Warn("An unauthorized user attempted to update resource information.")
-
+		log.WithField("user", user.Username).Warn("An unauthorized user attempted to create a job from a template.")
 		return
 	}
 
-	// Decode the request
+	// Decode the request, an empty body uses the template as it is
 	err := reqJSON.Decode(&req)
-	if err != nil {
+	if err != nil && err != io.EOF {
+		log.WithField("err", err).Error("Error parsing the request.")
 		resp.Status = RESP_CODE_BADREQ
 		resp.Message = RESP_CODE_BADREQ_T
 
 		rw.WriteHeader(RESP_CODE_BADREQ)
 		respJSON.Encode(resp)
-
-		log.WithField("error", err.Error()).Error("An error occured while trying to decode resource update data.")
-
 		return
 	}
 
-	// Get the resource ID
-	resID := mux.Vars(r)["id"]
-	managerName := req.Manager
-
-	// Get the manager for the resource
-	manager, manok := a.Q.GetResourceManager(managerName)
-
-	//If that resource manager doesn't exist, return a not found error
-	if !manok {
+	id := mux.Vars(r)["id"]
+	t, _, ok := a.Q.TemplateInfo(id)
+	if !ok || !templateVisible(user, t) {
 		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "That resource manager does not exist."
+		resp.Message = RESP_CODE_NOTFOUND_T
 
 		rw.WriteHeader(RESP_CODE_NOTFOUND)
 		respJSON.Encode(resp)
-
-		log.WithFields(log.Fields{
-			"manager":  managerName,
-			"resource": resID,
-		}).Warn("Unable to find requested manager to update resource.")
-
 		return
 	}
 
-	switch req.Status {
-	case common.STATUS_QUIT:
-		log.WithFields(log.Fields{
-			"manager":  manager.SystemName(),
-			"resource": resID,
-			"status":   req.Status,
-		}).Info("Quiting resource status.")
-
-		// Quit the resource
-		err = manager.DeleteResource(resID)
-		if err != nil {
-			resp.Status = RESP_CODE_ERROR
-			resp.Message = "An error occured while trying to quit that resource: " + err.Error()
-
-			rw.WriteHeader(RESP_CODE_ERROR)
-			respJSON.Encode(resp)
-
-			log.WithFields(log.Fields{
-				"manager":  manager.SystemName(),
-				"error":    err.Error(),
-				"resource": resID,
-			}).Error("An error occured while trying to quit a resource.")
+	job, err := a.Q.JobFromTemplate(id, user.Username, req.Name, paramsToStrings(req.Params))
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to use the template: " + err.Error()
 
-			return
-		}
-	case common.STATUS_PAUSED, common.STATUS_RUNNING:
-		log.WithFields(log.Fields{
-			"manager":  manager.SystemName(),
-			"resource": resID,
-			"status":   req.Status,
-		}).Info("Updating resource status.")
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+	job.OwnerRole = user.EffectiveRole()
 
-		// Pause or resume the resource
-		err = manager.UpdateResource(resID, req.Status, req.Params)
-		if err != nil {
-			resp.Status = RESP_CODE_ERROR
-			resp.Message = "An error occured while trying to update that resource: " + err.Error()
+	err = a.Q.AddJob(job)
+	if _, ok := err.(*queue.QuotaError); ok {
+		resp.Status = RESP_CODE_FORBIDDEN
+		resp.Message = err.Error()
 
-			rw.WriteHeader(RESP_CODE_ERROR)
-			respJSON.Encode(resp)
+		rw.WriteHeader(RESP_CODE_FORBIDDEN)
+		respJSON.Encode(resp)
+		return
+	}
+	if verr, ok := err.(*queue.ValidationError); ok {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "The job parameters do not match what the tool accepts."
+		resp.Errors = newAPIFieldErrors(verr.Fields)
 
-			log.WithFields(log.Fields{
-				"manager":  manager.SystemName(),
-				"error":    err.Error(),
-				"resource": resID,
-			}).Error("An error occured while trying to update a resource.")
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
+	}
+	if err != nil {
+		resp.Status = RESP_CODE_BADREQ
+		resp.Message = "An error occured when trying to create the job: " + err.Error()
 
-			return
-		}
+		rw.WriteHeader(RESP_CODE_BADREQ)
+		respJSON.Encode(resp)
+		return
 	}
 
-	// Build good response because we were able to get here
+	// Job was created so populate the response structure and return
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
+	resp.JobID = job.UUID
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
 	log.WithFields(log.Fields{
-		"resource": resID,
-		"status":   req.Status,
-	}).Info("Resource updated.")
+		"uuid":     job.UUID,
+		"template": id,
+	}).Info("Job created from template.")
 }
 
-func (a *AppController) DeleteResources(rw http.ResponseWriter, r *http.Request) {
-	// Response and Request structures
-	var resp ResDeleteResp
-	var req ResDeleteReq
+// Build the API structure of a template
+func newAPITemplate(t queue.Template, status queue.TemplateStatus) APITemplate {
+	params := map[string]interface{}{}
+	for k, v := range t.Parameters {
+		params[k] = v
+	}
 
-	// JSON Encoder and Decoder
+	return APITemplate{
+		ID:          t.UUID,
+		Name:        t.Name,
+		Owner:       t.Owner,
+		Shared:      t.Shared,
+		ToolID:      status.ToolUUID,
+		ToolName:    t.ToolName,
+		ToolVersion: t.ToolVersion,
+		JobName:     t.JobName,
+		Params:      params,
+		Created:     t.Created,
+		Updated:     t.Updated,
+		Stale:       status.Stale,
+		StaleReason: status.Reason,
+	}
+}
+
+// Search jobs that have been purged from the queue (GET - /api/archive). The
+// search is given in the query string by owner, tool, name, hash, from, to and
+// limit, with from and to in RFC 3339 format.
+func (a *AppController) SearchArchive(rw http.ResponseWriter, r *http.Request) {
+	// Response structure
+	var resp ArchiveSearchResp
+
+	// JSON Encoder
 	respJSON := json.NewEncoder(rw)
-	reqJSON := json.NewDecoder(r.Body)
 
 	// Get the authorization header
 	token := r.Header.Get("AuthorizationToken")
@@ -1239,27 +3384,44 @@ func (a *AppController) DeleteResources(rw http.ResponseWriter, r *http.Request)
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("token", token).Warn("An unknown user token attempted to delete a resource.")
+		log.WithField("token", token).Warn("An unknown user token attempted to search the archive.")
 
 		return
 	}
 
-	// Check for Administrator user level at least
+	// Check for standard user level at least
 	user, _ := a.T.GetUser(token)
-	if !user.Allowed(Administrator) {
+	if !user.Allowed(StandardUser) {
 		resp.Status = RESP_CODE_UNAUTHORIZED
 		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to delete a resource.")
+		log.WithField("username", user.Username).Warn("An unauthorized user attempted to search the archive.")
 
 		return
 	}
 
-	// Decode the request
-	err := reqJSON.Decode(&req)
+	// Build the filter from the query string
+	query := r.URL.Query()
+	filter := queue.ArchiveFilter{
+		Owner: query.Get("owner"),
+		Tool:  query.Get("tool"),
+		Name:  query.Get("name"),
+		Hash:  query.Get("hash"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+	}
+	if to := query.Get("to"); to != "" && err == nil {
+		filter.To, err = time.Parse(time.RFC3339, to)
+	}
+	if limit := query.Get("limit"); limit != "" && err == nil {
+		filter.Limit, err = strconv.Atoi(limit)
+	}
 	if err != nil {
 		resp.Status = RESP_CODE_BADREQ
 		resp.Message = RESP_CODE_BADREQ_T
@@ -1267,155 +3429,134 @@ func (a *AppController) DeleteResources(rw http.ResponseWriter, r *http.Request)
 		rw.WriteHeader(RESP_CODE_BADREQ)
 		respJSON.Encode(resp)
 
-		log.WithField("error", err.Error()).Error("An error occured while trying to decode resource delete data.")
-
-		return
-	}
-
-	// Get the resource ID
-	resID := mux.Vars(r)["id"]
-	managerName := req.Manager
-
-	// Get the manager for the resource
-	manager, manok := a.Q.GetResourceManager(managerName)
-
-	//If that resource manager doesn't exist, return a not found error
-	if !manok {
-		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "That resource manager does not exist."
-
-		rw.WriteHeader(RESP_CODE_NOTFOUND)
-		respJSON.Encode(resp)
-
-		log.WithFields(log.Fields{
-			"manager":  managerName,
-			"resource": resID,
-		}).Warn("Unable to find requested manager to update resource.")
+		log.WithField("error", err.Error()).Warn("An archive search was given an invalid filter.")
 
 		return
 	}
 
-	// Get the resource
-	resource, _, err := manager.GetResource(resID)
-
-	// If that resource doesn't exist, let's throw an error
-	if err != nil {
+	jobs, err := a.Q.SearchArchive(filter)
+	if err == queue.ErrNoArchive {
 		resp.Status = RESP_CODE_NOTFOUND
-		resp.Message = "That resource does not exist."
+		resp.Message = err.Error()
 
 		rw.WriteHeader(RESP_CODE_NOTFOUND)
 		respJSON.Encode(resp)
-
-		log.WithFields(log.Fields{
-			"manager": manager.SystemName(),
-			"addr":    resource.Address,
-			"name":    resource.Name,
-		}).Warn("Unable to find requested resource to update.")
-
 		return
 	}
-
-	// Remove the resource
-	err = manager.DeleteResource(resID)
 	if err != nil {
 		resp.Status = RESP_CODE_ERROR
-		resp.Message = "An error occured while trying to delete that resource: " + err.Error()
+		resp.Message = RESP_CODE_ERROR_T
 
 		rw.WriteHeader(RESP_CODE_ERROR)
 		respJSON.Encode(resp)
 
-		log.WithFields(log.Fields{
-			"error":    err.Error(),
-			"resource": resID,
-		}).Error("An error occured while trying to delete a resource.")
+		log.WithField("error", err.Error()).Error("An error occured while searching the archive.")
 
 		return
 	}
 
-	// Build good response
+	for _, j := range jobs {
+		resp.Jobs = append(resp.Jobs, APIArchivedJob{
+			ID:            j.UUID,
+			Name:          j.Name,
+			Status:        j.Status,
+			Owner:         j.Owner,
+			ToolID:        j.ToolUUID,
+			ToolName:      j.ToolName,
+			ToolVersion:   j.ToolVersion,
+			Params:        j.Parameters,
+			StartTime:     j.StartTime,
+			RunTime:       int64(j.RunTime / time.Second),
+			Archived:      j.Archived,
+			CrackedHashes: j.CrackedHashes,
+			TotalHashes:   j.TotalHashes,
+			OutputTitles:  j.OutputTitles,
+			OutputData:    j.OutputData,
+			Error:         j.Error,
+		})
+	}
+
 	resp.Status = RESP_CODE_OK
 	resp.Message = RESP_CODE_OK_T
 
 	rw.WriteHeader(RESP_CODE_OK)
 	respJSON.Encode(resp)
 
-	log.WithField("resource", resID).Info("Resource disconnected.")
+	log.WithFields(log.Fields{
+		"username": user.Username,
+		"jobs":     len(resp.Jobs),
+	}).Info("Archive searched.")
 }
 
-/*
-	Handler for the PUT /api/queue function in our API that is used, for now to
-	handle updates to the order of jobs in the queue.
-*/
-func (a *AppController) ReorderQueue(rw http.ResponseWriter, r *http.Request) {
-	// Structurs to hold our request and response from Negroni, see api_struct.go
-	var req QueueUpdateReq
-	var resp QueueUpdateResp
+// Get statistics on how the queue has been used (GET - /api/stats). The series
+// covers the last day unless a since time is given in RFC 3339 format in the
+// query string.
+func (a *AppController) GetStats(rw http.ResponseWriter, r *http.Request) {
+	// Response structure
+	var resp StatsResp
 
-	// A decoder to take the JSON information passed by the API and return it
-	reqJSON := json.NewDecoder(r.Body)
-	// An encoder to take our response and give it back to the user
+	// JSON Encoder
 	respJSON := json.NewEncoder(rw)
 
-	// First, we handle authentication through the header
+	// Get the authorization header
 	token := r.Header.Get("AuthorizationToken")
+
 	if !a.T.CheckToken(token) {
-		//If the token is unknown, send back an unauthenticated message
 		resp.Status = RESP_CODE_UNAUTHORIZED
 		resp.Message = RESP_CODE_UNAUTHORIZED_T
 
 		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
 		respJSON.Encode(resp)
 
-		log.WithField("token", token).Warn("An unknown user token attempted to reorder the queue.")
+		log.WithField("token", token).Warn("An unknown user token attempted to get queue statistics.")
 
 		return
 	}
 
-	// Let's then check to make sure the user has the right group, in this case standard
-	user, _ := a.T.GetUser(token)
-	if !user.Allowed(StandardUser) {
-		//If not, send back the proper response.
-		resp.Status = RESP_CODE_UNAUTHORIZED
-		resp.Message = RESP_CODE_UNAUTHORIZED_T
-
-		rw.WriteHeader(RESP_CODE_UNAUTHORIZED)
-		respJSON.Encode(resp)
-
-		log.WithField("username", user.Username).Warn("An unauthorized user attempted to reorder the queue.")
+	since := time.Now().Add(-24 * time.Hour)
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			resp.Status = RESP_CODE_BADREQ
+			resp.Message = RESP_CODE_BADREQ_T
 
-		return
+			rw.WriteHeader(RESP_CODE_BADREQ)
+			respJSON.Encode(resp)
+			return
+		}
 	}
 
-	// Decode the request data that we recieved into our struct
-	err := reqJSON.Decode(&req)
-	if err != nil {
-		// If there is an error, let the API know via HTTP
-		resp.Status = RESP_CODE_BADREQ
-		resp.Message = RESP_CODE_BADREQ_T
-
-		rw.WriteHeader(RESP_CODE_BADREQ)
-		respJSON.Encode(resp)
-
-		log.WithField("error", err.Error()).Error("An error occured while trying to decode queue update data.")
-
-		return
+	stats := a.Q.Stats(since)
+
+	resp.Stats = APIStats{
+		JobsCreated:   stats.JobsCreated,
+		JobsByTool:    stats.JobsByTool,
+		JobsByOwner:   stats.JobsByOwner,
+		JobsByStatus:  stats.JobsByStatus,
+		JobsFinished:  stats.JobsFinished,
+		RunTime:       map[string]int64{},
+		CrackedHashes: stats.CrackedHashes,
+		AverageWait:   int64(stats.AverageWait / time.Second),
+		Utilisation:   stats.Utilisation,
 	}
-
-	// Let's try and actually reorder the stack
-	err = a.Q.StackReorder(req.JobOrder)
-	if err != nil {
-		//If there was an error, send the code to the API
-		resp.Status = RESP_CODE_ERROR
-		resp.Message = err.Error()
-
-		rw.WriteHeader(RESP_CODE_ERROR)
-		respJSON.Encode(resp)
-
-		log.WithField("error", err.Error()).Error("An error occured while trying to update the queue order.")
-
-		return
+	for res, d := range stats.RunTime {
+		resp.Stats.RunTime[res] = int64(d / time.Second)
+	}
+	for _, p := range stats.Series {
+		resp.Stats.Series = append(resp.Stats.Series, APIStatsPoint{
+			Start:         p.Start,
+			JobsCreated:   p.JobsCreated,
+			JobsFinished:  p.JobsFinished,
+			CrackedHashes: p.CrackedHashes,
+			AverageWait:   int64(p.AverageWait / time.Second),
+			Utilisation:   p.Utilisation,
+		})
 	}
 
-	// Finally, we did it successfully!
-	log.Info("Queue reodered successfully")
+	resp.Status = RESP_CODE_OK
+	resp.Message = RESP_CODE_OK_T
+
+	rw.WriteHeader(RESP_CODE_OK)
+	respJSON.Encode(resp)
 }
@@ -7,6 +7,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/jmmcatee/cracklord/common"
 	"github.com/jmmcatee/cracklord/common/log"
+	"github.com/jmmcatee/cracklord/common/metrics"
 	"github.com/jmmcatee/cracklord/common/resource"
 	"github.com/jmmcatee/cracklord/plugins/tools/hashcat"
 	"github.com/jmmcatee/cracklord/plugins/tools/hashcat3"
@@ -16,8 +17,10 @@ import (
 	"github.com/jmmcatee/cracklord/plugins/tools/testtimergpu"
 	"github.com/vaughan0/go-ini"
 	"io/ioutil"
+	"net/http"
 	"net/rpc"
 	"os"
+	"strconv"
 )
 
 func main() {
@@ -157,6 +160,35 @@ func main() {
 		resQueue.AddTool(testtimercpu.NewTooler())
 	}
 
+	// Set how many units of each type of hardware this resource has
+	for hw, units := range confFile.Section("Hardware") {
+		n, err := strconv.Atoi(common.StripQuotes(units))
+		if err != nil || n < 0 {
+			log.WithField("hardware", hw).Error("Hardware units were provided, but are not a valid integer.")
+			continue
+		}
+		resQueue.SetHardware(hw, n)
+	}
+
+	// Labels the queue server can use to place jobs on this resource
+	for key, value := range confFile.Section("Labels") {
+		resQueue.SetLabel(key, common.StripQuotes(value))
+	}
+
+	// Serve Prometheus metrics if a listen address was given
+	if listenMetrics := common.StripQuotes(confFile.Section("Metrics")["listen"]); listenMetrics != "" {
+		resQueue.RegisterMetrics()
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go func() {
+			log.WithField("addr", listenMetrics).Info("Serving metrics.")
+			if err := http.ListenAndServe(listenMetrics, mux); err != nil {
+				log.Error("Unable to serve metrics on '" + listenMetrics + "':" + err.Error())
+			}
+		}()
+	}
+
 	// Get an RPC server
 	res := rpc.NewServer()
 
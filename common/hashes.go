@@ -0,0 +1,107 @@
+package common
+
+import (
+	"bufio"
+	"encoding/base64"
+	"strings"
+)
+
+// Parameter keys tools use for the hashes of a job
+const (
+	PARAM_HASHES          = "hashes"
+	PARAM_HASHES_MULTI    = "hashes_multiline"
+	PARAM_HASHES_UPLOAD   = "hashes_file_upload"
+	PARAM_HASHES_USE_FILE = "hashes_use_upload"
+)
+
+// InputHashes returns the hashes provided to a job through its parameters, one
+// per line. Both text inputs and base64 file uploads are supported.
+func InputHashes(params map[string]string) []string {
+	var input string
+
+	switch {
+	case params[PARAM_HASHES_USE_FILE] == "true" && params[PARAM_HASHES_UPLOAD] != "":
+		// Uploads look like file:[name];data:[type];base64,[data]
+		parts := strings.Split(params[PARAM_HASHES_UPLOAD], ";")
+		if len(parts) != 3 || !strings.HasPrefix(parts[2], "base64,") {
+			return nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(parts[2], "base64,"))
+		if err != nil {
+			return nil
+		}
+		input = string(decoded)
+	case params[PARAM_HASHES_MULTI] != "":
+		input = params[PARAM_HASHES_MULTI]
+	default:
+		input = params[PARAM_HASHES]
+	}
+
+	var hashes []string
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			hashes = append(hashes, line)
+		}
+	}
+
+	return hashes
+}
+
+// ReplaceInputHashes sets the hashes of a job in its parameters, replacing any
+// text input or file upload that was provided before.
+func ReplaceInputHashes(params map[string]string, hashes []string) {
+	input := strings.Join(hashes, "\n")
+
+	// Tools with a single hash input keep using it
+	if _, ok := params[PARAM_HASHES]; ok {
+		params[PARAM_HASHES] = input
+		return
+	}
+
+	params[PARAM_HASHES_MULTI] = input
+	params[PARAM_HASHES_USE_FILE] = "false"
+	delete(params, PARAM_HASHES_UPLOAD)
+}
+
+// RemainingHashes returns the input hashes of a job that do not appear in its
+// output. Input lines with extra fields, such as pwdump, are also removed when
+// one of their fields was cracked.
+func RemainingHashes(j Job) []string {
+	// Find the column of the output holding the hash
+	col := 1
+	for i, title := range j.OutputTitles {
+		if strings.HasPrefix(strings.ToLower(title), "hash") {
+			col = i
+		}
+	}
+
+	cracked := map[string]bool{}
+	for _, row := range j.OutputData {
+		if col < len(row) {
+			cracked[strings.ToLower(row[col])] = true
+		}
+	}
+
+	var remaining []string
+	for _, hash := range InputHashes(j.Parameters) {
+		if cracked[strings.ToLower(hash)] {
+			continue
+		}
+
+		var found bool
+		for _, field := range strings.Split(hash, ":") {
+			if field != "" && cracked[strings.ToLower(field)] {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			remaining = append(remaining, hash)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestInputHashes(t *testing.T) {
+	params := map[string]string{PARAM_HASHES_MULTI: "aaaa\n\nbbbb\r\n"}
+	if hashes := InputHashes(params); !reflect.DeepEqual(hashes, []string{"aaaa", "bbbb"}) {
+		t.Errorf("Expected [aaaa bbbb] but got %v", hashes)
+	}
+
+	upload := "file:hashes.txt;data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("cccc\ndddd\n"))
+	params = map[string]string{PARAM_HASHES_USE_FILE: "true", PARAM_HASHES_UPLOAD: upload}
+	if hashes := InputHashes(params); !reflect.DeepEqual(hashes, []string{"cccc", "dddd"}) {
+		t.Errorf("Expected [cccc dddd] but got %v", hashes)
+	}
+
+	ReplaceInputHashes(params, []string{"dddd"})
+	if _, ok := params[PARAM_HASHES_UPLOAD]; ok {
+		t.Error("Expected the file upload to be removed")
+	}
+	if hashes := InputHashes(params); !reflect.DeepEqual(hashes, []string{"dddd"}) {
+		t.Errorf("Expected [dddd] but got %v", hashes)
+	}
+}
+
+func TestRemainingHashes(t *testing.T) {
+	j := Job{
+		Parameters: map[string]string{
+			PARAM_HASHES_MULTI: "5F4DCC3B5AA765D61D8327DEB882CF99\nuser:500:aad3b435b51404ee:8846f7eaee8fb117ad06bdd830b7586c:::\nffff",
+		},
+		OutputTitles: []string{"Plaintext", "Hashes"},
+		OutputData: [][]string{
+			{"password", "5f4dcc3b5aa765d61d8327deb882cf99"},
+			{"password", "8846f7eaee8fb117ad06bdd830b7586c"},
+		},
+	}
+
+	if remaining := RemainingHashes(j); !reflect.DeepEqual(remaining, []string{"ffff"}) {
+		t.Errorf("Expected [ffff] but got %v", remaining)
+	}
+}
+
+func TestDependencyMet(t *testing.T) {
+	tests := []struct {
+		condition string
+		status    string
+		met       bool
+		never     bool
+	}{
+		{DEPEND_DONE, STATUS_RUNNING, false, false},
+		{DEPEND_DONE, STATUS_DONE, true, false},
+		{DEPEND_DONE, STATUS_FAILED, false, true},
+		{DEPEND_FAILED, STATUS_QUIT, true, false},
+		{DEPEND_FAILED, STATUS_DONE, false, true},
+		{DEPEND_FINISHED, STATUS_FAILED, true, false},
+	}
+
+	for _, test := range tests {
+		met, never := Dependency{Condition: test.condition}.Met(test.status)
+		if met != test.met || never != test.never {
+			t.Errorf("%s on %s: expected %v/%v but got %v/%v", test.condition, test.status, test.met, test.never, met, never)
+		}
+	}
+}
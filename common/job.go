@@ -1,30 +1,144 @@
 package common
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/pborman/uuid"
 )
 
 type Job struct {
-	UUID             string            // UUID generated by the Queue
-	ToolUUID         string            // ID of the tool to use with this job
-	Name             string            // Name of the job
-	Status           string            // Status of the job
-	Error            string            // Last returned error from the tool
-	StartTime        time.Time         // Start time of the job
-	PurgeTime        time.Time         // Time to remove the job from the queue during a Queue.keeper()
-	ETC              string            // The estimated time of completion
-	Owner            string            // Owner provided by the web frontend
-	ResAssigned      string            // Resource this job is assinged to if any
-	CrackedHashes    int64             // # of hashes cracked
-	TotalHashes      int64             // # of hashes provided
-	Progress         float64           // # % of cracked/provided
-	Parameters       map[string]string // Parameters returned to the tool
-	PerformanceData  map[string]string // Some performance status map[timestamp]perf#
-	PerformanceTitle string            // Title of the perf #
-	OutputData       [][]string        // A 2D array of rows for output values
-	OutputTitles     []string          // The headers for the 2D array of rows above
+	UUID             string             // UUID generated by the Queue
+	ToolUUID         string             // ID of the tool to use with this job
+	Name             string             // Name of the job
+	Status           string             // Status of the job
+	Error            string             // Last returned error from the tool
+	StartTime        time.Time          // Start time of the job
+	PurgeTime        time.Time          // Time to remove the job from the queue during a Queue.keeper()
+	ETC              string             // The estimated time of completion
+	Owner            string             // Owner provided by the web frontend
+	OwnerRole        string             // Role of the owner when the job was created, used for quotas
+	ResAssigned      string             // Resource this job is assinged to if any
+	CrackedHashes    int64              // # of hashes cracked
+	TotalHashes      int64              // # of hashes provided
+	Progress         float64            // # % of cracked/provided
+	Parameters       map[string]string  // Parameters returned to the tool
+	PerformanceData  map[string]string  // Some performance status map[timestamp]perf#
+	PerformanceTitle string             // Title of the perf #
+	OutputData       [][]string         // A 2D array of rows for output values
+	OutputTitles     []string           // The headers for the 2D array of rows above
+	ParentUUID       string             // Job this job is a keyspace chunk of, if any
+	Chunked          bool               // Job has been split into keyspace chunks by the Queue
+	Keyspace         int64              // Total keyspace of a chunked job
+	KeyspaceSkip     int64              // Start of the keyspace this chunk covers
+	KeyspaceLimit    int64              // Length of the keyspace this chunk covers
+	Retries          int                // # of times the Queue has re-queued this job
+	Priority         int                // Higher priority jobs are scheduled first by some policies
+	Dependencies     []Dependency       // Jobs that must finish before this job is started
+	FeedFrom         string             // Job whose uncracked hashes replace this job's hashes at start
+	NotBefore        time.Time          // The keeper will not start the job before this time
+	MaxRuntime       time.Duration      // The Queue quits the job once it has run this long, if set
+	Deadline         time.Time          // The Queue quits the job if it has not finished by this time, if set
+	RunTime          time.Duration      // Time the job has spent running as seen by the Queue
+	Attempts         []Attempt          // Every time the Queue has started this job on a resource
+	RetryAfter       time.Time          // The keeper will not retry the job before this time
+	Placement        Placement          // Constraints on which resources the job can run on
+	Created          time.Time          // When the job was created
+	Waiting          string             // Why the keeper is holding the job back, if it is
+	Events           []Event            // Every change in the state of the job, oldest first
+	Benchmark        bool               // Job benchmarks its tool rather than attacking hashes, only set by the Queue
+	Benchmarks       map[string]float64 // Speeds measured by a benchmark job by mode in hashes a second
+}
+
+// IsBenchmark checks if a job is a benchmark of its tool (See Benchmarker)
+func IsBenchmark(j Job) bool {
+	return j.Benchmark
+}
+
+// Placement limits and guides which resources the keeper starts a job on using
+// the labels of the resources. A label required or preferred with an empty value
+// only has to be present on the resource.
+type Placement struct {
+	Require map[string]string // Labels a resource must have
+	Prefer  map[string]string // Labels of the resources to use first
+	Avoid   []string          // UUIDs or names of resources never to use
+	Pin     string            // UUID of the only resource to use, if set
+}
+
+// An Attempt records one run of a job on a resource
+type Attempt struct {
+	ResourceUUID string    // Resource the job was started on
+	ToolUUID     string    // Queue Tool UUID the job was started with
+	Started      time.Time // When the job was started on the resource
+	Ended        time.Time // When the job left the resource, if it has
+	Error        string    // Why the attempt failed, if it did
+}
+
+// Types of Event in the life of a job
+const (
+	EVENT_CREATED   = "created"
+	EVENT_SPLIT     = "split"     // The job was split into keyspace chunks
+	EVENT_STARTED   = "started"   // The job was started on a resource
+	EVENT_PAUSED    = "paused"    // The job was paused by a user or the queue
+	EVENT_PREEMPTED = "preempted" // The job was paused for a higher priority job
+	EVENT_RESUMED   = "resumed"   // The job was resumed on its resource
+	EVENT_RETRIED   = "retried"   // The job was put back in the queue to start again
+	EVENT_QUIT      = "quit"      // The job was quit by a user
+	EVENT_FINISHED  = "finished"  // The job stopped running on its own
+	EVENT_STATUS    = "status"    // The status changed for any other reason
+	EVENT_ERROR     = "error"     // A call to the job's resource failed
+)
+
+// An Event records a change in the state of a job
+type Event struct {
+	Time     time.Time
+	Type     string // One of the EVENT_* types
+	Status   string // Status of the job after the event
+	Resource string // UUID of the resource involved, if any
+	User     string // User who caused the event, if any
+	Message  string
+}
+
+// Conditions a Dependency can wait on
+const (
+	DEPEND_DONE     = "done"     // The job completed successfully
+	DEPEND_FAILED   = "failed"   // The job failed or was quit
+	DEPEND_FINISHED = "finished" // The job stopped for any reason
+)
+
+type Dependency struct {
+	JobUUID   string // Job that must finish first
+	Condition string // One of the DEPEND_* conditions
+}
+
+// Met returns if the dependency is satisfied by the status of the job it is on.
+// If the job has finished without satisfying the dependency it can never be met,
+// which is returned through never.
+func (d Dependency) Met(status string) (met bool, never bool) {
+	if !IsDone(status) {
+		return false, false
+	}
+
+	switch d.Condition {
+	case DEPEND_DONE:
+		met = status == STATUS_DONE
+	case DEPEND_FAILED:
+		met = status == STATUS_FAILED || status == STATUS_QUIT
+	default:
+		met = true
+	}
+
+	return met, !met
+}
+
+// ValidCondition checks the condition is one a Dependency can wait on
+func ValidCondition(condition string) bool {
+	switch condition {
+	case DEPEND_DONE, DEPEND_FAILED, DEPEND_FINISHED:
+		return true
+	}
+
+	return false
 }
 
 func NewJob(tooluuid string, name string, owner string, params map[string]string) Job {
@@ -36,5 +150,25 @@ func NewJob(tooluuid string, name string, owner string, params map[string]string
 		Owner:           owner,
 		Parameters:      params,
 		PerformanceData: make(map[string]string),
+		Created:         time.Now(),
 	}
 }
+
+// LatestPerformance returns the most recent value from a job's performance data
+func LatestPerformance(data map[string]string) float64 {
+	var latest int64
+	var value float64
+	for k, v := range data {
+		t, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || t < latest {
+			continue
+		}
+
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			latest = t
+			value = f
+		}
+	}
+
+	return value
+}
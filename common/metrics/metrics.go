@@ -0,0 +1,336 @@
+// Package metrics keeps counters, gauges and histograms for the cracklord
+// servers and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the histogram buckets used for durations in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// A Sample is one value of a metric collected when it is scraped
+type Sample struct {
+	Labels []string // Label values in the order the metric was created with
+	Value  float64
+}
+
+type collector interface {
+	name() string
+	write(buf *bytes.Buffer)
+}
+
+var registry = struct {
+	sync.Mutex
+	metrics map[string]collector
+}{metrics: map[string]collector{}}
+
+func register(c collector) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.metrics[c.name()]; ok {
+		panic("metrics: " + c.name() + " registered twice")
+	}
+	registry.metrics[c.name()] = c
+}
+
+// Handler serves every registered metric
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(Gather())
+	})
+}
+
+// Gather returns every registered metric in the Prometheus text format
+func Gather() []byte {
+	registry.Lock()
+	var names []string
+	for n := range registry.metrics {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var metrics []collector
+	for _, n := range names {
+		metrics = append(metrics, registry.metrics[n])
+	}
+	registry.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.write(&buf)
+	}
+
+	return buf.Bytes()
+}
+
+type desc struct {
+	metric string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) name() string {
+	return d.metric
+}
+
+func (d desc) header(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", d.metric, d.help, d.metric, d.kind)
+}
+
+// line writes a single sample, adding any extra label after the metric's own
+func (d desc) line(buf *bytes.Buffer, suffix string, values []string, extra string, v float64) {
+	buf.WriteString(d.metric)
+	buf.WriteString(suffix)
+
+	var pairs []string
+	for i, l := range d.labels {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, l+"=\""+escape(value)+"\"")
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) > 0 {
+		buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	buf.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func escape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelKey joins label values so they can be used as a map key
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// values keeps one float per set of label values
+type values struct {
+	sync.Mutex
+	byKey  map[string]float64
+	labels map[string][]string
+}
+
+func (v *values) add(labels []string, delta float64, set bool) {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.byKey == nil {
+		v.byKey = map[string]float64{}
+		v.labels = map[string][]string{}
+	}
+
+	k := labelKey(labels)
+	if set {
+		v.byKey[k] = delta
+	} else {
+		v.byKey[k] += delta
+	}
+	v.labels[k] = append([]string(nil), labels...)
+}
+
+func (v *values) write(d desc, buf *bytes.Buffer) {
+	v.Lock()
+	defer v.Unlock()
+
+	d.header(buf)
+
+	var keys []string
+	for k := range v.byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d.line(buf, "", v.labels[k], "", v.byKey[k])
+	}
+}
+
+// A Counter is a value that only goes up
+type Counter struct {
+	desc
+	values values
+}
+
+// NewCounter creates and registers a counter with the label names given
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter", labels}}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values given
+func (c *Counter) Inc(labels ...string) {
+	c.values.add(labels, 1, false)
+}
+
+// Add adds to the counter for the label values given. Negative values are ignored.
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	c.values.add(labels, v, false)
+}
+
+func (c *Counter) write(buf *bytes.Buffer) {
+	c.values.write(c.desc, buf)
+}
+
+// A Gauge is a value that can go up and down
+type Gauge struct {
+	desc
+	values values
+}
+
+// NewGauge creates and registers a gauge with the label names given
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name, help, "gauge", labels}}
+	register(g)
+	return g
+}
+
+// Set sets the gauge for the label values given
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.values.add(labels, v, true)
+}
+
+func (g *Gauge) write(buf *bytes.Buffer) {
+	g.values.write(g.desc, buf)
+}
+
+// A GaugeFunc is a gauge whose values are collected each time it is scraped
+type GaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc creates and registers a gauge that calls collect when scraped
+func NewGaugeFunc(name, help string, labels []string, collect func() []Sample) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name, help, "gauge", labels}, collect: collect}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(buf *bytes.Buffer) {
+	samples := g.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return labelKey(samples[i].Labels) < labelKey(samples[j].Labels)
+	})
+
+	g.header(buf)
+	for _, s := range samples {
+		g.line(buf, "", s.Labels, "", s.Value)
+	}
+}
+
+// A Histogram counts observations into buckets
+type Histogram struct {
+	desc
+	buckets []float64
+
+	sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Observations in each bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates and registers a histogram with the upper bounds and
+// label names given
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+
+	h := &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: b,
+		series:  map[string]*histogramSeries{},
+	}
+	register(h)
+	return h
+}
+
+// Observe adds a value for the label values given
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.Lock()
+	defer h.Unlock()
+
+	k := labelKey(labels)
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{
+			labels: append([]string(nil), labels...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[k] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(buf *bytes.Buffer) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.header(buf)
+
+	var keys []string
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := h.series[k]
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			h.line(buf, "_bucket", s.labels, `le="`+formatFloat(upper)+`"`, float64(cumulative))
+		}
+		h.line(buf, "_bucket", s.labels, `le="+Inf"`, float64(s.count))
+		h.line(buf, "_sum", s.labels, "", s.sum)
+		h.line(buf, "_count", s.labels, "", float64(s.count))
+	}
+}
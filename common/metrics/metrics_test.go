@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGather(t *testing.T) {
+	c := NewCounter("test_failures_total", "Failures.", "type")
+	c.Inc("web")
+	c.Inc("web")
+	c.Add(3, `sc"ript`)
+
+	h := NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	NewGaugeFunc("test_jobs", "Jobs.", []string{"status"}, func() []Sample {
+		return []Sample{{[]string{"running"}, 2}, {[]string{"created"}, 1}}
+	})
+
+	out := string(Gather())
+	expected := []string{
+		"# TYPE test_failures_total counter\n",
+		`test_failures_total{type="web"} 2` + "\n",
+		`test_failures_total{type="sc\"ript"} 3` + "\n",
+		`test_duration_seconds_bucket{le="0.1"} 1` + "\n",
+		`test_duration_seconds_bucket{le="1"} 2` + "\n",
+		`test_duration_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_duration_seconds_sum 5.55\n",
+		"test_duration_seconds_count 3\n",
+		`test_jobs{status="created"} 1` + "\n" + `test_jobs{status="running"} 2` + "\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected %q in output:\n%s", e, out)
+		}
+	}
+}
@@ -22,3 +22,43 @@ type Tooler interface {
 	Requirements() string
 	NewTask(Job) (Tasker, error)
 }
+
+// Keyspacer is an optional interface for Toolers whose jobs can be split into
+// chunks of keyspace and spread across multiple resources. Keyspace returns the
+// total keyspace of the job, and the tool must then honor Job.KeyspaceSkip and
+// Job.KeyspaceLimit when a chunk is handed to NewTask.
+type Keyspacer interface {
+	Keyspace(Job) (int64, error)
+}
+
+// Estimator is an optional interface for Toolers that can count how many
+// candidates a job will try without running it, so the Queue can estimate how
+// long the job will take from the speeds it has seen. The count is a float64 as
+// it can easily be more than an int64 holds.
+type Estimator interface {
+	Candidates(Job) (float64, error)
+}
+
+// Benchmarker is an optional interface for Toolers that can measure how fast
+// their resource is. Jobs with Benchmark set are given to NewBenchmark
+// instead of NewTask, and the Tasker reports the speed of each mode it measured
+// in Job.Benchmarks when it is done.
+type Benchmarker interface {
+	NewBenchmark(Job) (Tasker, error)
+}
+
+// Unitser is an optional interface for Toolers whose jobs use more than one unit
+// of their Requirements hardware, such as a number of GPUs. Toolers without it
+// use one unit.
+type Unitser interface {
+	Units() int
+}
+
+// Devicer is an optional interface for Taskers that can be limited to some of
+// the units of their tool's Requirements hardware, such as the GPUs of a
+// resource with several. The resource sets aside devices for the task, numbered
+// from 1, and calls SetDevices before each Run. Tasks of tools without it are
+// not told which devices to use and use all of them.
+type Devicer interface {
+	SetDevices([]int)
+}
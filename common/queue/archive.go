@@ -0,0 +1,161 @@
+package queue
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// ErrNoArchive is returned when searching the archive without a store that keeps one
+var ErrNoArchive = errors.New("The queue is not keeping an archive.")
+
+// ArchiveRetention is how long purged jobs are kept in the archive. Zero keeps
+// them forever.
+var ArchiveRetention time.Duration
+
+// An Archiver is a Store that also keeps jobs once they are purged from the
+// Queue so their results can still be searched.
+type Archiver interface {
+	Archive(jobs []ArchivedJob) error
+	SearchArchive(f ArchiveFilter) ([]ArchivedJob, error)
+	// ExpireArchive removes jobs archived before the time given and returns how many
+	ExpireArchive(before time.Time) (int, error)
+}
+
+// An ArchivedJob is a job purged from the Queue along with the tool it used,
+// since tool UUIDs do not last across restarts.
+type ArchivedJob struct {
+	common.Job
+	ToolName    string
+	ToolVersion string
+	Archived    time.Time
+}
+
+// ArchiveFilter picks jobs out of the archive. Empty fields match every job.
+type ArchiveFilter struct {
+	Owner string
+	Tool  string    // Name or UUID of the tool
+	Name  string    // Part of the job name, ignoring case
+	Hash  string    // A value in one of the job's output rows
+	From  time.Time // Jobs started at or after this time
+	To    time.Time // Jobs started before this time
+	Limit int       // Most jobs to return, newest first
+}
+
+// Match checks if an archived job passes the filter
+func (f ArchiveFilter) Match(a ArchivedJob) bool {
+	if f.Owner != "" && a.Owner != f.Owner {
+		return false
+	}
+
+	if f.Tool != "" && !strings.EqualFold(a.ToolName, f.Tool) && a.ToolUUID != f.Tool {
+		return false
+	}
+
+	if f.Name != "" && !strings.Contains(strings.ToLower(a.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+
+	if !f.From.IsZero() && a.StartTime.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && !a.StartTime.Before(f.To) {
+		return false
+	}
+
+	if f.Hash != "" {
+		for _, row := range a.OutputData {
+			for _, v := range row {
+				if v == f.Hash {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// SearchArchive returns the archived jobs matching the filter, newest first
+func (q *Queue) SearchArchive(f ArchiveFilter) ([]ArchivedJob, error) {
+	archive, ok := q.store.(Archiver)
+	if !ok {
+		return nil, ErrNoArchive
+	}
+
+	return archive.SearchArchive(f)
+}
+
+// archiveJobs adds jobs about to be purged to the archive. The Queue can still
+// purge without an archive so that is not an error.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) archiveJobs(jobs []common.Job) error {
+	archive, ok := q.store.(Archiver)
+	if !ok || len(jobs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	archived := make([]ArchivedJob, 0, len(jobs))
+	for _, j := range jobs {
+		a := ArchivedJob{Job: cloneJob(j), Archived: now}
+		if tool, ok := q.findTool(j.ToolUUID); ok {
+			a.ToolName = tool.Name
+			a.ToolVersion = tool.Version
+		}
+
+		archived = append(archived, a)
+	}
+
+	if err := archive.Archive(archived); err != nil {
+		log.WithField("error", err.Error()).Error("Unable to archive purged jobs.")
+		return err
+	}
+
+	log.WithField("jobs", len(archived)).Debug("Purged jobs archived.")
+	return nil
+}
+
+// expireArchive removes jobs from the archive once they are past the retention
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) expireArchive() {
+	archive, ok := q.store.(Archiver)
+	if !ok || ArchiveRetention <= 0 {
+		return
+	}
+
+	n, err := archive.ExpireArchive(time.Now().Add(-ArchiveRetention))
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Unable to remove expired jobs from the archive.")
+		return
+	}
+
+	if n > 0 {
+		log.WithField("jobs", n).Info("Expired jobs removed from the archive.")
+	}
+}
+
+// findTool looks up a tool by the key the Queue has for it or by the UUID a
+// resource has for it, including tools from before a restart.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) findTool(toolUUID string) (common.Tool, bool) {
+	for _, res := range q.pool {
+		if tool, ok := res.Tools[toolUUID]; ok {
+			return tool, true
+		}
+
+		for _, tool := range res.Tools {
+			if tool.UUID == toolUUID {
+				return tool, true
+			}
+		}
+	}
+
+	tool, ok := q.restoredTools[toolUUID]
+	return tool, ok
+}
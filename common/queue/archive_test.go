@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestArchiveFilterMatch(t *testing.T) {
+	a := ArchivedJob{
+		Job: common.Job{
+			Name:       "Domain Dump",
+			Owner:      "alice",
+			ToolUUID:   "tool1",
+			StartTime:  time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC),
+			OutputData: [][]string{{"8846f7eaee8fb117ad06bdd830b7586c", "password"}},
+		},
+		ToolName: "Hashcat",
+	}
+
+	match := []ArchiveFilter{
+		{},
+		{Owner: "alice", Tool: "hashcat", Name: "domain"},
+		{Tool: "tool1"},
+		{Hash: "8846f7eaee8fb117ad06bdd830b7586c"},
+		{From: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, f := range match {
+		if !f.Match(a) {
+			t.Errorf("Expected filter %+v to match", f)
+		}
+	}
+
+	miss := []ArchiveFilter{
+		{Owner: "bob"},
+		{Tool: "john"},
+		{Hash: "password1"},
+		{To: time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, f := range miss {
+		if f.Match(a) {
+			t.Errorf("Expected filter %+v not to match", f)
+		}
+	}
+}
+
+func TestBoltArchiveExpire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cracklord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenBoltStore(filepath.Join(dir, "queue.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	archive := store.(Archiver)
+
+	now := time.Now()
+	err = archive.Archive([]ArchivedJob{
+		{Job: common.Job{UUID: "old"}, Archived: now.Add(-48 * time.Hour)},
+		{Job: common.Job{UUID: "new"}, Archived: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := archive.ExpireArchive(now.Add(-24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 job expired but got %d (%v)", n, err)
+	}
+
+	jobs, err := archive.SearchArchive(ArchiveFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].UUID != "new" {
+		t.Errorf("Unexpected archive after expiring %+v", jobs)
+	}
+}
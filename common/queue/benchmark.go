@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"errors"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// Benchmark builds a job that benchmarks a tool on one resource, in a single
+// mode if one is given or in every mode the tool benchmarks otherwise. The job
+// is pinned to the resource and its results are recorded as the resource's
+// speeds when it finishes (See recordSpeed). It is not added to the queue.
+// NO LOCK SHOULD BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) Benchmark(resUUID, toolUUID, mode, owner string) (common.Job, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	res, ok := q.pool[resUUID]
+	if !ok || res.Status == common.STATUS_QUIT {
+		return common.Job{}, errors.New("Resource does not exist!")
+	}
+
+	// The tool may be given by the resource's own UUID for it (See findTool)
+	tool, ok := res.Tools[toolUUID]
+	if !ok {
+		for key, t := range res.Tools {
+			if t.UUID == toolUUID {
+				tool, toolUUID, ok = t, key, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return common.Job{}, errors.New("Tool is not available on the resource.")
+	}
+
+	if !tool.Benchmarks {
+		return common.Job{}, errors.New("Tool does not support benchmarks.")
+	}
+
+	params := map[string]string{}
+	if mode != "" {
+		params[SpeedModeParameter] = mode
+	}
+
+	j := common.NewJob(toolUUID, "Benchmark of "+tool.Name+" on "+res.Name, owner, params)
+	j.Benchmark = true
+	j.Placement.Pin = resUUID
+
+	return j, nil
+}
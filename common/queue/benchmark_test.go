@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestBenchmark(t *testing.T) {
+	q := Queue{
+		pool: ResourcePool{
+			"res1": {Name: "rig1", Tools: map[string]common.Tool{
+				"tool1": {Name: "Hashcat", UUID: "real1", Benchmarks: true},
+				"tool2": {Name: "Other", UUID: "real2"},
+			}},
+		},
+	}
+
+	// The resource's own UUID for the tool is accepted
+	j, err := q.Benchmark("res1", "real1", "1000", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.ToolUUID != "tool1" || j.Placement.Pin != "res1" || !common.IsBenchmark(j) || j.Parameters[SpeedModeParameter] != "1000" {
+		t.Errorf("Unexpected benchmark job %+v", j)
+	}
+
+	if _, err := q.Benchmark("res1", "tool2", "", "admin"); err == nil {
+		t.Error("Expected a tool without benchmarks to fail")
+	}
+	if _, err := q.Benchmark("res2", "tool1", "", "admin"); err == nil {
+		t.Error("Expected a missing resource to fail")
+	}
+
+	// Every mode measured is recorded when the benchmark stops
+	j.ToolUUID = "real1"
+	j.ResAssigned = "res1"
+	j.Benchmarks = map[string]float64{"0": 20e9, "1000": 35e9, "1800": 0}
+	q.stack = []common.Job{j}
+	q.recordSpeed(0)
+
+	speeds, ok := q.ResourceSpeeds("res1")
+	if !ok || len(speeds) != 2 {
+		t.Fatalf("Expected 2 speeds but got %+v", speeds)
+	}
+	if speeds[0].Mode != "0" || speeds[1].Mode != "1000" || speeds[1].Speed != 35e9 || speeds[1].Source != SPEED_SOURCE_BENCHMARK {
+		t.Errorf("Unexpected speeds %+v", speeds)
+	}
+}
+
+func TestSpeedAfterReconnect(t *testing.T) {
+	tool := common.Tool{Name: "Hashcat", UUID: "real1", Requirements: common.RES_GPU}
+	running := func(name string) Resource {
+		res := NewResource()
+		res.Name = name
+		res.Status = common.STATUS_RUNNING
+		res.Tools["tool1"] = tool
+		res.Capacity[common.RES_GPU] = 1
+		res.Hardware[common.RES_GPU] = 1
+		return res
+	}
+
+	q := Queue{pool: ResourcePool{"old": running("rig1"), "res2": running("rig2")}}
+	q.setSpeed("old", Speed{Tool: "Hashcat", Mode: "1000", Speed: 35e9, Source: SPEED_SOURCE_BENCHMARK})
+	q.setSpeed("res2", Speed{Tool: "Hashcat", Mode: "1000", Speed: 1e9, Source: SPEED_SOURCE_BENCHMARK})
+
+	// The resource drops off and connects again under a new UUID
+	old := q.pool["old"]
+	old.Status = common.STATUS_QUIT
+	q.pool["old"] = old
+
+	resUUID, err := q.AddResource("rig1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.pool[resUUID] = running("rig1")
+
+	if speeds, ok := q.ResourceSpeeds(resUUID); !ok || len(speeds) != 1 || speeds[0].Speed != 35e9 {
+		t.Fatalf("Expected the benchmark to follow the resource but got %+v", speeds)
+	}
+
+	// and the keeper still prefers it for jobs in the mode it is fastest at
+	q.stack = []common.Job{common.NewJob("tool1", "job", "alice", map[string]string{SpeedModeParameter: "1000"})}
+	q.startJobs()
+	if q.stack[0].ResAssigned != resUUID {
+		t.Errorf("Expected the job on the faster reconnected resource but it is on %q", q.stack[0].ResAssigned)
+	}
+}
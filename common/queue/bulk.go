@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"path"
+	"strings"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// A JobFilter picks jobs out of the queue. Empty fields match every job.
+type JobFilter struct {
+	Owner  string
+	Status string
+	Tool   string // Name or UUID of the tool
+	Name   string // Pattern for the job name ignoring case, with * and ? as wildcards, otherwise part of the name
+}
+
+// Empty checks if the filter would match every job
+func (f JobFilter) Empty() bool {
+	return f == JobFilter{}
+}
+
+// Match checks if a job using the tool given passes the filter
+func (f JobFilter) Match(j common.Job, tool common.Tool) bool {
+	if f.Owner != "" && j.Owner != f.Owner {
+		return false
+	}
+
+	if f.Status != "" && j.Status != f.Status {
+		return false
+	}
+
+	if f.Tool != "" && !strings.EqualFold(tool.Name, f.Tool) && j.ToolUUID != f.Tool && tool.UUID != f.Tool {
+		return false
+	}
+
+	if f.Name != "" {
+		name, pattern := strings.ToLower(j.Name), strings.ToLower(f.Name)
+
+		if strings.ContainsAny(pattern, "*?") {
+			if ok, err := path.Match(pattern, name); err != nil || !ok {
+				return false
+			}
+		} else if !strings.Contains(name, pattern) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindJobs returns the jobs in the latest Snapshot that match the filter.
+// Keyspace chunks are left out as they are handled through their parent.
+func (q *Queue) FindJobs(f JobFilter) []common.Job {
+	snap := q.Snapshot()
+
+	q.RLock()
+	defer q.RUnlock()
+
+	var jobs []common.Job
+	for _, j := range snap.Jobs {
+		if j.ParentUUID != "" {
+			continue
+		}
+
+		tool, _ := q.findTool(j.ToolUUID)
+		if f.Match(j, tool) {
+			jobs = append(jobs, j)
+		}
+	}
+
+	return jobs
+}
@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestJobFilterMatch(t *testing.T) {
+	j := common.Job{Name: "ACME Domain Dump", Owner: "alice", Status: common.STATUS_RUNNING, ToolUUID: "res-tool"}
+	tool := common.Tool{Name: "Hashcat", UUID: "res-tool"}
+
+	match := []JobFilter{
+		{},
+		{Owner: "alice", Status: common.STATUS_RUNNING},
+		{Tool: "hashcat"},
+		{Tool: "res-tool"},
+		{Name: "domain"},
+		{Name: "acme*"},
+	}
+	for _, f := range match {
+		if !f.Match(j, tool) {
+			t.Errorf("Expected filter %+v to match", f)
+		}
+	}
+
+	miss := []JobFilter{
+		{Owner: "bob"},
+		{Status: common.STATUS_PAUSED},
+		{Tool: "john"},
+		{Name: "*dump?"},
+	}
+	for _, f := range miss {
+		if f.Match(j, tool) {
+			t.Errorf("Expected filter %+v not to match", f)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"errors"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// CloneJob builds a new job for the owner given from the tool and parameters of
+// an existing job, with overrides replacing any of its parameters. If remaining
+// is set the new job is fed the hashes the existing job has left uncracked once
+// it finishes (See checkDependencies). The new job still has to be added with
+// AddJob.
+func (q *Queue) CloneJob(jobUUID, owner string, overrides map[string]string, remaining bool) (common.Job, error) {
+	var orig common.Job
+	var found bool
+	for _, j := range q.Snapshot().Jobs {
+		if j.UUID == jobUUID {
+			orig, found = j, true
+			break
+		}
+	}
+
+	if !found {
+		return common.Job{}, errors.New("Job does not exist!")
+	}
+	if orig.ParentUUID != "" {
+		return common.Job{}, errors.New("Keyspace chunks can not be cloned, clone the job they belong to.")
+	}
+
+	params := make(map[string]string, len(orig.Parameters)+len(overrides))
+	for k, v := range orig.Parameters {
+		params[k] = v
+	}
+	for k, v := range overrides {
+		params[k] = v
+	}
+
+	if remaining && len(common.InputHashes(params)) == 0 {
+		return common.Job{}, errors.New("Job has no hashes to run again.")
+	}
+
+	// Started jobs may have been given a resource specific Tool UUID, so go
+	// back to the one the Queue knows it by (See requeue)
+	toolUUID := orig.ToolUUID
+	if n := len(orig.Attempts); n > 0 && orig.Attempts[n-1].ToolUUID != "" {
+		toolUUID = orig.Attempts[n-1].ToolUUID
+	}
+
+	// Only Benchmark makes benchmark jobs so a clone is always an attack
+	j := common.NewJob(toolUUID, orig.Name, owner, params)
+	j.Benchmark = false
+	j.Placement = clonePlacement(orig.Placement)
+	j.MaxRuntime = orig.MaxRuntime
+	if remaining {
+		j.FeedFrom = orig.UUID
+	}
+
+	return j, nil
+}
+
+func clonePlacement(p common.Placement) common.Placement {
+	c := common.Placement{
+		Require: cloneStrings(p.Require),
+		Prefer:  cloneStrings(p.Prefer),
+		Pin:     p.Pin,
+	}
+	if p.Avoid != nil {
+		c.Avoid = append([]string(nil), p.Avoid...)
+	}
+
+	return c
+}
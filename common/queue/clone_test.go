@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestQueueCloneJob(t *testing.T) {
+	q := Queue{stack: []common.Job{
+		{
+			UUID:       "orig",
+			Name:       "NTLM",
+			Owner:      "alice",
+			ToolUUID:   "res-tool",
+			Status:     common.STATUS_DONE,
+			Priority:   5,
+			Parameters: map[string]string{common.PARAM_HASHES_MULTI: "aaa\nbbb", "mode": "1000"},
+			Attempts:   []common.Attempt{{ResourceUUID: "res1", ToolUUID: "tool1"}},
+		},
+		{UUID: "chunk", ParentUUID: "orig"},
+		{UUID: "scan", Parameters: map[string]string{"target": "10.0.0.1"}},
+	}}
+	q.publish()
+
+	j, err := q.CloneJob("orig", "bob", map[string]string{"mode": "3000"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.UUID == "orig" || j.Owner != "bob" || j.ToolUUID != "tool1" || j.Priority != 0 || j.FeedFrom != "orig" {
+		t.Errorf("Unexpected clone %+v", j)
+	}
+	if j.Parameters["mode"] != "3000" || j.Parameters[common.PARAM_HASHES_MULTI] != "aaa\nbbb" {
+		t.Errorf("Unexpected clone parameters %v", j.Parameters)
+	}
+
+	// The original is left alone
+	if q.Snapshot().Job("orig").Parameters["mode"] != "1000" {
+		t.Error("Expected the original parameters to be unchanged")
+	}
+
+	for _, id := range []string{"missing", "chunk"} {
+		if _, err := q.CloneJob(id, "bob", nil, false); err == nil {
+			t.Errorf("Expected cloning %s to fail", id)
+		}
+	}
+	if _, err := q.CloneJob("scan", "bob", nil, true); err == nil {
+		t.Error("Expected running the remaining hashes of a job without hashes to fail")
+	}
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	bad := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range bad {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected an error parsing %q", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 2017-03-15 10:30
+	start := time.Date(2017, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2017, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 19 * * *", time.Date(2017, time.March, 15, 19, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2017, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2017, time.March, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2017, time.March, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10 15 3 *", time.Date(2018, time.March, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 8-17/4 * * 1-5", time.Date(2017, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 5", time.Date(2017, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2017, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		spec, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", test.expr, err.Error())
+			continue
+		}
+
+		if next := spec.Next(start); !next.Equal(test.next) {
+			t.Errorf("%q: expected %s but got %s", test.expr, test.next, next)
+		}
+	}
+
+	// Impossible dates never match
+	spec, _ := ParseCron("0 0 31 2 *")
+	if next := spec.Next(start); !next.IsZero() {
+		t.Errorf("Expected no match for February 31st but got %s", next)
+	}
+}
@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// jobIndex returns the index of the job in the stack or -1 if it does not exist.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) jobIndex(jobuuid string) int {
+	for i := range q.stack {
+		if q.stack[i].UUID == jobuuid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// checkJobDependencies validates the dependencies of a job being added to the
+// queue. A job fed from another job always depends on that job finishing.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) checkJobDependencies(j *common.Job) error {
+	if j.FeedFrom != "" {
+		var found bool
+		for _, d := range j.Dependencies {
+			if d.JobUUID == j.FeedFrom {
+				found = true
+			}
+		}
+
+		if !found {
+			j.Dependencies = append(j.Dependencies, common.Dependency{JobUUID: j.FeedFrom, Condition: common.DEPEND_FINISHED})
+		}
+	}
+
+	for i, d := range j.Dependencies {
+		if d.Condition == "" {
+			j.Dependencies[i].Condition = common.DEPEND_FINISHED
+		} else if !common.ValidCondition(d.Condition) {
+			return errors.New("Dependency condition " + d.Condition + " is not valid.")
+		}
+
+		if d.JobUUID == j.UUID || q.jobIndex(d.JobUUID) == -1 {
+			return errors.New("Dependency job " + d.JobUUID + " does not exist.")
+		}
+	}
+
+	return nil
+}
+
+// checkDependencies releases pending jobs once the jobs they depend on have
+// finished, replacing their hashes with those left by the job they are fed from.
+// Jobs whose dependencies can never be met are quit.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) checkDependencies() {
+	for i := range q.stack {
+		if q.stack[i].Status != common.STATUS_PENDING {
+			continue
+		}
+
+		logger := log.WithField("job", q.stack[i].UUID)
+
+		ready := true
+		var reason string
+		for _, d := range q.stack[i].Dependencies {
+			dep := q.jobIndex(d.JobUUID)
+			if dep == -1 {
+				reason = "Dependency job " + d.JobUUID + " no longer exists."
+				break
+			}
+
+			met, never := d.Met(q.stack[dep].Status)
+			if never {
+				reason = "Dependency job " + q.stack[dep].Name + " finished as " + q.stack[dep].Status + " instead of " + d.Condition + "."
+				break
+			}
+
+			if !met {
+				ready = false
+			}
+		}
+
+		if reason != "" {
+			logger.WithField("reason", reason).Info("Job dependencies can not be met, quitting job.")
+			q.finishPending(i, common.STATUS_QUIT, reason)
+			continue
+		}
+
+		if !ready {
+			continue
+		}
+
+		// Use the hashes the previous job was not able to crack
+		if q.stack[i].FeedFrom != "" {
+			feed := q.jobIndex(q.stack[i].FeedFrom)
+			if feed == -1 {
+				q.finishPending(i, common.STATUS_QUIT, "Job to take hashes from no longer exists.")
+				continue
+			}
+
+			remaining := common.RemainingHashes(q.stack[feed])
+			if len(remaining) == 0 {
+				logger.Info("No hashes left from the previous job, nothing to do.")
+				q.stack[i].Progress = 100
+				q.finishPending(i, common.STATUS_DONE, "No hashes were left from the previous job.")
+				continue
+			}
+
+			if q.stack[i].Parameters == nil {
+				q.stack[i].Parameters = make(map[string]string)
+			}
+			common.ReplaceInputHashes(q.stack[i].Parameters, remaining)
+
+			logger.WithFields(log.Fields{
+				"from":   q.stack[feed].UUID,
+				"hashes": len(remaining),
+			}).Debug("Job fed with remaining hashes.")
+		}
+
+		logger.Info("Job dependencies met, job is ready to start.")
+		q.stack[i].Status = common.STATUS_CREATED
+	}
+}
+
+// finishPending ends a pending job that will never run.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) finishPending(i int, status, reason string) {
+	q.stack[i].Status = status
+	q.stack[i].Error = reason
+	q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+
+	// Call out to the registered hooks that the job is complete
+	go HookOnJobFinish(Hooks.JobFinish, cloneJob(q.stack[i]))
+}
@@ -0,0 +1,368 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// ChunksPerResource is the number of keyspace chunks created for each resource
+// able to run a split job. More chunks balance better between resources of
+// different speeds at the cost of more task startup overhead.
+var ChunksPerResource = 2
+
+// MaxChunkRetries is the number of times a failed or disconnected chunk will be
+// re-queued before the whole job is failed.
+var MaxChunkRetries = 3
+
+// splitCandidate checks if a job could be split into keyspace chunks. Only top
+// level jobs that have not started yet and are allowed to start can be split.
+// Benchmarks measure one resource so they are never split.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) splitCandidate(job common.Job) bool {
+	if job.Status != common.STATUS_CREATED || job.ParentUUID != "" || job.Chunked || q.nosplit[job.UUID] || common.IsBenchmark(job) {
+		return false
+	}
+
+	// Jobs that are not allowed to start yet are split when they are
+	return !time.Now().Before(job.NotBefore)
+}
+
+// splitResources finds every running resource the job may use with a
+// splittable version of its tool.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) splitResources(job common.Job) []string {
+	var resources []string
+	for resKey, res := range q.pool {
+		if res.Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		if _, ok := placementScore(job.Placement, resKey, res); !ok {
+			continue
+		}
+
+		if tool, ok := res.Tools[job.ToolUUID]; ok && tool.Splittable {
+			resources = append(resources, resKey)
+		}
+	}
+
+	sort.Strings(resources)
+	return resources
+}
+
+// splitJobs looks for newly created jobs whose tool supports keyspace splitting
+// and breaks them into chunk jobs that can run on every resource with the tool.
+// The parent job stays in the stack to hold the aggregated results. Keyspaces
+// are fetched beforehand by fetchKeyspaces and jobs still waiting on one are
+// held back from starting.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) splitJobs() {
+	if q.nosplit == nil {
+		q.nosplit = map[string]bool{}
+	}
+	if q.keyspaces == nil {
+		q.keyspaces = map[string]int64{}
+	}
+	q.keyspaceWait = map[string]bool{}
+
+	for i := 0; i < len(q.stack); i++ {
+		job := q.stack[i]
+
+		if !q.splitCandidate(job) {
+			continue
+		}
+
+		resources := q.splitResources(job)
+
+		// Splitting only helps if more than one resource can take part
+		if len(resources) < 2 {
+			continue
+		}
+
+		logger := log.WithFields(log.Fields{
+			"job":       job.UUID,
+			"resources": len(resources),
+		})
+
+		// Wait for the keyspace before letting the job start on a single resource
+		keyspace, ok := q.keyspaces[job.UUID]
+		if !ok {
+			q.keyspaceWait[job.UUID] = true
+			continue
+		}
+		delete(q.keyspaces, job.UUID)
+
+		bounds := splitKeyspace(keyspace, int64(len(resources)*ChunksPerResource))
+		if len(bounds) < 2 {
+			logger.WithField("keyspace", keyspace).Debug("Keyspace is too small to split.")
+			q.nosplit[job.UUID] = true
+			continue
+		}
+
+		// Build a chunk job for each piece of the keyspace
+		chunks := make([]common.Job, 0, len(bounds))
+		for n, b := range bounds {
+			params := make(map[string]string, len(job.Parameters))
+			for k, v := range job.Parameters {
+				params[k] = v
+			}
+
+			chunk := common.NewJob(job.ToolUUID, fmt.Sprintf("%s [%d/%d]", job.Name, n+1, len(bounds)), job.Owner, params)
+			chunk.ParentUUID = job.UUID
+			chunk.KeyspaceSkip = b[0]
+			chunk.KeyspaceLimit = b[1]
+			chunk.Priority = job.Priority
+			chunk.Placement = job.Placement
+			chunk.OwnerRole = job.OwnerRole
+			addEvent(&chunk, common.EVENT_CREATED, "", "", "Split from job "+job.UUID+".")
+
+			chunks = append(chunks, chunk)
+		}
+
+		// The parent is now tracked through its chunks
+		q.stack[i].Chunked = true
+		q.stack[i].Keyspace = keyspace
+		q.stack[i].Status = common.STATUS_RUNNING
+		q.stack[i].StartTime = time.Now()
+		q.recordEvent(i, common.EVENT_SPLIT, "", "", fmt.Sprintf("Split into %d keyspace chunks.", len(chunks)))
+
+		// Insert the chunks right after the parent so they keep its place in the stack
+		newStack := make([]common.Job, 0, len(q.stack)+len(chunks))
+		newStack = append(newStack, q.stack[:i+1]...)
+		newStack = append(newStack, chunks...)
+		newStack = append(newStack, q.stack[i+1:]...)
+		q.stack = newStack
+
+		logger.WithFields(log.Fields{
+			"keyspace": keyspace,
+			"chunks":   len(chunks),
+		}).Info("Job split into keyspace chunks.")
+
+		// Call out to our registered hooks to note job has started
+		go HookOnJobStart(Hooks.JobStart, cloneJob(q.stack[i]))
+
+		i += len(chunks)
+	}
+}
+
+// splitKeyspace breaks a keyspace into at most n pieces returned as skip and
+// limit pairs. The last piece picks up any remainder.
+func splitKeyspace(keyspace, n int64) [][2]int64 {
+	if keyspace <= 0 || n <= 0 {
+		return nil
+	}
+
+	if n > keyspace {
+		n = keyspace
+	}
+
+	size := keyspace / n
+	bounds := make([][2]int64, 0, n)
+	for i := int64(0); i < n; i++ {
+		skip := i * size
+		limit := size
+		if i == n-1 {
+			limit = keyspace - skip
+		}
+
+		bounds = append(bounds, [2]int64{skip, limit})
+	}
+
+	return bounds
+}
+
+// aggregateChunks rolls the status of every chunk up into its parent job and
+// re-queues chunks that have failed.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) aggregateChunks() {
+	for i := range q.stack {
+		if !q.stack[i].Chunked || common.IsDone(q.stack[i].Status) {
+			continue
+		}
+
+		parent := &q.stack[i]
+
+		// Give failed chunks another chance before looking at the totals
+		for c := range q.stack {
+			if q.stack[c].ParentUUID == parent.UUID && q.stack[c].Status == common.STATUS_FAILED && q.stack[c].Retries < MaxChunkRetries {
+				q.retryJob(c, q.stack[c].Error)
+			}
+		}
+
+		var active, paused, done, failed int
+		var cracked bool
+		var progress float64
+		var speed float64
+		seen := map[string]bool{}
+		for _, row := range parent.OutputData {
+			seen[strings.Join(row, ":")] = true
+		}
+
+		for _, chunk := range q.stack {
+			if chunk.ParentUUID != parent.UUID {
+				continue
+			}
+
+			switch chunk.Status {
+			case common.STATUS_CREATED, common.STATUS_RUNNING:
+				active++
+			case common.STATUS_PAUSED:
+				paused++
+			case common.STATUS_DONE:
+				done++
+			case common.STATUS_FAILED:
+				failed++
+				if parent.Error == "" {
+					parent.Error = chunk.Error
+				}
+			}
+
+			// Weight the progress of each chunk by its share of the keyspace
+			if chunk.Status == common.STATUS_DONE {
+				progress += float64(chunk.KeyspaceLimit)
+			} else {
+				progress += chunk.Progress / 100 * float64(chunk.KeyspaceLimit)
+			}
+
+			// Keep every cracked hash even if the chunk is later re-queued
+			for _, row := range chunk.OutputData {
+				if key := strings.Join(row, ":"); !seen[key] {
+					seen[key] = true
+					parent.OutputData = append(parent.OutputData, row)
+				}
+			}
+
+			if len(parent.OutputTitles) == 0 {
+				parent.OutputTitles = chunk.OutputTitles
+			}
+
+			if chunk.CrackedHashes > parent.CrackedHashes {
+				parent.CrackedHashes = chunk.CrackedHashes
+			}
+
+			if chunk.TotalHashes > parent.TotalHashes {
+				parent.TotalHashes = chunk.TotalHashes
+			}
+
+			if chunk.Status == common.STATUS_RUNNING {
+				if parent.PerformanceTitle == "" {
+					parent.PerformanceTitle = chunk.PerformanceTitle
+				}
+				speed += common.LatestPerformance(chunk.PerformanceData)
+			}
+		}
+
+		if int64(len(parent.OutputData)) > parent.CrackedHashes {
+			parent.CrackedHashes = int64(len(parent.OutputData))
+		}
+
+		if parent.Keyspace > 0 {
+			parent.Progress = progress / float64(parent.Keyspace) * 100
+		}
+
+		if active > 0 {
+			if parent.PerformanceData == nil {
+				parent.PerformanceData = make(map[string]string)
+			}
+			parent.PerformanceData[fmt.Sprintf("%d", time.Now().Unix())] = fmt.Sprintf("%f", speed)
+		}
+
+		// Once every hash is cracked there is no need to search the rest of the keyspace
+		if active+paused > 0 && parent.TotalHashes > 0 && parent.CrackedHashes >= parent.TotalHashes {
+			log.WithField("job", parent.UUID).Info("All hashes cracked, quitting remaining chunks.")
+			q.quitChunks(parent.UUID)
+			active, paused = 0, 0
+			cracked = true
+		}
+
+		switch {
+		case active > 0:
+			parent.Status = common.STATUS_RUNNING
+			continue
+		case paused > 0:
+			parent.Status = common.STATUS_PAUSED
+			continue
+		case failed > 0 && !cracked:
+			parent.Status = common.STATUS_FAILED
+		case done > 0 || cracked:
+			parent.Status = common.STATUS_DONE
+			parent.Progress = 100
+		default:
+			parent.Status = common.STATUS_QUIT
+		}
+
+		// The parent job is now finished
+		log.WithFields(log.Fields{
+			"JobID":  parent.UUID,
+			"status": parent.Status,
+		}).Debug("Chunked job has finished.")
+
+		go HookOnJobFinish(Hooks.JobFinish, cloneJob(*parent))
+
+		parent.PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+	}
+}
+
+// quitChunks stops every unfinished chunk of a parent job. The quit calls are
+// made by a worker once the lock is released (See runDispatches).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) quitChunks(parentUUID string) {
+	for i := range q.stack {
+		if q.stack[i].ParentUUID != parentUUID {
+			continue
+		}
+
+		switch q.stack[i].Status {
+		case common.STATUS_CREATED:
+			q.stack[i].Status = common.STATUS_QUIT
+		case common.STATUS_RUNNING, common.STATUS_PAUSED:
+			// Paused chunks have already given their hardware back
+			if q.stack[i].Status == common.STATUS_RUNNING {
+				q.releaseHardware(i)
+			}
+
+			q.stack[i].Status = common.STATUS_QUIT
+			q.stopTask(i, "Queue.TaskQuit")
+		}
+	}
+}
+
+// pauseChunks pauses every running chunk of a parent job. The pause calls are
+// made by a worker once the lock is released (See runDispatches), so every
+// chunk is tried even if some fail. A chunk that fails to pause is started over
+// (See applyStop).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) pauseChunks(parentUUID string) {
+	for i := range q.stack {
+		if q.stack[i].ParentUUID != parentUUID || q.stack[i].Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		q.stack[i].Status = common.STATUS_PAUSED
+		q.stopTask(i, "Queue.TaskPause")
+		q.releaseHardware(i)
+	}
+}
+
+// releaseHardware marks the hardware used by the job at index i as free on its
+// assigned resource.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) releaseHardware(i int) {
+	res, ok := q.pool[q.stack[i].ResAssigned]
+	if !ok {
+		return
+	}
+
+	// Find the tool by its real UUID since the Job's might have changed (See AddJob)
+	for _, tool := range res.Tools {
+		if tool.UUID == q.stack[i].ToolUUID {
+			res.freeHardware(tool)
+			return
+		}
+	}
+}
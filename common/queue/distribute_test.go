@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestSplitKeyspace(t *testing.T) {
+	bounds := splitKeyspace(10, 3)
+	if len(bounds) != 3 {
+		t.Fatalf("Expected 3 chunks but got %d", len(bounds))
+	}
+
+	// The chunks should cover the whole keyspace without overlapping
+	var next int64
+	for _, b := range bounds {
+		if b[0] != next {
+			t.Errorf("Expected chunk to skip %d but got %d", next, b[0])
+		}
+		next = b[0] + b[1]
+	}
+	if next != 10 {
+		t.Errorf("Expected chunks to cover a keyspace of 10 but covered %d", next)
+	}
+
+	// Never more chunks than the keyspace
+	if bounds = splitKeyspace(2, 8); len(bounds) != 2 {
+		t.Errorf("Expected 2 chunks but got %d", len(bounds))
+	}
+
+	if bounds = splitKeyspace(0, 4); len(bounds) != 0 {
+		t.Errorf("Expected no chunks for an empty keyspace but got %d", len(bounds))
+	}
+}
+
+func TestPauseChunksAfterFailure(t *testing.T) {
+	tool := common.Tool{UUID: "tool1", Requirements: common.RES_GPU}
+	pool := ResourcePool{}
+	for _, key := range []string{"res1", "res2"} {
+		res := NewResource()
+		res.Status = common.STATUS_RUNNING
+		res.Tools["tool1"] = tool
+		res.Capacity[common.RES_GPU] = 1
+		pool[key] = res
+	}
+
+	q := &Queue{
+		pool: pool,
+		stack: []common.Job{
+			{UUID: "parent", Chunked: true, Status: common.STATUS_RUNNING},
+			{UUID: "chunk1", ParentUUID: "parent", ToolUUID: "tool1", ResAssigned: "res1", Status: common.STATUS_RUNNING},
+			{UUID: "chunk2", ParentUUID: "parent", ToolUUID: "tool1", ResAssigned: "res2", Status: common.STATUS_RUNNING},
+		},
+	}
+
+	// Neither resource is connected so both pause calls fail
+	if err := q.PauseJob("parent", "tester"); err != nil {
+		t.Fatal(err)
+	}
+
+	q.RLock()
+	if q.stack[0].Status != common.STATUS_PAUSED {
+		t.Errorf("Expected the parent to be paused but it is %s", q.stack[0].Status)
+	}
+	for _, key := range []string{"res1", "res2"} {
+		if free := q.pool[key].Hardware[common.RES_GPU]; free != 1 {
+			t.Errorf("Expected the GPU on %s to be freed but %d are free", key, free)
+		}
+	}
+	q.RUnlock()
+
+	// Every chunk is tried and each failure is noted on the parent
+	var errs int
+	for start := time.Now(); time.Since(start) < time.Second && errs < 2; time.Sleep(10 * time.Millisecond) {
+		q.RLock()
+		errs = 0
+		for _, e := range q.stack[0].Events {
+			if e.Type == common.EVENT_ERROR {
+				errs++
+			}
+		}
+		q.RUnlock()
+	}
+	if errs != 2 {
+		t.Fatalf("Expected an error on the parent for each chunk but got %d", errs)
+	}
+
+	q.RLock()
+	defer q.RUnlock()
+	for _, chunk := range q.stack[1:] {
+		if chunk.Status != common.STATUS_CREATED {
+			t.Errorf("Expected chunk %s to be started over but it is %s", chunk.UUID, chunk.Status)
+		}
+	}
+}
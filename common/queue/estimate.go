@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"errors"
+	"math"
+	"net/rpc"
+	"sort"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// A ResourceEstimate is how long a job would take on one resource
+type ResourceEstimate struct {
+	Resource string
+	Name     string
+	Speed    float64       // Hashes a second, 0 if no speed has been recorded
+	Duration time.Duration // 0 if no speed has been recorded
+}
+
+// An Estimate is how much work a job is and how long it should take. The
+// durations are 0 when no resource with the tool has a recorded speed.
+type Estimate struct {
+	Candidates float64
+	Mode       string
+	Resources  []ResourceEstimate
+	Fastest    time.Duration // On every resource at once for jobs that can be split, otherwise the fastest resource
+	Slowest    time.Duration // On the slowest resource
+}
+
+// estimateDuration returns how long trying the candidates takes at a speed,
+// capped at the longest time.Duration
+func estimateDuration(candidates, speed float64) time.Duration {
+	if speed <= 0 {
+		return 0
+	}
+
+	d := candidates / speed * float64(time.Second)
+	if d >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(d)
+}
+
+// calculate works out the durations of the estimate from the candidates and
+// the speed of each resource
+func (e *Estimate) calculate(splittable bool) {
+	var total, fastest, slowest float64
+	for i := range e.Resources {
+		speed := e.Resources[i].Speed
+		if speed <= 0 {
+			continue
+		}
+
+		e.Resources[i].Duration = estimateDuration(e.Candidates, speed)
+
+		total += speed
+		if speed > fastest {
+			fastest = speed
+		}
+		if slowest == 0 || speed < slowest {
+			slowest = speed
+		}
+	}
+
+	if splittable {
+		fastest = total
+	}
+
+	e.Fastest = estimateDuration(e.Candidates, fastest)
+	e.Slowest = estimateDuration(e.Candidates, slowest)
+}
+
+// EstimateJob works out how many candidates a job would try and how long that
+// would take on the resources that have its tool, using the speeds recorded
+// for the job's mode. A resource is asked to count the candidates, so the
+// tool must support it (See common.Estimator).
+// NO LOCK SHOULD BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) EstimateJob(j common.Job) (Estimate, error) {
+	est := Estimate{Mode: j.Parameters[SpeedModeParameter]}
+
+	q.RLock()
+	tool, ok := q.findTool(j.ToolUUID)
+	if !ok {
+		q.RUnlock()
+		return Estimate{}, errors.New("Tool does not exist!")
+	}
+
+	var client *rpc.Client
+	var resTool string
+	for key, res := range q.pool {
+		if res.Status == common.STATUS_QUIT {
+			continue
+		}
+
+		rt, ok := res.Tools[j.ToolUUID]
+		if !ok {
+			continue
+		}
+
+		if client == nil {
+			client, resTool = res.Client, rt.UUID
+		}
+
+		speed, _ := q.speedOf(key, tool.Name, est.Mode)
+		est.Resources = append(est.Resources, ResourceEstimate{
+			Resource: key,
+			Name:     res.Name,
+			Speed:    speed.Speed,
+		})
+	}
+	q.RUnlock()
+
+	if client == nil {
+		return Estimate{}, errors.New("No connected resource has the tool.")
+	}
+
+	// The resource only knows the tool by its own UUID (See AddJob)
+	j.ToolUUID = resTool
+	if err := callWithin(client, "Queue.TaskCandidates", common.RPCCall{Job: j}, &est.Candidates, KeyspaceTimeout); err != nil {
+		return Estimate{}, err
+	}
+
+	sort.Slice(est.Resources, func(a, b int) bool { return est.Resources[a].Name < est.Resources[b].Name })
+	est.calculate(tool.Splittable)
+
+	return est, nil
+}
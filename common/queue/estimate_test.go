@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestRecordSpeed(t *testing.T) {
+	q := Queue{
+		pool: ResourcePool{
+			"res1": {Tools: map[string]common.Tool{"tool1": {Name: "Hashcat", UUID: "real1"}}},
+		},
+		stack: []common.Job{{
+			UUID:             "job",
+			ToolUUID:         "real1",
+			ResAssigned:      "res1",
+			Parameters:       map[string]string{"hashmode": "1000"},
+			PerformanceTitle: "MH/s",
+			PerformanceData:  map[string]string{"1": "10.0", "2": "30.0", "3": "0.0"},
+		}},
+	}
+
+	q.recordSpeed(0)
+
+	s, ok := q.speedOf("res1", "hashcat", "1000")
+	if !ok || s.Speed != 20e6 || s.Source != SPEED_SOURCE_JOB {
+		t.Errorf("Expected the average of 20 MH/s to be recorded but got %+v", s)
+	}
+
+	// Performance that is not a hash rate is left out
+	q.stack[0].PerformanceTitle = "Time data"
+	q.stack[0].Parameters["hashmode"] = "0"
+	q.recordSpeed(0)
+	if _, ok := q.speedOf("res1", "Hashcat", "0"); ok {
+		t.Error("Expected a job without a hash rate not to be recorded")
+	}
+}
+
+func TestEstimateCalculate(t *testing.T) {
+	est := Estimate{
+		Candidates: 3600e6,
+		Resources: []ResourceEstimate{
+			{Resource: "fast", Speed: 2e6},
+			{Resource: "slow", Speed: 1e6},
+			{Resource: "unknown"},
+		},
+	}
+
+	est.calculate(false)
+	if est.Fastest != 30*time.Minute || est.Slowest != time.Hour {
+		t.Errorf("Expected 30m to 1h but got %v to %v", est.Fastest, est.Slowest)
+	}
+	if est.Resources[2].Duration != 0 {
+		t.Error("Expected no duration for a resource without a speed")
+	}
+
+	// Split jobs use every resource at once
+	est.calculate(true)
+	if est.Fastest != 20*time.Minute {
+		t.Errorf("Expected 20m across both resources but got %v", est.Fastest)
+	}
+
+	est.Candidates = 1e30
+	est.calculate(false)
+	if est.Slowest <= 0 {
+		t.Errorf("Expected a huge estimate to be capped but got %v", est.Slowest)
+	}
+}
+
+// candidateResource answers Queue.TaskCandidates like a resource would
+type candidateResource struct{}
+
+func (candidateResource) TaskCandidates(rpc common.RPCCall, candidates *float64) error {
+	*candidates = 3600e6
+	return nil
+}
+
+func TestEstimateAfterRestart(t *testing.T) {
+	q, err := NewQueue("", 30, 1, HookParameters{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Speeds saved by an older version under the UUID the resource had then
+	q.restoreState(StateFile{
+		Pool:   ResourcePool{"old": {Name: "rig1", Tools: map[string]common.Tool{"tool1": {Name: "Hashcat", UUID: "real1"}}}},
+		Speeds: Speeds{"old": {speedKey("Hashcat", "1000"): {Tool: "Hashcat", Mode: "1000", Speed: 2e6}}},
+	})
+
+	// The resource reconnects under a new UUID
+	server := rpc.NewServer()
+	server.RegisterName("Queue", candidateResource{})
+	local, remote := net.Pipe()
+	go server.ServeConn(remote)
+	client := rpc.NewClient(local)
+	defer client.Close()
+
+	resUUID, err := q.AddResource("rig1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := NewResource()
+	res.Name = "rig1"
+	res.Client = client
+	res.Status = common.STATUS_RUNNING
+	res.Tools["tool1"] = common.Tool{Name: "Hashcat", UUID: "real1"}
+	q.Lock()
+	q.pool[resUUID] = res
+	q.Unlock()
+
+	est, err := q.EstimateJob(common.Job{ToolUUID: "tool1", Parameters: map[string]string{SpeedModeParameter: "1000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Fastest != 30*time.Minute || est.Slowest != 30*time.Minute {
+		t.Errorf("Expected 30m from the speed saved before the restart but got %v to %v", est.Fastest, est.Slowest)
+	}
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// MaxJobEvents is how many events are kept for each job, dropping the oldest
+var MaxJobEvents = 200
+
+// addEvent appends an event to a job, taking the status from the job as it is now
+func addEvent(j *common.Job, kind, resKey, user, msg string) {
+	j.Events = append(j.Events, common.Event{
+		Time:     time.Now(),
+		Type:     kind,
+		Status:   j.Status,
+		Resource: resKey,
+		User:     user,
+		Message:  msg,
+	})
+
+	if MaxJobEvents > 0 && len(j.Events) > MaxJobEvents {
+		j.Events = append([]common.Event(nil), j.Events[len(j.Events)-MaxJobEvents:]...)
+	}
+}
+
+// recordEvent appends an event to the job at index i
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) recordEvent(i int, kind, resKey, user, msg string) {
+	addEvent(&q.stack[i], kind, resKey, user, msg)
+}
+
+// recordRepeatedError adds an error event to the job at index i unless it is
+// the same as the last event, so an error seen on every keeper run, such as a
+// resource not answering status calls, does not push out the job's history.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) recordRepeatedError(i int, msg string) {
+	j := &q.stack[i]
+	if n := len(j.Events); n > 0 {
+		last := j.Events[n-1]
+		if last.Type == common.EVENT_ERROR && last.Resource == j.ResAssigned && last.Message == msg {
+			return
+		}
+	}
+
+	addEvent(j, common.EVENT_ERROR, j.ResAssigned, "", msg)
+}
+
+// recordStatusChanges adds an event for every job whose status has changed
+// without one being recorded, such as a tool finishing on its resource.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) recordStatusChanges() {
+	for i := range q.stack {
+		j := &q.stack[i]
+
+		// Jobs saved before events were kept have none, so finished ones are left alone
+		n := len(j.Events)
+		if n == 0 && common.IsDone(j.Status) {
+			continue
+		}
+		if n > 0 && j.Events[n-1].Status == j.Status {
+			continue
+		}
+
+		if common.IsDone(j.Status) {
+			addEvent(j, common.EVENT_FINISHED, j.ResAssigned, "", j.Error)
+		} else {
+			addEvent(j, common.EVENT_STATUS, j.ResAssigned, "", "")
+		}
+	}
+}
+
+// JobEvents returns the events of the job with the UUID given
+func (q *Queue) JobEvents(jobUUID string) ([]common.Event, bool) {
+	for _, j := range q.Snapshot().Jobs {
+		if j.UUID == jobUUID {
+			return j.Events, true
+		}
+	}
+
+	return nil, false
+}
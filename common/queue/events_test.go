@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestRecordStatusChanges(t *testing.T) {
+	q := Queue{stack: []common.Job{
+		{UUID: "new", Status: common.STATUS_CREATED},
+		{UUID: "old", Status: common.STATUS_DONE},
+	}}
+	addEvent(&q.stack[0], common.EVENT_CREATED, "", "alice", "")
+
+	// Nothing has changed since the job was created
+	q.recordStatusChanges()
+	if len(q.stack[0].Events) != 1 || len(q.stack[1].Events) != 0 {
+		t.Fatalf("Expected no new events but got %+v", q.stack)
+	}
+
+	q.stack[0].Status = common.STATUS_FAILED
+	q.stack[0].Error = "Tool crashed."
+	q.stack[0].ResAssigned = "res1"
+	q.recordStatusChanges()
+	q.recordStatusChanges()
+
+	events := q.stack[0].Events
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events but got %+v", events)
+	}
+	if e := events[1]; e.Type != common.EVENT_FINISHED || e.Status != common.STATUS_FAILED || e.Resource != "res1" || e.Message != "Tool crashed." {
+		t.Errorf("Unexpected finish event %+v", e)
+	}
+}
+
+func TestAddEventLimit(t *testing.T) {
+	defer func(max int) { MaxJobEvents = max }(MaxJobEvents)
+	MaxJobEvents = 3
+
+	var j common.Job
+	for _, kind := range []string{common.EVENT_CREATED, common.EVENT_STARTED, common.EVENT_PAUSED, common.EVENT_RESUMED} {
+		addEvent(&j, kind, "", "", "")
+	}
+
+	if len(j.Events) != 3 || j.Events[0].Type != common.EVENT_STARTED || j.Events[2].Type != common.EVENT_RESUMED {
+		t.Errorf("Expected the newest 3 events but got %+v", j.Events)
+	}
+}
+
+func TestRecordRepeatedError(t *testing.T) {
+	q := Queue{stack: []common.Job{{UUID: "job", Status: common.STATUS_RUNNING, ResAssigned: "res1"}}}
+
+	q.recordRepeatedError(0, "Unable to get status: timeout")
+	q.recordRepeatedError(0, "Unable to get status: timeout")
+	if n := len(q.stack[0].Events); n != 1 {
+		t.Fatalf("Expected a repeated error to be recorded once but got %d events", n)
+	}
+
+	q.recordRepeatedError(0, "Unable to get status: refused")
+	addEvent(&q.stack[0], common.EVENT_STATUS, "res1", "", "")
+	q.recordRepeatedError(0, "Unable to get status: refused")
+	if n := len(q.stack[0].Events); n != 4 {
+		t.Errorf("Expected new errors and errors after other events to be recorded but got %d events", n)
+	}
+}
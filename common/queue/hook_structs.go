@@ -13,6 +13,7 @@ type HookParameters struct {
 	JobStart        []string
 	ResourceConnect []string
 	QueueReorder    []string
+	JobPreempt      []string
 }
 
 // Jobs structure for hook
@@ -33,6 +34,13 @@ type HookJob struct {
 	OutputData       [][]string        `json:"outputdata"`
 }
 
+// Preemption structure for hooks
+type HookJobPreempt struct {
+	Preempted  HookJob `json:"preempted"`
+	Urgent     HookJob `json:"urgent"`
+	ResourceID string  `json:"resourceid"`
+}
+
 // Resource structure to be used for hooks
 type HookResource struct {
 	ID      string `json:"id"`
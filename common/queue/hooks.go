@@ -54,6 +54,23 @@ func HookOnJobFinish(hooks []string, j common.Job) {
 
 }
 
+/* Runs when a running job is paused by the keeper to make room for a higher
+ * priority job
+ */
+func HookOnJobPreempt(hooks []string, preempted common.Job, urgent common.Job, resourceid string) {
+	log.WithFields(log.Fields{
+		"id":     preempted.UUID,
+		"urgent": urgent.UUID,
+	}).Debug("Executing hooks against job preemption.")
+
+	var data HookJobPreempt
+	data.Preempted = copyJobToHookJob(preempted)
+	data.Urgent = copyJobToHookJob(urgent)
+	data.ResourceID = resourceid
+
+	hooksRun(hooks, data)
+}
+
 /* Runs when a resource is initially connected to the queue
  */
 func HookOnResourceConnect(hooks []string, id string, r Resource) {
@@ -96,6 +113,19 @@ func hookPerformWebPOST(url string, data interface{}) error {
 
 	// POST up our data and then return if we got an error or not.
 	res, err := http.Post(url, "application/json; charset=utf-8", b)
+	if err != nil {
+		hookFailures.Inc("web")
+		log.WithFields(log.Fields{
+			"url": url,
+			"msg": err.Error(),
+		}).Warn("Unable to POST to webhook.")
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		hookFailures.Inc("web")
+	}
 
 	log.WithFields(log.Fields{
 		"url":    url,
@@ -117,6 +147,7 @@ func hookPerformScriptExecute(path string, data interface{}) error {
 			"path": path,
 			"msg":  err.Error(),
 		}).Error("Unable to open hook script file.")
+		hookFailures.Inc("script")
 		return err
 	}
 
@@ -140,6 +171,7 @@ func hookRunUnsafeCode(unsafe *os.File, data interface{}) {
 	defer func() {      // This function will cleanup when completed.
 		duration := time.Since(start)
 		if caught := recover(); caught != nil {
+			hookFailures.Inc("script")
 			if caught == halt {
 				log.WithFields(log.Fields{
 					"path":     unsafe.Name(),
@@ -169,6 +201,7 @@ func hookRunUnsafeCode(unsafe *os.File, data interface{}) {
 
 	_, err := vm.Run(unsafe) // Here be dragons (risky code)
 	if err != nil {
+		hookFailures.Inc("script")
 		log.WithFields(log.Fields{
 			"path": unsafe.Name(),
 			"msg": err,
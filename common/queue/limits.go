@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// limitExceeded returns why a job has gone past its runtime limit or deadline,
+// or an empty string if it has not.
+func limitExceeded(j common.Job, now time.Time) string {
+	if j.MaxRuntime > 0 && j.RunTime >= j.MaxRuntime {
+		return "Job exceeded its maximum runtime of " + j.MaxRuntime.String() + "."
+	}
+
+	if !j.Deadline.IsZero() && now.After(j.Deadline) {
+		return "Job passed its deadline of " + j.Deadline.Format(time.RFC3339) + "."
+	}
+
+	return ""
+}
+
+// trackRuntime adds the time since the last keeper run to the RunTime of every
+// running job. Time spent paused or waiting does not count.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) trackRuntime(now time.Time) {
+	if q.runClock == nil {
+		q.runClock = map[string]time.Time{}
+	}
+
+	for i := range q.stack {
+		id := q.stack[i].UUID
+
+		if q.stack[i].Status != common.STATUS_RUNNING {
+			delete(q.runClock, id)
+			continue
+		}
+
+		if last, ok := q.runClock[id]; ok {
+			q.stack[i].RunTime += now.Sub(last)
+
+			// Chunked jobs are counted through their chunks on each resource
+			if !q.stack[i].Chunked {
+				q.stats.addRuntime(q.stack[i].ResAssigned, now.Sub(last))
+
+				if units := q.gpuUnits(i); units > 0 {
+					q.stats.addGPUTime(q.stack[i].Owner, now.Sub(last)*time.Duration(units), now)
+				}
+			}
+		}
+		q.runClock[id] = now
+	}
+}
+
+// enforceLimits fails every job that has run longer than its MaxRuntime or is
+// still unfinished after its Deadline. This is done by the Queue so it does not
+// depend on the tool on the resource honouring any limits.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) enforceLimits() {
+	now := time.Now()
+	q.trackRuntime(now)
+
+	for i := range q.stack {
+		// Chunks are stopped through their parent
+		if q.stack[i].ParentUUID != "" || common.IsDone(q.stack[i].Status) {
+			continue
+		}
+
+		reason := limitExceeded(q.stack[i], now)
+		if reason == "" {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"job":    q.stack[i].UUID,
+			"status": q.stack[i].Status,
+			"reason": reason,
+		}).Warn("Job is past its limits, stopping it.")
+
+		switch {
+		case q.stack[i].Chunked:
+			q.quitChunks(q.stack[i].UUID)
+		case q.stack[i].Status == common.STATUS_RUNNING || q.stack[i].Status == common.STATUS_PAUSED:
+			if _, ok := q.pool[q.stack[i].ResAssigned]; !ok {
+				break
+			}
+
+			// Paused jobs have already given their hardware back
+			if q.stack[i].Status == common.STATUS_RUNNING {
+				q.releaseHardware(i)
+			}
+
+			// The quit is made by a worker once the lock is released
+			q.stopTask(i, "Queue.TaskQuit")
+		}
+
+		q.stack[i].Status = common.STATUS_FAILED
+		q.stack[i].Error = reason
+		q.stack[i].PurgeTime = now.Add(time.Duration(q.jpurge*24) * time.Hour)
+		delete(q.runClock, q.stack[i].UUID)
+
+		go HookOnJobFinish(Hooks.JobFinish, cloneJob(q.stack[i]))
+	}
+}
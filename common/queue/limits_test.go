@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestLimitExceeded(t *testing.T) {
+	now := time.Now()
+
+	var j common.Job
+	if reason := limitExceeded(j, now); reason != "" {
+		t.Errorf("Expected a job without limits to pass but got %q", reason)
+	}
+
+	j.MaxRuntime = time.Hour
+	j.RunTime = 59 * time.Minute
+	if reason := limitExceeded(j, now); reason != "" {
+		t.Errorf("Expected a job under its runtime to pass but got %q", reason)
+	}
+
+	j.RunTime = time.Hour
+	if reason := limitExceeded(j, now); reason == "" {
+		t.Error("Expected a job at its maximum runtime to be stopped")
+	}
+
+	j = common.Job{Deadline: now.Add(time.Minute)}
+	if reason := limitExceeded(j, now); reason != "" {
+		t.Errorf("Expected a job before its deadline to pass but got %q", reason)
+	}
+
+	if reason := limitExceeded(j, now.Add(2*time.Minute)); reason == "" {
+		t.Error("Expected a job past its deadline to be stopped")
+	}
+}
+
+func TestTrackRuntime(t *testing.T) {
+	now := time.Now()
+	q := Queue{stack: []common.Job{
+		{UUID: "running", Status: common.STATUS_RUNNING},
+		{UUID: "paused", Status: common.STATUS_PAUSED},
+	}}
+
+	q.trackRuntime(now)
+	q.trackRuntime(now.Add(time.Minute))
+
+	if q.stack[0].RunTime != time.Minute {
+		t.Errorf("Expected a runtime of 1m but got %s", q.stack[0].RunTime)
+	}
+	if q.stack[1].RunTime != 0 {
+		t.Errorf("Expected a paused job not to gain runtime but got %s", q.stack[1].RunTime)
+	}
+}
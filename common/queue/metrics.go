@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/jmmcatee/cracklord/common/metrics"
+)
+
+var (
+	keeperDuration = metrics.NewHistogram("cracklord_keeper_duration_seconds",
+		"Time taken by each run of the queue keeper.", metrics.DefBuckets)
+	rpcDuration = metrics.NewHistogram("cracklord_rpc_duration_seconds",
+		"Time taken by RPC calls to resources.", metrics.DefBuckets, "resource", "method")
+	rpcErrors = metrics.NewCounter("cracklord_rpc_errors_total",
+		"RPC calls to resources that failed or timed out.", "resource", "method")
+	hookFailures = metrics.NewCounter("cracklord_hook_failures_total",
+		"Hooks that could not be run or returned an error.", "type")
+)
+
+// clientNames maps the RPC client of each connected resource to its name so
+// calls can be labelled by resource
+var clientNames sync.Map
+
+func setClientName(client *rpc.Client, name string) {
+	if client != nil {
+		clientNames.Store(client, name)
+	}
+}
+
+func forgetClient(client *rpc.Client) {
+	if client != nil {
+		clientNames.Delete(client)
+	}
+}
+
+func clientName(client *rpc.Client) string {
+	if name, ok := clientNames.Load(client); ok {
+		return name.(string)
+	}
+
+	return "unknown"
+}
+
+// observeCall records the time taken by an RPC call and whether it failed
+func observeCall(client *rpc.Client, method string, start time.Time, err error) {
+	name := clientName(client)
+
+	rpcDuration.Observe(time.Since(start).Seconds(), name, method)
+	if err != nil {
+		rpcErrors.Inc(name, method)
+	}
+}
+
+// RegisterMetrics adds gauges for the jobs and resources of the Queue, read from
+// the latest Snapshot when they are scraped. It should only be called once.
+func (q *Queue) RegisterMetrics() {
+	metrics.NewGaugeFunc("cracklord_queue_jobs", "Jobs in the queue by status.",
+		[]string{"status"}, func() []metrics.Sample {
+			counts := map[string]int{}
+			for _, j := range q.Snapshot().Jobs {
+				if j.ParentUUID == "" {
+					counts[j.Status]++
+				}
+			}
+
+			var samples []metrics.Sample
+			for status, n := range counts {
+				samples = append(samples, metrics.Sample{Labels: []string{status}, Value: float64(n)})
+			}
+			return samples
+		})
+
+	metrics.NewGaugeFunc("cracklord_resource_running_jobs", "Jobs running on each resource.",
+		[]string{"resource"}, func() []metrics.Sample {
+			snap := q.Snapshot()
+
+			// Connected resources without running jobs still report zero
+			counts := map[string]int{}
+			for _, res := range snap.Resources {
+				if res.Status == common.STATUS_RUNNING {
+					counts[res.Name] = 0
+				}
+			}
+			for _, j := range snap.Jobs {
+				if j.Status != common.STATUS_RUNNING || j.ResAssigned == "" {
+					continue
+				}
+				if res, ok := snap.Resources[j.ResAssigned]; ok {
+					counts[res.Name]++
+				}
+			}
+
+			var samples []metrics.Sample
+			for name, n := range counts {
+				samples = append(samples, metrics.Sample{Labels: []string{name}, Value: float64(n)})
+			}
+			return samples
+		})
+}
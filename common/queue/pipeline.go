@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/pborman/uuid"
+)
+
+// A Pipeline is a chain of jobs created together, where each stage depends on
+// the one before it and may be fed the hashes it left uncracked.
+type Pipeline struct {
+	UUID    string    `json:"uuid"`
+	Name    string    `json:"name"`
+	Owner   string    `json:"owner"`
+	Created time.Time `json:"created"`
+	Jobs    []string  `json:"jobs"`
+}
+
+// PipelineStage describes one job of a pipeline
+type PipelineStage struct {
+	Job       common.Job
+	Condition string // Condition on the previous stage, finished by default
+	Feed      bool   // Use the hashes the previous stage left uncracked
+}
+
+// AddPipeline chains the stages together and adds them to the queue as jobs.
+// If any stage can not be added the stages already added are removed.
+func (q *Queue) AddPipeline(name, owner string, stages []PipelineStage) (Pipeline, error) {
+	if len(stages) == 0 {
+		return Pipeline{}, errors.New("A pipeline needs at least one stage.")
+	}
+
+	p := Pipeline{
+		UUID:    uuid.New(),
+		Name:    name,
+		Owner:   owner,
+		Created: time.Now(),
+	}
+
+	for i, stage := range stages {
+		j := stage.Job
+
+		if i > 0 {
+			prev := p.Jobs[i-1]
+
+			condition := stage.Condition
+			if condition == "" {
+				condition = common.DEPEND_FINISHED
+			}
+			j.Dependencies = append(j.Dependencies, common.Dependency{JobUUID: prev, Condition: condition})
+
+			if stage.Feed {
+				j.FeedFrom = prev
+			}
+		}
+
+		if err := q.AddJob(j); err != nil {
+			log.WithFields(log.Fields{
+				"pipeline": p.UUID,
+				"stage":    i,
+				"error":    err.Error(),
+			}).Error("Unable to add pipeline stage, removing pipeline.")
+
+			for _, added := range p.Jobs {
+				q.RemoveJob(added, p.Owner)
+			}
+
+			return Pipeline{}, err
+		}
+
+		p.Jobs = append(p.Jobs, j.UUID)
+	}
+
+	q.Lock()
+	if q.pipelines == nil {
+		q.pipelines = map[string]Pipeline{}
+	}
+	q.pipelines[p.UUID] = p
+	q.Unlock()
+
+	log.WithFields(log.Fields{
+		"pipeline": p.UUID,
+		"stages":   len(p.Jobs),
+	}).Info("Pipeline created.")
+
+	return p, nil
+}
+
+// AllPipelines returns every pipeline the queue knows about
+func (q *Queue) AllPipelines() []Pipeline {
+	q.RLock()
+	defer q.RUnlock()
+
+	pipelines := make([]Pipeline, 0, len(q.pipelines))
+	for _, p := range q.pipelines {
+		pipelines = append(pipelines, p)
+	}
+
+	return pipelines
+}
+
+// PipelineInfo returns the pipeline with the UUID given
+func (q *Queue) PipelineInfo(pipelineuuid string) (Pipeline, bool) {
+	q.RLock()
+	defer q.RUnlock()
+
+	p, ok := q.pipelines[pipelineuuid]
+	return p, ok
+}
+
+// purgePipelines removes pipelines once all of their jobs have been purged.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) purgePipelines() {
+	for id, p := range q.pipelines {
+		var exists bool
+		for _, j := range p.Jobs {
+			if q.jobIndex(j) != -1 {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			delete(q.pipelines, id)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// ParseLabels parses labels written as a comma separated list of key=value
+// pairs, such as "site=lab2, gpu=a100". A key without a value has an empty value.
+func ParseLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			return nil, errors.New("Label " + pair + " does not have a name.")
+		}
+
+		labels[key] = ""
+		if len(kv) == 2 {
+			labels[key] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	return labels, nil
+}
+
+// FormatLabels writes labels in the form read by ParseLabels
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if v == "" {
+			pairs = append(pairs, k)
+		} else {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// labelsMatch checks if a label wanted by a job is on the resource
+func labelsMatch(labels map[string]string, key, value string) bool {
+	v, ok := labels[key]
+	if !ok {
+		return false
+	}
+
+	return value == "" || v == value
+}
+
+// placementScore checks if a job with the placement given may run on the
+// resource and, if it can, how many of its preferred labels the resource has.
+func placementScore(p common.Placement, resUUID string, res Resource) (score int, ok bool) {
+	if p.Pin != "" && p.Pin != resUUID {
+		return 0, false
+	}
+
+	for _, avoid := range p.Avoid {
+		if avoid == resUUID || avoid == res.Name {
+			return 0, false
+		}
+	}
+
+	labels := res.AllLabels()
+	for k, v := range p.Require {
+		if !labelsMatch(labels, k, v) {
+			return 0, false
+		}
+	}
+
+	for k, v := range p.Prefer {
+		if labelsMatch(labels, k, v) {
+			score++
+		}
+	}
+
+	return score, true
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels(" site=lab2, gpu = a100 ,dedicated,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(labels) != 3 || labels["site"] != "lab2" || labels["gpu"] != "a100" {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+	if v, ok := labels["dedicated"]; !ok || v != "" {
+		t.Errorf("Expected a label without a value but got %v", labels)
+	}
+
+	if FormatLabels(labels) != "dedicated, gpu=a100, site=lab2" {
+		t.Errorf("Unexpected formatted labels %q", FormatLabels(labels))
+	}
+
+	if _, err := ParseLabels("=lab2"); err == nil {
+		t.Error("Expected an error for a label without a name")
+	}
+}
+
+func TestPlacementScore(t *testing.T) {
+	res := Resource{
+		Name:          "rig1",
+		Labels:        map[string]string{"site": "lab1", "gpu": "a100"},
+		ManagerLabels: map[string]string{"site": "lab2"},
+	}
+
+	// Manager labels override the resource's own
+	if _, ok := placementScore(common.Placement{Require: map[string]string{"site": "lab2"}}, "res1", res); !ok {
+		t.Error("Expected resource labelled site=lab2 by its manager to be allowed")
+	}
+	if _, ok := placementScore(common.Placement{Require: map[string]string{"site": "lab1"}}, "res1", res); ok {
+		t.Error("Expected resource not to match a label its manager replaced")
+	}
+
+	if _, ok := placementScore(common.Placement{Avoid: []string{"rig1"}}, "res1", res); ok {
+		t.Error("Expected resource to be avoided by name")
+	}
+	if _, ok := placementScore(common.Placement{Avoid: []string{"res1"}}, "res1", res); ok {
+		t.Error("Expected resource to be avoided by UUID")
+	}
+
+	if _, ok := placementScore(common.Placement{Pin: "res2"}, "res1", res); ok {
+		t.Error("Expected a job pinned to another resource not to be allowed")
+	}
+	if _, ok := placementScore(common.Placement{Pin: "res1"}, "res1", res); !ok {
+		t.Error("Expected a job pinned to the resource to be allowed")
+	}
+
+	score, ok := placementScore(common.Placement{Prefer: map[string]string{"gpu": "a100", "fast": ""}}, "res1", res)
+	if !ok || score != 1 {
+		t.Errorf("Expected a score of 1 but got %d (allowed %v)", score, ok)
+	}
+}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// Preemption allows the keeper to pause lower priority running jobs when a
+// higher priority job is waiting on hardware that is fully in use. Preempted jobs
+// are resumed by the keeper once their hardware is free again.
+var Preemption bool
+
+// preemptFor looks for the lowest priority running job holding the hardware the
+// created job at index jobKey needs and pauses it. The resource the hardware
+// was freed on is returned, or an empty string if nothing could be preempted.
+// The pause call itself is left to a worker (See runDispatches).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) preemptFor(jobKey int) string {
+	urgent := q.stack[jobKey]
+
+	victim := -1
+	for i := range q.stack {
+		running := q.stack[i]
+
+		// Only jobs with a lower priority running on a resource can be preempted
+		if running.Status != common.STATUS_RUNNING || running.Chunked || running.Priority >= urgent.Priority {
+			continue
+		}
+
+		res, ok := q.pool[running.ResAssigned]
+		if !ok || res.Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		// The urgent job's tool must be on the resource and it must be allowed there
+		tool, ok := res.Tools[urgent.ToolUUID]
+		if !ok {
+			continue
+		}
+		if _, ok := placementScore(urgent.Placement, running.ResAssigned, res); !ok {
+			continue
+		}
+
+		// and the running job must be using the hardware the tool needs, enough
+		// of it to make room once it is paused
+		var runningTool common.Tool
+		for _, t := range res.Tools {
+			if t.UUID == running.ToolUUID {
+				runningTool = t
+			}
+		}
+		if runningTool.Requirements != tool.Requirements || res.Hardware[tool.Requirements]+toolUnits(runningTool) < toolUnits(tool) {
+			continue
+		}
+
+		// Pick the lowest priority, with the most recently queued job losing ties
+		if victim == -1 || running.Priority <= q.stack[victim].Priority {
+			victim = i
+		}
+	}
+
+	if victim == -1 {
+		return ""
+	}
+
+	resKey := q.stack[victim].ResAssigned
+	logger := log.WithFields(log.Fields{
+		"job":               q.stack[victim].UUID,
+		"priority":          q.stack[victim].Priority,
+		"urgentjob":         urgent.UUID,
+		"urgentjobpriority": urgent.Priority,
+		"resource":          q.pool[resKey].Name,
+	})
+
+	// The pause is made by a worker before the urgent job is started on the
+	// resource (See stopTask), so the hardware can be handed over now
+	q.stack[victim].Status = common.STATUS_PAUSED
+	q.stopTask(victim, "Queue.TaskPause")
+	q.releaseHardware(victim)
+	q.recordEvent(victim, common.EVENT_PREEMPTED, resKey, "", "Paused for job "+urgent.UUID+".")
+
+	logger.Info("Job preempted by a higher priority job.")
+
+	// Call out to our registered hooks to note the preemption
+	go HookOnJobPreempt(Hooks.JobPreempt, cloneJob(q.stack[victim]), cloneJob(urgent), resKey)
+
+	return resKey
+}
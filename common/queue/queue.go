@@ -2,14 +2,13 @@ package queue
 
 import (
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"io"
 	"net"
 	"net/rpc"
-	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -31,22 +30,55 @@ var StateFileLocation string
 var Hooks HookParameters
 
 type Queue struct {
-	status   string // Empty, Running, Paused, Exhausted
-	pool     ResourcePool
-	stack    []common.Job
-	managers protectedmap.ProtectedMap
-	stats    Stats
-	jpurge   int
+	status    string // Empty, Running, Paused, Exhausted
+	pool      ResourcePool
+	stack     []common.Job
+	managers  protectedmap.ProtectedMap
+	stats     Stats
+	jpurge    int
+	nosplit   map[string]bool // Jobs that could not be split into keyspace chunks
+	pipelines map[string]Pipeline
+	schedules map[string]Schedule
+	templates map[string]Template
+	speeds    Speeds // Speeds seen on each resource (See recordSpeed)
+	runClock  map[string]time.Time // When RunTime was last updated for running jobs
+	restored  map[string]bool      // Jobs from the state file waiting on their resource to reconnect
+
+	restoredTools map[string]common.Tool // Tools from the state file by their old UUID
+	restoredUntil time.Time              // End of the grace period for restored jobs
+
+	starting     map[string]bool            // Jobs with a start or resume call waiting on a worker
+	dispatches   []taskCall                 // Calls for the workers to make once the lock is released
+	workers      map[string]*resourceWorker // Worker making the calls of each resource
+	polling      map[string]bool            // Jobs with a status call waiting on a worker
+	polled       map[string]taskResult      // Statuses polled since the keeper last ran
+	fetching     map[string]bool            // Jobs with a keyspace call waiting on a worker
+	keyspaces    map[string]int64           // Keyspaces fetched for jobs waiting to be split
+	keyspaceWait map[string]bool            // Jobs held back until their keyspace is known
+
+	snap    atomic.Value // Latest *Snapshot for readers (See publish)
+	version uint64
+
+	store Store      // Where the state is saved, nil if it is not
+	saved savedState // What was last saved to the store
 	sync.RWMutex
 	qk chan bool
 }
 
 type StateFile struct {
-	Stack []common.Job `json:"stack"`
-	Pool  ResourcePool `json:"pool"`
+	Stack     []common.Job        `json:"stack"`
+	Pool      ResourcePool        `json:"pool"`
+	Pipelines map[string]Pipeline `json:"pipelines"`
+	Schedules map[string]Schedule `json:"schedules"`
+	Templates map[string]Template `json:"templates"`
+	Speeds    Speeds              `json:"speeds"`
+	Stats     *Stats              `json:"stats,omitempty"`
 }
 
-func NewQueue(statefile string, updatetime int, timeout int, hooks HookParameters, purgetime int) Queue {
+// NewQueue builds a Queue and restores its state from the state store. An
+// error is returned if the store cannot be opened, rather than running without
+// saving anything and losing the queue on the next restart.
+func NewQueue(statefile string, updatetime int, timeout int, hooks HookParameters, purgetime int) (*Queue, error) {
 	//Setup the options
 	StateFileLocation = statefile
 	KeeperDuration = time.Duration(updatetime) * time.Second
@@ -54,82 +86,65 @@ func NewQueue(statefile string, updatetime int, timeout int, hooks HookParameter
 	Hooks = hooks
 
 	// Build the queue
-	q := Queue{
-		status:   STATUS_EMPTY,
-		pool:     NewResourcePool(),
-		stack:    []common.Job{},
-		managers: protectedmap.New(),
-		stats:    NewStats(),
-		jpurge:   purgetime,
+	q := &Queue{
+		status:       STATUS_EMPTY,
+		pool:         NewResourcePool(),
+		stack:        []common.Job{},
+		managers:     protectedmap.New(),
+		stats:        NewStats(),
+		jpurge:       purgetime,
+		nosplit:      map[string]bool{},
+		pipelines:    map[string]Pipeline{},
+		schedules:    map[string]Schedule{},
+		templates:    map[string]Template{},
+		speeds:       Speeds{},
+		runClock:     map[string]time.Time{},
+		restored:     map[string]bool{},
+		starting:     map[string]bool{},
+		keyspaces:    map[string]int64{},
+		keyspaceWait: map[string]bool{},
 	}
 
-	if _, err := os.Stat(StateFileLocation); err == nil {
-		q.parseState()
+	if StateStoreLocation != "" {
+		if err := q.openStore(); err != nil {
+			log.WithFields(log.Fields{
+				"statestore": StateStoreLocation,
+				"error":      err.Error(),
+			}).Error("Unable to open the state store.")
+			return nil, errors.New("Unable to open the state store: " + err.Error())
+		}
 	}
 
 	log.WithFields(log.Fields{
 		"statefile":  StateFileLocation,
+		"statestore": StateStoreLocation,
 		"keepertime": KeeperDuration,
 		"nettimeout": NetworkTimeout,
 	}).Debug("Setup a new queue")
 
-	return q
-}
-
-func (q *Queue) writeState() error {
-	var s StateFile
-
-	//Create a state fila in case we are rebooted
-	stateFile, err := os.Create(StateFileLocation)
-	if err != nil {
-		log.WithField("error", err.Error()).Fatal("Unable to write to state file")
-		return err
-	}
-	stateEncoder := json.NewEncoder(stateFile)
-
-	s.Stack = make([]common.Job, len(q.stack))
-	copy(s.Stack, q.stack)
-
-	s.Pool = make(map[string]Resource)
-	for k, v := range q.pool {
-		s.Pool[k] = v
-	}
-
-	stateEncoder.Encode(s)
-	stateFile.Close()
-
-	log.Debug("State file written successfully.")
+	q.publish()
 
-	return nil
+	return q, nil
 }
 
-func (q *Queue) parseState() error {
-	var s StateFile
-
-	stateFile, err := os.Open(StateFileLocation)
-	if err != nil {
-		log.WithField("error", err.Error()).Error("An error occured opening the state file.")
-		return err
-	}
-
-	stateDecoder := json.NewDecoder(stateFile)
-	err = stateDecoder.Decode(&s)
-	if err != nil {
-		log.WithField("error", err.Error()).Error("An error occured decoding the state file.")
-		return err
-	}
-	stateFile.Close()
-
+// restoreState puts the jobs, resources, pipelines, schedules, templates and
+// speeds saved before a restart back into the Queue.
+func (q *Queue) restoreState(s StateFile) {
 	for id, v := range s.Pool {
 		log.WithFields(log.Fields{
 			"name": v.Name,
 			"id":   id,
-		}).Debug("Added resource from state file.")
+		}).Debug("Added resource from saved state.")
 
 		v.Address = "(disconnected)"
 		v.Status = common.STATUS_QUIT
 
+		// Keep the tools so restored jobs can find them again when resources reconnect
+		if q.restoredTools == nil {
+			q.restoredTools = map[string]common.Tool{}
+		}
 		for tool, _ := range v.Tools {
+			q.restoredTools[tool] = v.Tools[tool]
 			delete(v.Tools, tool)
 		}
 
@@ -140,19 +155,49 @@ func (q *Queue) parseState() error {
 			continue
 		}
 
-		if s.Stack[i].Status == common.STATUS_CREATED || s.Stack[i].Status == common.STATUS_PAUSED || s.Stack[i].Status == common.STATUS_RUNNING {
-			s.Stack[i].Status = common.STATUS_QUIT
-			s.Stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
-		}
+		// Unfinished jobs are picked back up once resources reconnect
+		q.restoreJob(s.Stack[i])
 
 		q.stack = append(q.stack, s.Stack[i])
 		log.WithFields(log.Fields{
 			"name": s.Stack[i].Name,
 			"id":   s.Stack[i].UUID,
-		}).Debug("Added job from state file.")
+		}).Debug("Added job from saved state.")
 	}
 
-	return nil
+	if len(s.Stack) > 0 {
+		q.restoredUntil = time.Now().Add(ReconnectGrace)
+	}
+
+	for id, p := range s.Pipelines {
+		q.pipelines[id] = p
+	}
+
+	for id, sched := range s.Schedules {
+		// Schedules saved before they kept the name of their tool can find it
+		// from the old UUID while the resources are still known by it
+		if tool, ok := q.restoredTools[sched.ToolUUID]; ok && sched.ToolName == "" {
+			sched.bindTool(tool)
+		}
+		q.schedules[id] = sched
+	}
+
+	for id, t := range s.Templates {
+		q.templates[id] = t
+	}
+
+	for id, speeds := range s.Speeds {
+		// Speeds saved before they were kept by resource name are moved over
+		// from the UUID of the resource they were recorded on
+		if res, ok := q.pool[id]; ok && res.Name != "" {
+			id = res.Name
+		}
+		q.speeds[id] = speeds
+	}
+
+	if s.Stats != nil {
+		q.stats = restoreStats(*s.Stats)
+	}
 }
 
 // Add a job to the queue at the end of the stack
@@ -168,88 +213,51 @@ func (q *Queue) AddJob(j common.Job) error {
 
 	logger.Debug("Queue locked.")
 
+	// Refuse jobs whose parameters do not match their tool's schema
+	if err := q.validateJob(j); err != nil {
+		return err
+	}
+
+	// Refuse jobs past the owner's quota
+	if err := q.checkQueuedQuota(j); err != nil {
+		return err
+	}
+
+	// Jobs waiting on other jobs are pending until the keeper releases them
+	if err := q.checkJobDependencies(&j); err != nil {
+		return err
+	}
+	if len(j.Dependencies) > 0 {
+		j.Status = common.STATUS_PENDING
+	}
+	addEvent(&j, common.EVENT_CREATED, "", j.Owner, "")
+
 	// Add job to stack
 	q.stack = append(q.stack, j)
 	jobIndex := len(q.stack) - 1
 	logger.Debug("job added to stack.")
 
 	// Call out to the registered hooks to inform them of job creation
-	go HookOnJobCreate(Hooks.JobCreate, j)
+	go HookOnJobCreate(Hooks.JobCreate, cloneJob(j))
 
 	// Add stats
 	// TODO: Add more stats
-	q.stats.IncJob()
-
-	// Check if the Queue was empty
-	if q.status == STATUS_EMPTY {
-		logger.Debug("Queue is empty, job needs starting.")
-		// The Queue is empty so we need to start this job and the keeper
-
-		// Start the keeper
-		logger.Debug("Keeper started")
-		q.qk = make(chan bool)
-		go q.keeper()
-
-		// We have started the keeper so change the status
-		q.status = STATUS_RUNNING
-
-		// Find the first open resource
-		for i, _ := range q.pool {
-			logger.WithField("resource", q.pool[i].Name).Debug("Looking for resource.")
-
-			// Make sure this resource isn't paused
-			if q.pool[i].Status == common.STATUS_PAUSED || q.pool[i].Status == common.STATUS_QUIT {
-				continue
-			}
-
-			// See if the tool exist on this resource
-			tool, ok := q.pool[i].Tools[j.ToolUUID]
-			if ok {
-				logger.WithFields(log.Fields{
-					"resource": q.pool[i].Name,
-					"tool":     tool.Name,
-				}).Debug("Tool exists on resource.")
-
-				// It is now possible that the job about to be assigned to the resource
-				// actually has a Tool UUID different than the one the Queue has for this
-				// tool. This is because the Queue changes the UUID if it has two identical
-				// tools. We need to set the Job's ToolUUID to the real UUID of the tool
-				// if this is the case
-				if tool.UUID != j.ToolUUID {
-					log.WithField("toolid", tool.UUID).Debug("Changed tool UUID to match resource.")
-					j.ToolUUID = tool.UUID
-				}
-
-				// Tool exist, lets start the job on this resource and assign the resource to the job
-				j.ResAssigned = i
-				addJob := common.RPCCall{Job: j}
-
-				logger.Debug("Queue.AddTask RPC call started.")
-				err := q.pool[i].Client.Call("Queue.AddTask", addJob, &j)
-				if err != nil {
-					logger.WithField("error", err.Error()).Error("There was a problem making an RPC call.")
-					q.DeleteJobFromStackByIndex(jobIndex)
-					return err
-				}
-
-				// Update the job in the stack
-				q.stack[jobIndex] = j
-
-				// Note the resources as being used
-				q.pool[i].Hardware[tool.Requirements] = false
-
-				// Call out to our registered hooks to note job start
-				go HookOnJobStart(Hooks.JobStart, j)
-
-				// We should be done so return no errors
-				return nil
-			}
+	if j.Created.IsZero() {
+		j.Created = time.Now()
+		q.stack[jobIndex].Created = j.Created
+	}
+	tool, _ := q.findTool(j.ToolUUID)
+	q.stats.jobCreated(j, tool.Name, time.Now())
 
-			// Tool did not exist... return error
-			return errors.New("Tool did not exist for jobs provided.")
-		}
+	// Jobs held back by their owner's quota are left for the keeper
+	if j.Status == common.STATUS_CREATED && q.holdForQuota(jobIndex, time.Now()) {
+		logger.WithField("reason", q.stack[jobIndex].Waiting).Info("Job held by quota.")
 	}
 
+	// Start the keeper if the Queue was empty. The keeper starts the job on a
+	// resource (See startJobs) so no call is made while the lock is held.
+	q.startKeeper()
+
 	// If the queue is running or paused all we need to have done is add it to the queue
 	return nil
 }
@@ -261,19 +269,16 @@ func (q *Queue) DeleteJobFromStackByIndex(idx int) {
 	q.stack = append(tmp[:idx], tmp[idx+1:]...)
 }
 
-// Get the full queue stack
+// Get the full queue stack as of the latest snapshot
 func (q *Queue) AllJobs() []common.Job {
 	log.Debug("Gathering all jobs from queue.")
 
-	q.Lock()
-
-	q.Unlock()
-	return q.stack
+	return append([]common.Job(nil), q.Snapshot().Jobs...)
 }
 
 // Get a list of all jobs assigned to a resource
 func (q *Queue) AllJobsByResource(resourceid string) []common.Job {
-	jobs := q.AllJobs()
+	jobs := q.Snapshot().Jobs
 	outJobs := make([]common.Job, 0)
 
 	for _, job := range jobs {
@@ -288,20 +293,41 @@ func (q *Queue) AllJobsByResource(resourceid string) []common.Job {
 // Get one specific job
 func (q *Queue) JobInfo(jobUUID string) common.Job {
 	log.WithField("job", jobUUID).Debug("Gathering information on job.")
+
+	return q.Snapshot().Job(jobUUID)
+}
+
+// SetJobPriority changes the priority of a job and any chunks it was split into
+func (q *Queue) SetJobPriority(jobuuid string, priority int) error {
+	log.WithFields(log.Fields{
+		"job":      jobuuid,
+		"priority": priority,
+	}).Info("Attempting to set job priority.")
+
 	q.Lock()
 	defer q.Unlock()
 
-	for _, job := range q.stack {
-		if job.UUID == jobUUID {
-			return job
+	var found bool
+	for i := range q.stack {
+		if q.stack[i].UUID == jobuuid || q.stack[i].ParentUUID == jobuuid {
+			q.stack[i].Priority = priority
+			found = true
 		}
 	}
 
-	return common.Job{}
+	if !found {
+		return errors.New("Job does not exist!")
+	}
+
+	return nil
 }
 
-func (q *Queue) PauseJob(jobuuid string) error {
-	log.WithField("job", jobuuid).Info("Attempting to pause job.")
+// PauseJob pauses a running job for the user given
+func (q *Queue) PauseJob(jobuuid, user string) error {
+	log.WithFields(log.Fields{
+		"job":  jobuuid,
+		"user": user,
+	}).Info("Attempting to pause job.")
 	q.Lock()
 	defer q.Unlock()
 
@@ -313,32 +339,25 @@ func (q *Queue) PauseJob(jobuuid string) error {
 				"status": q.stack[i].Status,
 			}).Debug("Job found in queue.")
 
+			// Jobs split into chunks are paused through their chunks. Chunks that
+			// fail to pause are noted on this job (See applyStop).
+			if q.stack[i].Chunked && q.stack[i].Status == common.STATUS_RUNNING {
+				q.pauseChunks(jobuuid)
+				q.stack[i].Status = common.STATUS_PAUSED
+				q.recordEvent(i, common.EVENT_PAUSED, "", user, "")
+				return nil
+			}
+
 			// We have found the job so lets see if it running
 			if q.stack[i].Status == common.STATUS_RUNNING {
-				// Job is running so lets tell it to pause
-				pauseJob := common.RPCCall{Job: q.stack[i]}
-
-				err := q.pool[q.stack[i].ResAssigned].Client.Call("Queue.TaskPause", pauseJob, &q.stack[i])
-				log.WithField("job", jobuuid).Debug("Calling Queue.TaskPause on remote resource.")
-				if err != nil {
-					log.WithFields(log.Fields{
-						"job":   jobuuid,
-						"error": err.Error(),
-					}).Error("An error occurred while trying to pause a remote job.")
-					return err
-				}
-
-				// Task is now paused so update the resource
-				// Find the real ToolUUID since the Job's might have changed (See AddJob)
-				var tUUID, hw string
-				for qUUID, tool := range q.pool[q.stack[i].ResAssigned].Tools {
-					if q.stack[i].ToolUUID == tool.UUID {
-						// We found the UUID of the tool is so store it
-						tUUID = qUUID
-					}
-				}
-				hw = q.pool[q.stack[i].ResAssigned].Tools[tUUID].Requirements
-				q.pool[q.stack[i].ResAssigned].Hardware[hw] = true
+				// Job is running so mark it paused and free up the resource. The
+				// pause call is made by a worker and a failure is recorded on the
+				// job (See applyStop).
+				log.WithField("job", jobuuid).Debug("Queueing Queue.TaskPause on remote resource.")
+				q.stack[i].Status = common.STATUS_PAUSED
+				q.stopTask(i, "Queue.TaskPause")
+				q.releaseHardware(i)
+				q.recordEvent(i, common.EVENT_PAUSED, q.stack[i].ResAssigned, user, "")
 
 				return nil
 			} else {
@@ -352,8 +371,12 @@ func (q *Queue) PauseJob(jobuuid string) error {
 	return errors.New("Job does not exist!")
 }
 
-func (q *Queue) QuitJob(jobuuid string) error {
-	log.WithField("job", jobuuid).Info("Attempting to pause job.")
+// QuitJob stops a job for the user given
+func (q *Queue) QuitJob(jobuuid, user string) error {
+	log.WithFields(log.Fields{
+		"job":  jobuuid,
+		"user": user,
+	}).Info("Attempting to quit job.")
 
 	q.Lock()
 	defer q.Unlock()
@@ -369,20 +392,39 @@ func (q *Queue) QuitJob(jobuuid string) error {
 			// We have found the job so lets check that it isn't already done
 			s := q.stack[i].Status
 
-			if s != common.STATUS_DONE && s != common.STATUS_FAILED && s != common.STATUS_QUIT && s != common.STATUS_CREATED {
-				// Lets build the call to stop the job
-				quitJob := common.RPCCall{Job: q.stack[i]}
+			// Jobs split into chunks are quit through their chunks
+			if q.stack[i].Chunked && !common.IsDone(s) {
+				q.quitChunks(jobuuid)
 
-				err := q.pool[q.stack[i].ResAssigned].Client.Call("Queue.TaskQuit", quitJob, &q.stack[i])
-				log.WithField("job", jobuuid).Debug("Attempting to call Queue.TaskQuit on remote resource.")
-				if err != nil {
-					log.WithFields(log.Fields{
-						"job":   jobuuid,
-						"error": err.Error(),
-					}).Error("An error occurred while trying to quit a remote job.")
-					return err
+				q.stack[i].Status = common.STATUS_QUIT
+				q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+				q.recordEvent(i, common.EVENT_QUIT, "", user, "")
+
+				return nil
+			}
+
+			// Jobs restored from the state file are not on a connected resource yet
+			if q.restored[jobuuid] {
+				delete(q.restored, jobuuid)
+				q.stack[i].Status = common.STATUS_QUIT
+				q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+				q.recordEvent(i, common.EVENT_QUIT, q.stack[i].ResAssigned, user, "")
+
+				return nil
+			}
+
+			if s != common.STATUS_DONE && s != common.STATUS_FAILED && s != common.STATUS_QUIT && s != common.STATUS_CREATED && s != common.STATUS_PENDING {
+				// Paused jobs have already given their hardware back
+				if s == common.STATUS_RUNNING {
+					q.releaseHardware(i)
 				}
-				
+
+				// Mark the job quit and leave the call to the resource to a worker
+				log.WithField("job", jobuuid).Debug("Queueing Queue.TaskQuit on remote resource.")
+				q.stack[i].Status = common.STATUS_QUIT
+				q.stopTask(i, "Queue.TaskQuit")
+				q.recordEvent(i, common.EVENT_QUIT, q.stack[i].ResAssigned, user, "")
+
 				// Set a purge time
 				q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
 				// Log purge time
@@ -391,24 +433,13 @@ func (q *Queue) QuitJob(jobuuid string) error {
 					"PurgeTime": q.stack[i].PurgeTime,
 				}).Debug("Updated PurgeTime value")
 
-				// Task has been quit without errors so update the available hardware and return
-				// Find the real ToolUUID since the Job's might have changed (See AddJob)
-				var tUUID, hw string
-				for qUUID, tool := range q.pool[q.stack[i].ResAssigned].Tools {
-					if q.stack[i].ToolUUID == tool.UUID {
-						// We found the UUID of the tool is so store it
-						tUUID = qUUID
-					}
-				}
-				hw = q.pool[q.stack[i].ResAssigned].Tools[tUUID].Requirements
-				q.pool[q.stack[i].ResAssigned].Hardware[hw] = true
-
 				return nil
 			}
 
-			if s == common.STATUS_CREATED {
+			if s == common.STATUS_CREATED || s == common.STATUS_PENDING {
 				// We need to set the new status for the job to quit
 				q.stack[i].Status = common.STATUS_QUIT
+				q.recordEvent(i, common.EVENT_QUIT, "", user, "")
 				return nil
 			}
 
@@ -421,7 +452,8 @@ func (q *Queue) QuitJob(jobuuid string) error {
 	return errors.New("Job does not exist!")
 }
 
-func (q *Queue) RemoveJob(jobuuid string) error {
+// RemoveJob removes a job for the user given, quitting it first if it is running
+func (q *Queue) RemoveJob(jobuuid, user string) error {
 	log.WithField("job", jobuuid).Debug("Attempting to remove job")
 	q.Lock()
 
@@ -438,7 +470,7 @@ func (q *Queue) RemoveJob(jobuuid string) error {
 			if s == common.STATUS_RUNNING {
 				// Quit the job
 				q.Unlock()
-				err := q.QuitJob(jobuuid)
+				err := q.QuitJob(jobuuid, user)
 				q.Lock()
 				if err != nil {
 					q.Unlock()
@@ -446,10 +478,10 @@ func (q *Queue) RemoveJob(jobuuid string) error {
 				}
 			}
 
-			// Job should now be quit so lets rebuild the stack
+			// Job should now be quit so lets rebuild the stack without it or its chunks
 			newStack := []common.Job{}
 			for _, v := range q.stack {
-				if v.UUID != jobuuid {
+				if v.UUID != jobuuid && v.ParentUUID != jobuuid {
 					newStack = append(newStack, v)
 				}
 			}
@@ -487,25 +519,12 @@ func (q *Queue) PauseResource(resUUID string) error {
 		}).Debug("Identifying running jobs on paused resource.")
 
 		if q.stack[i].ResAssigned == resUUID && q.stack[i].Status == common.STATUS_RUNNING {
-			// We found a task that is running so lets pause it
-			pauseJob := common.RPCCall{Job: q.stack[i]}
-
-			err := q.pool[resUUID].Client.Call("Queue.TaskPause", pauseJob, &q.stack[i])
-			if err != nil {
-				return err
-			}
-
-			// Task should now be paused to free up the resource
-			// Find the real ToolUUID since the Job's might have changed (See AddJob)
-			var tUUID, hw string
-			for qUUID, tool := range q.pool[q.stack[i].ResAssigned].Tools {
-				if q.stack[i].ToolUUID == tool.UUID {
-					// We found the UUID of the tool is so store it
-					tUUID = qUUID
-				}
-			}
-			hw = q.pool[q.stack[i].ResAssigned].Tools[tUUID].Requirements
-			q.pool[q.stack[i].ResAssigned].Hardware[hw] = true
+			// We found a task that is running so mark it paused and free up the
+			// resource. The pause call is made by a worker (See stopTask).
+			q.stack[i].Status = common.STATUS_PAUSED
+			q.stopTask(i, "Queue.TaskPause")
+			q.releaseHardware(i)
+			q.recordEvent(i, common.EVENT_PAUSED, resUUID, "", "Resource paused.")
 		}
 	}
 
@@ -541,23 +560,23 @@ func (q *Queue) ResumeResource(resUUID string) error {
 	return nil
 }
 
-// Pause the whole queue and return any and all errors pausing active jobs/tasks
-func (q *Queue) PauseQueue() []error {
+// Pause the whole queue. The pause calls are made by the resource workers and
+// any that fail are recorded on their jobs (See applyStop).
+func (q *Queue) PauseQueue() {
 	log.Debug("Attempting to pause entire queue.")
 
-	var e []error
-
 	// Let's run the keep functions on all of our resource managers
 	q.KeepAllResourceManagers()
 
 	// First order of business is to kill the keeper
 	q.qk <- true
+
 	q.Lock()
 	defer q.Unlock()
 	q.qk = nil
 
-	// Now we need to be 100% up-to-date
-	q.updateQueue()
+	// Bring the stack up-to-date with what has been polled so far
+	q.updateQueue(q.takePolled())
 
 	log.Debug("Queue update completed.")
 
@@ -570,6 +589,11 @@ func (q *Queue) PauseQueue() []error {
 		})
 		joblog.Debug("Processing job.")
 
+		// Jobs split into chunks have no resource, their chunks are paused below
+		if q.stack[i].Chunked {
+			continue
+		}
+
 		if q.stack[i].Status == common.STATUS_RUNNING {
 			joblog.Debug("Found running job, attempting to stop")
 
@@ -577,39 +601,17 @@ func (q *Queue) PauseQueue() []error {
 			resuuid := q.stack[i].ResAssigned
 
 			// This task is running and needs to be paused
-			pauseJob := common.RPCCall{Job: q.stack[i]}
-
-			joblog.Debug("Calling Queue.TaskPause on job")
-			err := q.pool[resuuid].Client.Call("Queue.TaskPause", pauseJob, &q.stack[i])
-			if err != nil {
-				// Note the error but now mark the job as Failed
-				// This is a definied way of dealing with this to avoid complicated error handling
-				q.stack[i].Status = common.STATUS_FAILED
-				q.stack[i].Error = err.Error()
-				e = append(e, err)
-
-				joblog.Debug("There was a problem pausing the remote job.")
-			}
-
-			// Update available hardware
-			// Find the real ToolUUID since the Job's might have changed (See AddJob)
-			var tUUID, hw string
-			for qUUID, tool := range q.pool[q.stack[i].ResAssigned].Tools {
-				if q.stack[i].ToolUUID == tool.UUID {
-					// We found the UUID of the tool is so store it
-					tUUID = qUUID
-				}
-			}
-			hw = q.pool[q.stack[i].ResAssigned].Tools[tUUID].Requirements
-			q.pool[q.stack[i].ResAssigned].Hardware[hw] = true
+			joblog.Debug("Queueing Queue.TaskPause on job")
+			q.stack[i].Status = common.STATUS_PAUSED
+			q.stopTask(i, "Queue.TaskPause")
+			q.releaseHardware(i)
+			q.recordEvent(i, common.EVENT_PAUSED, resuuid, "", "Queue paused.")
 		}
 	}
 
 	// All jobs/tasks should now be paused so lets set the Queue Status
 	q.status = STATUS_PAUSED
 	log.Debug("Queue paused.")
-
-	return e
 }
 
 func (q *Queue) ResumeQueue() {
@@ -628,13 +630,19 @@ func (q *Queue) ResumeQueue() {
 	return
 }
 
-// Given a new slice of the UUIDs for all jobs in order, reorder the stack
+// Given a new slice of the UUIDs for all jobs in order, reorder the stack.
+// Keyspace chunks are not included and always follow the job they belong to.
 func (q *Queue) StackReorder(uuids []string) error {
 	q.Lock()
 
 	// Check all the UUIDs before we do anything
-	l := len(uuids)
-	if l != len(q.stack) {
+	var l int
+	for i := range q.stack {
+		if q.stack[i].ParentUUID == "" {
+			l++
+		}
+	}
+	if l != len(uuids) {
 		q.Unlock()
 		return errors.New("The wrong number of UUIDs were provided.")
 	}
@@ -648,6 +656,9 @@ func (q *Queue) StackReorder(uuids []string) error {
 	// Loop through the stack and check for bad UUIDs
 	for i, _ := range q.stack {
 		j := q.stack[i]
+		if j.ParentUUID != "" {
+			continue
+		}
 		log.WithField("uuid", j.UUID).Debug("Checking UUID on reorder stack.")
 		if _, ok := uuidCheck[j.UUID]; !ok {
 			q.Unlock()
@@ -655,9 +666,9 @@ func (q *Queue) StackReorder(uuids []string) error {
 		}
 	}
 
-	// UUIDs are good so pause the queue (we will return the errors at the end)
+	// UUIDs are good so pause the queue
 	q.Unlock()
-	err := q.PauseQueue()
+	q.PauseQueue()
 	q.Lock()
 
 	// Get Job information to build new stack
@@ -669,9 +680,16 @@ func (q *Queue) StackReorder(uuids []string) error {
 	newStack := []common.Job{}
 	for _, v := range uuids {
 		newStack = append(newStack, uuidCheck[v])
+
+		// Keep any chunks right after their parent
+		for i := range q.stack {
+			if q.stack[i].ParentUUID == v {
+				newStack = append(newStack, q.stack[i])
+			}
+		}
 	}
 
-	// If no errors were given we now have a new stack so lets assign it and finally unlock the Queue
+	// We now have a new stack so lets assign it and finally unlock the Queue
 	log.Debug("Assigning new stack to queue stack")
 	q.stack = newStack
 
@@ -679,12 +697,7 @@ func (q *Queue) StackReorder(uuids []string) error {
 	q.Unlock()
 	q.ResumeQueue()
 
-	// Return the errors from the QueuePause if there were any
-	if err != nil {
-		return errors.New("There was an error pausing jobs.")
-	}
-
-	go HookOnQueueReorder(Hooks.QueueReorder, q.stack)
+	go HookOnQueueReorder(Hooks.QueueReorder, cloneJobs(q.stack))
 
 	return nil
 }
@@ -701,12 +714,11 @@ func (q *Queue) Quit() []common.Job {
 	}
 
 	q.Lock()
-	defer q.Unlock()
 
 	q.qk = nil
 
-	// Now we need to be 100% up-to-date
-	q.updateQueue()
+	// Bring the stack up-to-date with what has been polled so far
+	q.updateQueue(q.takePolled())
 
 	// Loop through and quit any job that is not done, failed, quit
 	log.Debug("Looping through stack")
@@ -720,24 +732,39 @@ func (q *Queue) Quit() []common.Job {
 
 		s := q.stack[i].Status
 
+		// Jobs split into chunks have no resource so just mark them as quit
+		if q.stack[i].Chunked {
+			if !common.IsDone(s) {
+				q.stack[i].Status = common.STATUS_QUIT
+			}
+			continue
+		}
+
 		// If the job is running quit it
 		if s == common.STATUS_RUNNING || s == common.STATUS_PAUSED {
-			// Build the quit call
-			quitJob := common.RPCCall{Job: q.stack[i]}
-
+			// Quit the task on the resource, errors are only logged (See applyStop)
 			joblog.Debug("Quiting tasks")
-			err := q.pool[q.stack[i].ResAssigned].Client.Call("Queue.TaskQuit", quitJob, &q.stack[i])
-			// Log any errors but we don't care from a flow perspective
-			if err != nil {
-				log.Error(err.Error())
-			}
+			q.stack[i].Status = common.STATUS_QUIT
+			q.stopTask(i, "Queue.TaskQuit")
 		}
 	}
 
-	// Get rid of all the resource
+	// Stop the workers, which close the connections once the quit calls are made
+	var stopped []chan bool
 	for i, _ := range q.pool {
 		log.WithField("resource", q.pool[i].Name).Info("Stopping resource.")
-		q.pool[i].Client.Close()
+		stopped = append(stopped, q.stopWorker(i))
+	}
+	q.Unlock()
+
+	for _, done := range stopped {
+		<-done
+	}
+
+	// Get rid of all the resource
+	q.Lock()
+	defer q.Unlock()
+	for i, _ := range q.pool {
 		delete(q.pool, i)
 	}
 
@@ -760,6 +787,7 @@ func (q *Queue) keeper() {
 			select {
 			case <-kTimer:
 				log.Info("Updating queue status and keeping jobs.")
+				start := time.Now()
 
 				// Run all resource manager keep routines
 				q.KeepAllResourceManagers()
@@ -767,8 +795,11 @@ func (q *Queue) keeper() {
 				// Get lock
 				q.Lock()
 
-				// Update all running jobs
-				q.updateQueue()
+				// Update all running jobs from the statuses polled since the last run
+				q.updateQueue(q.takePolled())
+
+				// Give up on restored jobs whose resources have not come back
+				q.checkRestored()
 
 				// Quit jobs without a tool in the current resource list
 				for j := range q.stack {
@@ -783,6 +814,11 @@ func (q *Queue) keeper() {
 						continue
 					}
 
+					// Resources may still be reconnecting after a restart
+					if !q.restoredUntil.IsZero() {
+						continue
+					}
+
 					for r := range q.pool {
 						// Log looking at resource for tools
 						log.WithField("resource", q.pool[r].Name).Debug("Checking resource for job tool UUID.")
@@ -812,108 +848,36 @@ func (q *Queue) keeper() {
 					}
 				}
 
-				//Write our state file
-				if StateFileLocation != "" {
-					q.writeState()
-				}
+				// Add any jobs that are due from schedules
+				q.runSchedules()
 
-				// Look for open resources
-				// ResourceLoop:
-				for resKey, _ := range q.pool {
-					// Check that the resource is running
-					if q.pool[resKey].Status == common.STATUS_RUNNING {
-						// Loop through hardware the resouce offers (CPU, GPU, etc.)
-					HardwareLoop:
-						for hardwareKey, hardwareFree := range q.pool[resKey].Hardware {
-							// If the hardware is free
-							if hardwareFree {
-								log.WithFields(log.Fields{
-									"resource": q.pool[resKey].Name,
-									"hardware": hardwareKey,
-								}).Debug("Found empty resource hardware")
-
-								// This resource is free, so lets find a job for it
-							JobLoop:
-								for jobKey, _ := range q.stack {
-									logger := log.WithFields(log.Fields{
-										"resource": q.pool[resKey].Name,
-										"job":      q.stack[jobKey].UUID,
-									})
-
-									// Are we looking to start or resume the job?
-									switch q.stack[jobKey].Status {
-									case common.STATUS_CREATED: // We are going to start the job fresh
-										// We first need to check if this tool exists on this resource
-										if tool, ok := q.pool[resKey].Tools[q.stack[jobKey].ToolUUID]; ok {
-											// We now need to get the hardware requirements for this tool
-											if q.pool[resKey].Tools[q.stack[jobKey].ToolUUID].Requirements == hardwareKey {
-												// We now know we have an open resource and a job that needs that resource
-												logger.Debug("Attempting to start new job on resource")
-
-												// It is now possible that the Tool UUID on the resource does not match
-												// the Tool UUID the job and Queue have. This is because the Queue changes
-												// all UUIDs (keys in the pool map) to make identical Tools across multiple
-												// resources show up as one Tool available to the system. We now need to set
-												// the Job's ToolUUID field to the correct UUID for the resource if this has
-												// happened.
-												if tool.UUID != q.stack[jobKey].ToolUUID {
-													q.stack[jobKey].ToolUUID = tool.UUID
-												}
-
-												logger.Debug("Calling Queue.AddTask to start the job.")
-												err := q.pool[resKey].Client.Call("Queue.AddTask", common.RPCCall{Job: q.stack[jobKey]}, &q.stack[jobKey])
-												if err != nil {
-													// Something failed so let's mark the job as failed
-													logger.WithField("error", err.Error()).Error("Error while attempting to start job on remote resource.")
-													q.stack[jobKey].Status = common.STATUS_FAILED
-													continue JobLoop
-												}
-
-												// Job has been started so mark the hardware as in use and assign the resource ID
-												q.stack[jobKey].ResAssigned = resKey
-												q.pool[resKey].Hardware[hardwareKey] = false
-
-												// Call out to our registered hooks to note job has started
-												go HookOnJobStart(Hooks.JobStart, q.stack[jobKey])
-
-												break HardwareLoop
-											}
-										}
-									case common.STATUS_PAUSED: // We are going to resume the job were it is
-										// We are resuming a job so we first need to check if the job was assigned to this resource
-										if q.stack[jobKey].ResAssigned == resKey {
-											// This job was assigned to this resource so we need to find the correct local UUID of the tool
-											for _, resTool := range q.pool[resKey].Tools {
-												if resTool.UUID == q.stack[jobKey].ToolUUID {
-													// We have found the correct UUID, so check if this is the available hardware
-													if resTool.Requirements == hardwareKey {
-														// The job requires the hardware that is available on this resource to resume
-														logger.Debug("Attempting to resume job.")
-
-														err := q.pool[resKey].Client.Call("Queue.TaskRun", common.RPCCall{Job: q.stack[jobKey]}, &q.stack[jobKey])
-														if err != nil {
-															// Something failed so let's mark the job as failed
-															logger.WithField("error", err.Error()).Error("Error while attempting to resume job on remote resource.")
-															q.stack[jobKey].Status = common.STATUS_FAILED
-															continue JobLoop
-														}
-
-														// Job has been started so mark the hardware as in use
-														q.pool[resKey].Hardware[hardwareKey] = false
-														break HardwareLoop
-													}
-												}
-											}
-										}
-									}
-								}
-							}
-						}
-					}
-				}
+				// Release jobs whose dependencies have finished
+				q.checkDependencies()
+
+				// Split any new jobs that can be shared between resources
+				q.splitJobs()
+
+				// Note status changes the events of each job do not cover yet
+				q.recordStatusChanges()
+
+				// Save anything that changed to the state store
+				q.stats.sample(q.stack, q.pool, time.Now())
+				q.saveState()
+				q.expireArchive()
+
+				// Start or resume waiting jobs on free hardware
+				q.startJobs()
+
+				// Ask the resources for the status of running jobs and the keyspaces
+				// of jobs to split. The workers make these calls along with the
+				// start and resume calls from startJobs once the lock is released.
+				q.pollJobs()
+				q.fetchKeyspaces()
 
 				// Release the Lock
 				q.Unlock()
+
+				keeperDuration.Observe(time.Since(start).Seconds())
 			case <-q.qk:
 				log.Debug("Keeper has been quit.")
 				break keeperLoop
@@ -923,20 +887,175 @@ func (q *Queue) keeper() {
 	}()
 }
 
-// This is an internal function used to update the status of all Jobs.
+// startJobs offers free resource hardware to waiting jobs in the order given by
+// the Scheduler. Created jobs are started on any running resource with the tool
+// and paused jobs are resumed on the resource they were assigned.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) startJobs() {
+	now := time.Now()
+
+	for _, jobKey := range Scheduler.Order(q.stack) {
+		logger := log.WithFields(log.Fields{
+			"job":      q.stack[jobKey].UUID,
+			"priority": q.stack[jobKey].Priority,
+			"policy":   Scheduler.Name(),
+		})
+
+		// Skip jobs already being started and jobs waiting to be split
+		if q.starting[q.stack[jobKey].UUID] || q.keyspaceWait[q.stack[jobKey].UUID] {
+			continue
+		}
+
+		// Leave jobs waiting while their owner is at their quota
+		if q.holdForQuota(jobKey, now) {
+			logger.WithField("reason", q.stack[jobKey].Waiting).Debug("Job held by quota.")
+			continue
+		}
+
+		// Are we looking to start or resume the job?
+		switch q.stack[jobKey].Status {
+		case common.STATUS_CREATED: // We are going to start the job fresh
+			best, bestScore, bestSpeed := "", -1, 0.0
+			mode := q.stack[jobKey].Parameters[SpeedModeParameter]
+			for resKey := range q.pool {
+				// Check that the resource is running
+				if q.pool[resKey].Status != common.STATUS_RUNNING {
+					continue
+				}
+
+				// and the job is allowed to run on it
+				score, ok := placementScore(q.stack[jobKey].Placement, resKey, q.pool[resKey])
+				if !ok {
+					continue
+				}
+
+				// We first need to check if this tool exists on this resource
+				tool, ok := q.pool[resKey].Tools[q.stack[jobKey].ToolUUID]
+				if !ok {
+					continue
+				}
+
+				// We now need to check the hardware requirements for this tool are free
+				if !q.pool[resKey].hasHardware(tool) {
+					continue
+				}
+
+				// Retried jobs stay off resources they have failed on if they can
+				if q.retryExcluded(jobKey, resKey) {
+					continue
+				}
+
+				// We now know we have an open resource and a job that needs that
+				// resource, keep looking for one with more of the preferred labels
+				// and then for the fastest one seen running the job's mode
+				speed, _ := q.speedOf(resKey, tool.Name, mode)
+				if score > bestScore || (score == bestScore && speed.Speed > bestSpeed) {
+					best, bestScore, bestSpeed = resKey, score, speed.Speed
+				}
+			}
+
+			if best != "" {
+				q.startJob(jobKey, best)
+				continue
+			}
+
+			// If nothing was free see if a lower priority job can make room
+			if Preemption {
+				if resKey := q.preemptFor(jobKey); resKey != "" {
+					q.startJob(jobKey, resKey)
+				}
+			}
+		case common.STATUS_PAUSED: // We are going to resume the job were it is
+			// We are resuming a job so it must go back on the resource it was assigned
+			resKey := q.stack[jobKey].ResAssigned
+			res, ok := q.pool[resKey]
+			if !ok || res.Status != common.STATUS_RUNNING {
+				continue
+			}
+
+			// Find the correct local UUID of the tool and check its hardware is free
+			for _, resTool := range res.Tools {
+				if resTool.UUID != q.stack[jobKey].ToolUUID || !res.hasHardware(resTool) {
+					continue
+				}
+
+				// The job requires the hardware that is available on this resource to resume
+				logger.Debug("Attempting to resume job.")
+
+				// Mark the hardware as in use and leave the call to a worker (See runDispatches)
+				res.takeHardware(resTool)
+				q.dispatch(jobKey, resKey, "Queue.TaskRun", resTool)
+				break
+			}
+		}
+	}
+}
+
+// startJob starts the created job at index jobKey on the resource given. The
+// hardware the job's tool needs must already be free on the resource.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) startJob(jobKey int, resKey string) {
+	tool := q.pool[resKey].Tools[q.stack[jobKey].ToolUUID]
+
+	logger := log.WithFields(log.Fields{
+		"job":      q.stack[jobKey].UUID,
+		"resource": q.pool[resKey].Name,
+	})
+	logger.Debug("Attempting to start new job on resource")
+
+	// It is now possible that the Tool UUID on the resource does not match
+	// the Tool UUID the job and Queue have. This is because the Queue changes
+	// all UUIDs (keys in the pool map) to make identical Tools across multiple
+	// resources show up as one Tool available to the system. We now need to set
+	// the Job's ToolUUID field to the correct UUID for the resource if this has
+	// happened.
+	q.startAttempt(jobKey, resKey, q.stack[jobKey].ToolUUID)
+	if tool.UUID != q.stack[jobKey].ToolUUID {
+		q.stack[jobKey].ToolUUID = tool.UUID
+	}
+
+	// Mark the hardware as in use and assign the resource ID. The Queue.AddTask
+	// call itself is made by a worker once the lock is released (See runDispatches).
+	q.stack[jobKey].ResAssigned = resKey
+	q.pool[resKey].takeHardware(tool)
+
+	logger.Debug("Queueing Queue.AddTask to start the job.")
+	q.dispatch(jobKey, resKey, "Queue.AddTask", tool)
+}
+
+// This is an internal function used to update the status of all Jobs from the
+// statuses polled by the resource workers (See pollJobs).
 // A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
-func (q *Queue) updateQueue() {
+func (q *Queue) updateQueue(polled map[string]taskResult) {
 	purge := []int{}
 	// Loop through jobs and get the status of running jobs
 	for i, _ := range q.stack {
-		if q.stack[i].Status == common.STATUS_RUNNING {
-			// Build status update call
-			jobStatus := common.RPCCall{Job: q.stack[i]}
+		// Jobs split into chunks are updated from their chunks (See aggregateChunks)
+		// Restored jobs are updated once their resource reconnects (See adoptTasks)
+		if q.stack[i].Status == common.STATUS_RUNNING && !q.stack[i].Chunked && !q.restored[q.stack[i].UUID] {
+			// Get the status update from the resource. Jobs started since the poll
+			// or moved to another resource wait for the next one.
+			r, ok := polled[q.stack[i].UUID]
+			if !ok || r.ResKey != q.stack[i].ResAssigned {
+				continue
+			}
+
+			err := r.Err
+			if err == nil {
+				mergeTaskStatus(&q.stack[i], r.Task)
+			}
 
-			err := q.pool[q.stack[i].ResAssigned].Client.Call("Queue.TaskStatus", jobStatus, &q.stack[i])
 			// we care about the errors, but only from a logging perspective
 			if err != nil {
 				log.WithField("rpc error", err.Error()).Error("Error during RPC call.")
+				q.recordRepeatedError(i, "Unable to get status: "+err.Error())
+
+				// A job on a resource we can no longer reach is given to another resource
+				if err == rpc.ErrShutdown || err == io.EOF || err == io.ErrUnexpectedEOF {
+					q.releaseHardware(i)
+					q.retryJob(i, "Lost connection to resource: "+err.Error())
+					continue
+				}
 			}
 
 			// Check if this is now no longer running
@@ -944,16 +1063,17 @@ func (q *Queue) updateQueue() {
 				// Release the resources from this change
 				log.WithField("JobID", q.stack[i].UUID).Debug("Job has finished.")
 
-				// Call out to the registered hooks that the job is complete
-				go HookOnJobFinish(Hooks.JobFinish, q.stack[i])
+				q.endAttempt(i, q.stack[i].Error)
 
-				var hw string
-				for _, v := range q.pool[q.stack[i].ResAssigned].Tools {
-					if v.UUID == q.stack[i].ToolUUID {
-						hw = v.Requirements
-					}
+				// Keep how fast the resource ran the job for estimates
+				q.recordSpeed(i)
+
+				// Call out to the registered hooks that the job is complete
+				if q.stack[i].ParentUUID == "" {
+					go HookOnJobFinish(Hooks.JobFinish, cloneJob(q.stack[i]))
 				}
-				q.pool[q.stack[i].ResAssigned].Hardware[hw] = true
+
+				q.releaseHardware(i)
 
 				// Set a purge time
 				q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
@@ -965,13 +1085,44 @@ func (q *Queue) updateQueue() {
 			}
 		}
 
-		// Check and delete jobs past their purge timer
+	}
+
+	// Roll chunk updates up into the jobs that were split
+	q.aggregateChunks()
+
+	// Stop jobs that have run too long or missed their deadline
+	q.enforceLimits()
+
+	// Check and delete jobs past their purge timer. Chunks are purged with their parent.
+	purgeParents := map[string]bool{}
+	for i := range q.stack {
+		if q.stack[i].ParentUUID != "" {
+			continue
+		}
+
 		if q.stack[i].Status == common.STATUS_DONE || q.stack[i].Status == common.STATUS_FAILED || q.stack[i].Status == common.STATUS_QUIT {
 			if time.Now().After(q.stack[i].PurgeTime) {
 				purge = append(purge, i)
+				purgeParents[q.stack[i].UUID] = true
 			}
 		}
 	}
+	for i := range q.stack {
+		if purgeParents[q.stack[i].ParentUUID] {
+			purge = append(purge, i)
+		}
+	}
+
+	// Keep purged jobs in the archive, holding on to them if that fails
+	var archive []common.Job
+	for i := range q.stack {
+		if q.stack[i].ParentUUID == "" && purgeParents[q.stack[i].UUID] {
+			archive = append(archive, q.stack[i])
+		}
+	}
+	if err := q.archiveJobs(archive); err != nil {
+		purge = nil
+	}
 
 	// Do we need to purge?
 	if len(purge) > 0 {
@@ -993,6 +1144,9 @@ func (q *Queue) updateQueue() {
 			}
 		}
 		q.stack = newStack
+
+		// Pipelines go once all of their jobs are gone
+		q.purgePipelines()
 	}
 }
 
@@ -1197,6 +1351,7 @@ func (q *Queue) ConnectResource(resUUID, addr string, tlsconfig *tls.Config) err
 
 	// Build the RPC client for the resource
 	localRes.Client = rpc.NewClient(conn)
+	setClientName(localRes.Client, localRes.Name)
 	if err != nil {
 		log.WithField("addr", target).Debug("An error occured while creating new client")
 		return err
@@ -1213,9 +1368,13 @@ func (q *Queue) ConnectResource(resUUID, addr string, tlsconfig *tls.Config) err
 	// Now let's make sure the tools and hardware are loaded
 	q.LoadRemoteResourceHardware(resUUID)
 	q.LoadRemoteResourceTools(resUUID)
+	q.LoadRemoteResourceLabels(resUUID)
+
+	// Take back any jobs from before a restart that the resource is still holding
+	q.adoptTasks(resUUID)
 
 	// Call out to the registered hooks about resource creation
-	go HookOnResourceConnect(Hooks.ResourceConnect, resUUID, localRes)
+	go HookOnResourceConnect(Hooks.ResourceConnect, resUUID, q.Snapshot().Resources[resUUID])
 
 	return nil
 }
@@ -1224,7 +1383,7 @@ func (q *Queue) ConnectResource(resUUID, addr string, tlsconfig *tls.Config) err
 //will return false, otherwise it will return true.
 func (q *Queue) CheckResourceConnectionStatus(res *Resource) bool {
 	var reply int64
-	err := res.Client.Call("Queue.Ping", 12345, &reply)
+	err := callTimeout(res.Client, "Queue.Ping", 12345, &reply)
 	if err == rpc.ErrShutdown || err == io.EOF || err == io.ErrUnexpectedEOF {
 		return false
 	}
@@ -1239,7 +1398,24 @@ func (q *Queue) LoadRemoteResourceHardware(resUUID string) {
 	q.RUnlock()
 
 	// Get Hardware
-	err := localRes.Client.Call("Queue.ResourceHardware", common.RPCCall{}, &localRes.Hardware)
+	var capacity map[string]int
+	err := callTimeout(localRes.Client, "Queue.ResourceHardware", common.RPCCall{}, &capacity)
+	if err != nil && err != ErrTimeout {
+		// Resources from before hardware had units reply with the types of
+		// hardware they have, each of which is a single unit
+		var hardware map[string]bool
+		if errOld := callTimeout(localRes.Client, "Queue.ResourceHardware", common.RPCCall{}, &hardware); errOld == nil {
+			log.WithField("resource", resUUID).Warn("Resource replied with hardware types rather than units, it should be upgraded.")
+
+			err = nil
+			capacity = map[string]int{}
+			for key, ok := range hardware {
+				if ok {
+					capacity[key] = 1
+				}
+			}
+		}
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":    err.Error(),
@@ -1249,8 +1425,10 @@ func (q *Queue) LoadRemoteResourceHardware(resUUID string) {
 	}
 
 	// Set all hardware as available
-	for key, _ := range localRes.Hardware {
-		localRes.Hardware[key] = true
+	localRes.Capacity = capacity
+	localRes.Hardware = make(map[string]int, len(localRes.Capacity))
+	for key, units := range localRes.Capacity {
+		localRes.Hardware[key] = units
 	}
 
 	q.Lock()
@@ -1267,7 +1445,7 @@ func (q *Queue) LoadRemoteResourceTools(resUUID string) {
 
 	// Get Tools
 	var tools []common.Tool
-	err := localRes.Client.Call("Queue.ResourceTools", common.RPCCall{}, &tools)
+	err := callTimeout(localRes.Client, "Queue.ResourceTools", common.RPCCall{}, &tools)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":    err.Error(),
@@ -1309,6 +1487,48 @@ func (q *Queue) LoadRemoteResourceTools(resUUID string) {
 	log.WithField("resource", resUUID).Debug("Loaded tools for resource")
 }
 
+// This loads the labels a remote resource has been configured with
+func (q *Queue) LoadRemoteResourceLabels(resUUID string) {
+	q.RLock()
+	localRes := q.pool[resUUID]
+	q.RUnlock()
+
+	var labels map[string]string
+	err := callTimeout(localRes.Client, "Queue.ResourceLabels", common.RPCCall{}, &labels)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err.Error(),
+			"resource": resUUID,
+		}).Error("Unable to gather resource labels.")
+		return
+	}
+
+	q.Lock()
+	localRes = q.pool[resUUID]
+	localRes.Labels = labels
+	q.pool[resUUID] = localRes
+	q.Unlock()
+
+	log.WithField("resource", resUUID).Debug("Loaded labels for resource")
+}
+
+// SetResourceLabels sets the labels given to a resource by its resource
+// manager. These are added to the labels from the resource itself.
+func (q *Queue) SetResourceLabels(resUUID string, labels map[string]string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	res, ok := q.pool[resUUID]
+	if !ok {
+		return errors.New("Given Resource UUID does not exist.")
+	}
+
+	res.ManagerLabels = labels
+	q.pool[resUUID] = res
+
+	return nil
+}
+
 //This function will add a resource to the queue.  Returns the UUID.
 func (q *Queue) AddResource(name string) (string, error) {
 	// Check that the address is already in use
@@ -1339,10 +1559,8 @@ func (q *Queue) AddResource(name string) (string, error) {
 
 func (q *Queue) GetResource(resUUID string) (*Resource, bool) {
 	log.WithField("resourceid", resUUID).Debug("Gathering data on resource.")
-	q.Lock()
-	defer q.Unlock()
 
-	res, ok := q.pool[resUUID]
+	res, ok := q.Snapshot().Resources[resUUID]
 	if !ok {
 		return &Resource{}, false
 	}
@@ -1369,19 +1587,15 @@ func (q *Queue) RemoveResource(resUUID string) error {
 		if v.ResAssigned == resUUID {
 			// Check status
 			if v.Status == common.STATUS_RUNNING || v.Status == common.STATUS_PAUSED {
-				// Quit the task
-				quitTask := common.RPCCall{Job: v}
-
-				err := q.pool[resUUID].Client.Call("Queue.TaskQuit", quitTask, &q.stack[i])
-				if err != nil {
-					log.Println(err.Error())
-				}
+				// Quit the task. Unfinished jobs are given to the remaining
+				// resources once the call is made (See applyStop).
+				q.stopTask(i, "Queue.TaskQuit")
 			}
 		}
 	}
 
-	// Close the connection to the client
-	q.pool[resUUID].Client.Close()
+	// Close the connection to the client once the quit calls are made
+	q.stopWorker(resUUID)
 
 	// Remove information that might affect additional resource adding
 	res, _ := q.pool[resUUID]
@@ -1392,7 +1606,7 @@ func (q *Queue) RemoveResource(resUUID string) error {
 	}
 	q.pool[resUUID] = res
 	for i, _ := range q.pool[resUUID].Hardware {
-		q.pool[resUUID].Hardware[i] = false
+		q.pool[resUUID].Hardware[i] = 0
 	}
 
 	return nil
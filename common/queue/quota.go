@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// A Quota limits how much of the queue one user can take. A limit of zero is no
+// limit.
+type Quota struct {
+	MaxRunning  int     // Jobs running at once, further jobs wait in the queue
+	MaxQueued   int     // Unfinished jobs, further jobs are refused
+	MaxGPUHours float64 // GPU hours used per day, further jobs wait until the next day
+}
+
+// UserQuotas are the quotas of single users by username. They replace the quota
+// of the user's role.
+var UserQuotas = map[string]Quota{}
+
+// RoleQuotas are the quotas of every user with a role by role name
+var RoleQuotas = map[string]Quota{}
+
+// A QuotaError is returned when a job would take a user past their quota
+type QuotaError struct {
+	Owner  string
+	Reason string
+}
+
+func (e *QuotaError) Error() string {
+	return "Quota for " + e.Owner + " reached: " + e.Reason
+}
+
+// quotaFor returns the quota of the owner of a job
+func quotaFor(j common.Job) Quota {
+	if quota, ok := UserQuotas[j.Owner]; ok {
+		return quota
+	}
+
+	return RoleQuotas[j.OwnerRole]
+}
+
+// topJob returns the UUID of the job a job belongs to, which is the job it was
+// split from for chunks
+func topJob(j common.Job) string {
+	if j.ParentUUID != "" {
+		return j.ParentUUID
+	}
+
+	return j.UUID
+}
+
+// checkQueuedQuota refuses a new job if its owner already has as many
+// unfinished jobs as they are allowed.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) checkQueuedQuota(j common.Job) error {
+	quota := quotaFor(j)
+	if quota.MaxQueued <= 0 {
+		return nil
+	}
+
+	var queued int
+	for _, job := range q.stack {
+		if job.ParentUUID == "" && job.Owner == j.Owner && !common.IsDone(job.Status) {
+			queued++
+		}
+	}
+
+	if queued >= quota.MaxQueued {
+		return &QuotaError{
+			Owner:  j.Owner,
+			Reason: fmt.Sprintf("%d of %d jobs already in the queue.", queued, quota.MaxQueued),
+		}
+	}
+
+	return nil
+}
+
+// quotaHeld returns why the job at index i can not be started or resumed
+// because of its owner's quota, or an empty string if it can.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) quotaHeld(i int, now time.Time) string {
+	j := q.stack[i]
+	quota := quotaFor(j)
+
+	if quota.MaxGPUHours > 0 {
+		used := q.stats.gpuTime(j.Owner, now)
+		if used.Hours() >= quota.MaxGPUHours {
+			return fmt.Sprintf("Waiting for quota: %.1f of %.1f GPU hours used today.", used.Hours(), quota.MaxGPUHours)
+		}
+	}
+
+	if quota.MaxRunning <= 0 {
+		return ""
+	}
+
+	// Jobs already counted as running, with chunks counted through their parent
+	top := topJob(j)
+	running := map[string]bool{}
+	for _, job := range q.stack {
+		if job.Owner != j.Owner || job.Chunked {
+			continue
+		}
+		if job.Status == common.STATUS_RUNNING || q.starting[job.UUID] {
+			running[topJob(job)] = true
+		}
+	}
+
+	// Further chunks of a job that is already running are not another job
+	if running[top] {
+		return ""
+	}
+
+	if len(running) >= quota.MaxRunning {
+		return fmt.Sprintf("Waiting for quota: %d of %d jobs already running.", len(running), quota.MaxRunning)
+	}
+
+	return ""
+}
+
+// holdForQuota records why a job is being held back and reports whether it is
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) holdForQuota(i int, now time.Time) bool {
+	reason := q.quotaHeld(i, now)
+	q.stack[i].Waiting = reason
+
+	// Chunks are shown through their parent
+	if parent := q.stack[i].ParentUUID; parent != "" {
+		if p := q.jobIndex(parent); p != -1 && q.stack[p].Status == common.STATUS_CREATED {
+			q.stack[p].Waiting = reason
+		}
+	}
+
+	return reason != ""
+}
+
+// gpuUnits returns how many GPUs the job at index i is using on its resource
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) gpuUnits(i int) int {
+	res, ok := q.pool[q.stack[i].ResAssigned]
+	if !ok {
+		return 0
+	}
+
+	// Find the tool by its real UUID since the Job's might have changed (See AddJob)
+	for _, tool := range res.Tools {
+		if tool.UUID == q.stack[i].ToolUUID && tool.Requirements == common.RES_GPU {
+			return toolUnits(tool)
+		}
+	}
+
+	return 0
+}
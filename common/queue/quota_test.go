@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestQuotas(t *testing.T) {
+	defer func(users, roles map[string]Quota) {
+		UserQuotas, RoleQuotas = users, roles
+	}(UserQuotas, RoleQuotas)
+
+	RoleQuotas = map[string]Quota{"Standard User": {MaxRunning: 1, MaxQueued: 3}}
+	UserQuotas = map[string]Quota{"bob": {MaxGPUHours: 2}}
+
+	now := time.Now()
+	q := Queue{stack: []common.Job{
+		{UUID: "running", Owner: "alice", OwnerRole: "Standard User", Status: common.STATUS_RUNNING},
+		{UUID: "waiting", Owner: "alice", OwnerRole: "Standard User", Status: common.STATUS_CREATED},
+		{UUID: "done", Owner: "alice", OwnerRole: "Standard User", Status: common.STATUS_DONE},
+		{UUID: "bob", Owner: "bob", OwnerRole: "Standard User", Status: common.STATUS_CREATED},
+	}}
+
+	if err := q.checkQueuedQuota(common.Job{Owner: "alice", OwnerRole: "Standard User"}); err != nil {
+		t.Errorf("Expected a third unfinished job to be allowed but got %v", err)
+	}
+
+	q.stack = append(q.stack, common.Job{UUID: "third", Owner: "alice", OwnerRole: "Standard User", Status: common.STATUS_PAUSED})
+	if _, ok := q.checkQueuedQuota(common.Job{Owner: "alice", OwnerRole: "Standard User"}).(*QuotaError); !ok {
+		t.Error("Expected a fourth unfinished job to be refused")
+	}
+
+	// Alice already has a job running
+	if !q.holdForQuota(1, now) || q.stack[1].Waiting == "" {
+		t.Error("Expected a second running job to be held")
+	}
+
+	// Bob's own quota replaces the role's so only GPU time counts
+	if q.holdForQuota(3, now) {
+		t.Errorf("Expected bob's job to start but was held: %s", q.stack[3].Waiting)
+	}
+
+	q.stats.addGPUTime("bob", 2*time.Hour, now)
+	if !q.holdForQuota(3, now) {
+		t.Error("Expected bob's job to be held once the GPU hours are used")
+	}
+	if q.holdForQuota(3, now.Add(24*time.Hour)) {
+		t.Error("Expected GPU hours to start again the next day")
+	}
+}
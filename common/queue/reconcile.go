@@ -0,0 +1,220 @@
+package queue
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// ReconnectGrace is how long jobs restored from the state file wait for their
+// resources to reconnect before they are re-queued or quit.
+var ReconnectGrace = 10 * time.Minute
+
+// restoreJob records a job loaded from the state file. Running and paused jobs
+// were on resources the Queue is no longer connected to, so they wait until
+// their resource reconnects and tells us what became of them.
+func (q *Queue) restoreJob(j common.Job) {
+	if j.Chunked {
+		// Chunked jobs follow their chunks
+		return
+	}
+
+	if j.Status == common.STATUS_RUNNING || j.Status == common.STATUS_PAUSED {
+		q.restored[j.UUID] = true
+	}
+}
+
+// adoptTasks matches the jobs restored from the state file against the tasks a
+// newly connected resource still holds. Jobs the resource holds are adopted as
+// they are. Jobs that were on a resource of the same name but are no longer
+// held by it are re-queued if they can be, otherwise they are quit.
+// NO LOCK SHOULD BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) adoptTasks(resUUID string) {
+	q.RLock()
+	waiting := len(q.restored)
+	client := q.pool[resUUID].Client
+	q.RUnlock()
+
+	if waiting == 0 || client == nil {
+		return
+	}
+
+	// Ask the resource without the lock so a slow one does not hold up the Queue
+	var tasks []common.Job
+	err := callTimeout(client, "Queue.AllTaskStatus", common.RPCCall{}, &tasks)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"resource": resUUID,
+			"error":    err.Error(),
+		}).Error("Unable to gather the tasks held by resource.")
+		return
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	// The resource may have been removed while it was being asked
+	res, ok := q.pool[resUUID]
+	if !ok || res.Client != client || res.Status == common.STATUS_QUIT {
+		return
+	}
+
+	held := map[string]common.Job{}
+	for _, t := range tasks {
+		held[t.UUID] = t
+	}
+
+	for i := range q.stack {
+		if !q.restored[q.stack[i].UUID] {
+			continue
+		}
+
+		if task, ok := held[q.stack[i].UUID]; ok {
+			q.adoptTask(i, resUUID, task)
+			continue
+		}
+
+		if old, ok := q.pool[q.stack[i].ResAssigned]; ok && old.Name == res.Name {
+			q.abandonRestored(i, "Job was no longer on its resource after the queue restarted.")
+		}
+	}
+
+	q.remapTools()
+}
+
+// adoptTask takes back the job at index i from the task a resource still holds
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) adoptTask(i int, resUUID string, task common.Job) {
+	res := q.pool[resUUID]
+	delete(q.restored, q.stack[i].UUID)
+
+	// Find the Queue's UUID for the tool the task is using
+	var toolKey string
+	var taskTool common.Tool
+	for key, tool := range res.Tools {
+		if tool.UUID == task.ToolUUID {
+			toolKey = key
+			taskTool = tool
+		}
+	}
+
+	q.stack[i].ResAssigned = resUUID
+	q.stack[i].ToolUUID = task.ToolUUID
+	mergeTaskStatus(&q.stack[i], task)
+
+	if n := len(q.stack[i].Attempts); n > 0 {
+		q.stack[i].Attempts[n-1].ResourceUUID = resUUID
+		if toolKey != "" {
+			q.stack[i].Attempts[n-1].ToolUUID = toolKey
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"job":      q.stack[i].UUID,
+		"resource": res.Name,
+		"status":   q.stack[i].Status,
+	}).Info("Adopted job from reconnected resource.")
+
+	switch q.stack[i].Status {
+	case common.STATUS_RUNNING:
+		res.takeHardware(taskTool)
+	case common.STATUS_PAUSED:
+		// Resumed by the keeper when the hardware is free
+	default:
+		// The job finished while the Queue was down
+		q.endAttempt(i, q.stack[i].Error)
+		q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+		if q.stack[i].ParentUUID == "" {
+			go HookOnJobFinish(Hooks.JobFinish, cloneJob(q.stack[i]))
+		}
+	}
+}
+
+// abandonRestored gives up on finding the job at index i on a resource. Paused
+// jobs and chunks are started again from the beginning, running jobs are quit.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) abandonRestored(i int, reason string) {
+	delete(q.restored, q.stack[i].UUID)
+	q.endAttempt(i, reason)
+
+	log.WithFields(log.Fields{
+		"job":    q.stack[i].UUID,
+		"status": q.stack[i].Status,
+		"reason": reason,
+	}).Warn("Restored job could not be found on a resource.")
+
+	if q.stack[i].Status == common.STATUS_PAUSED || q.stack[i].ParentUUID != "" {
+		q.requeue(i)
+		return
+	}
+
+	q.stack[i].Status = common.STATUS_QUIT
+	q.stack[i].Error = reason
+	q.stack[i].PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+	go HookOnJobFinish(Hooks.JobFinish, cloneJob(q.stack[i]))
+}
+
+// checkRestored gives up on restored jobs whose resources have not reconnected
+// within the grace period.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) checkRestored() {
+	if q.restoredUntil.IsZero() {
+		return
+	}
+
+	q.remapTools()
+
+	if time.Now().Before(q.restoredUntil) {
+		return
+	}
+
+	for i := range q.stack {
+		if q.restored[q.stack[i].UUID] {
+			q.abandonRestored(i, "Job's resource did not reconnect after the queue restarted.")
+		}
+	}
+
+	q.remapTools()
+	q.restoredTools = nil
+	q.restoredUntil = time.Time{}
+}
+
+// remapTools points waiting jobs from the state file at the Queue's current
+// UUID for their tool, since the UUIDs are handed out again as resources
+// reconnect.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) remapTools() {
+	if len(q.restoredTools) == 0 {
+		return
+	}
+
+	current := map[string]bool{}
+	for _, res := range q.pool {
+		for key := range res.Tools {
+			current[key] = true
+		}
+	}
+
+	for i := range q.stack {
+		j := &q.stack[i]
+		if current[j.ToolUUID] || (j.Status != common.STATUS_CREATED && j.Status != common.STATUS_PENDING && !j.Chunked) {
+			continue
+		}
+
+		old, ok := q.restoredTools[j.ToolUUID]
+		if !ok {
+			continue
+		}
+
+	search:
+		for _, res := range q.pool {
+			for key, tool := range res.Tools {
+				if common.CompareTools(old, tool) {
+					j.ToolUUID = key
+					break search
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestAbandonRestored(t *testing.T) {
+	q := Queue{
+		stack: []common.Job{
+			{UUID: "running", Status: common.STATUS_RUNNING, ResAssigned: "old"},
+			{UUID: "paused", Status: common.STATUS_PAUSED, ResAssigned: "old"},
+		},
+		restored: map[string]bool{"running": true, "paused": true},
+	}
+
+	q.abandonRestored(0, "gone")
+	q.abandonRestored(1, "gone")
+
+	if q.stack[0].Status != common.STATUS_QUIT || q.stack[0].Error != "gone" {
+		t.Errorf("Expected the running job to be quit with an error but got %s %q", q.stack[0].Status, q.stack[0].Error)
+	}
+	if q.stack[1].Status != common.STATUS_CREATED || q.stack[1].ResAssigned != "" {
+		t.Errorf("Expected the paused job to be re-queued but got %s on %q", q.stack[1].Status, q.stack[1].ResAssigned)
+	}
+	if len(q.restored) != 0 {
+		t.Errorf("Expected no jobs left waiting on resources but had %d", len(q.restored))
+	}
+}
+
+func TestRemapTools(t *testing.T) {
+	tool := common.Tool{UUID: "resource-tool", Name: "Hashcat", Type: "Cracking", Version: "3"}
+
+	q := Queue{
+		stack: []common.Job{
+			{UUID: "waiting", Status: common.STATUS_CREATED, ToolUUID: "old-tool"},
+			{UUID: "done", Status: common.STATUS_DONE, ToolUUID: "old-tool"},
+		},
+		pool:          ResourcePool{"res": Resource{Tools: map[string]common.Tool{"new-tool": tool}}},
+		restoredTools: map[string]common.Tool{"old-tool": {UUID: "old-tool", Name: "Hashcat", Type: "Cracking", Version: "3"}},
+	}
+
+	q.remapTools()
+
+	if q.stack[0].ToolUUID != "new-tool" {
+		t.Errorf("Expected the waiting job to use the new tool UUID but had %s", q.stack[0].ToolUUID)
+	}
+	if q.stack[1].ToolUUID != "old-tool" {
+		t.Errorf("Expected the finished job to be left alone but had %s", q.stack[1].ToolUUID)
+	}
+}
+
+// lockingResource answers Queue.AllTaskStatus after taking the lock of the
+// Queue asking, which only works if the Queue does not hold it during the call
+type lockingResource struct {
+	q *Queue
+}
+
+func (r lockingResource) AllTaskStatus(rpc common.RPCCall, tasks *[]common.Job) error {
+	r.q.Lock()
+	r.q.Unlock()
+
+	*tasks = []common.Job{{UUID: "running", ToolUUID: "real1", Status: common.STATUS_RUNNING}}
+	return nil
+}
+
+func TestAdoptTasksWithoutLock(t *testing.T) {
+	timeout := NetworkTimeout
+	NetworkTimeout = time.Second
+	defer func() { NetworkTimeout = timeout }()
+
+	q := &Queue{
+		stack:    []common.Job{{UUID: "running", Status: common.STATUS_RUNNING, ResAssigned: "old"}},
+		restored: map[string]bool{"running": true},
+	}
+
+	server := rpc.NewServer()
+	server.RegisterName("Queue", lockingResource{q: q})
+	local, remote := net.Pipe()
+	go server.ServeConn(remote)
+	client := rpc.NewClient(local)
+	defer client.Close()
+
+	res := NewResource()
+	res.Name = "rig1"
+	res.Client = client
+	res.Status = common.STATUS_RUNNING
+	res.Tools["tool1"] = common.Tool{UUID: "real1"}
+	q.pool = ResourcePool{"res1": res}
+
+	q.adoptTasks("res1")
+
+	if q.stack[0].ResAssigned != "res1" || q.restored["running"] {
+		t.Errorf("Expected the job to be adopted by the resource but it is on %q", q.stack[0].ResAssigned)
+	}
+}
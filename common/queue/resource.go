@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"encoding/json"
 	"github.com/jmmcatee/cracklord/common"
 	"net/rpc"
 )
@@ -11,9 +12,13 @@ type Resource struct {
 	Client   *rpc.Client
 	Name     string
 	Address  string
-	Hardware map[string]bool
+	Hardware map[string]int `json:"-"` // Free units of each hardware type (See MarshalJSON)
+	Capacity map[string]int // Total units of each hardware type
 	Tools    map[string]common.Tool
 	Status   string // Can be running, paused, quit
+
+	Labels        map[string]string // Labels from the resource server's configuration
+	ManagerLabels map[string]string // Labels set through the resource manager, these override Labels
 }
 
 func NewResourcePool() ResourcePool {
@@ -22,7 +27,106 @@ func NewResourcePool() ResourcePool {
 
 func NewResource() Resource {
 	return Resource{
-		Hardware: make(map[string]bool),
+		Hardware: make(map[string]int),
+		Capacity: make(map[string]int),
 		Tools:    make(map[string]common.Tool),
 	}
 }
+
+// resourceFields has the fields of a Resource without its JSON methods
+type resourceFields Resource
+
+// MarshalJSON keeps Hardware in the format it has always been saved in, the
+// types of hardware the resource has, with the free units of each type saved
+// alongside as HardwareFree.
+func (r Resource) MarshalJSON() ([]byte, error) {
+	types := make(map[string]bool, len(r.Capacity))
+	for hw, units := range r.Capacity {
+		if units > 0 {
+			types[hw] = true
+		}
+	}
+
+	return json.Marshal(struct {
+		resourceFields
+		Hardware     map[string]bool
+		HardwareFree map[string]int
+	}{resourceFields(r), types, r.Hardware})
+}
+
+// UnmarshalJSON reads a resource saved by MarshalJSON. Resources saved before
+// hardware was counted in units only have Hardware, so each type they list is
+// taken as a single free unit.
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*resourceFields
+		Hardware     map[string]bool
+		HardwareFree map[string]int
+	}{resourceFields: (*resourceFields)(r)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if r.Capacity == nil {
+		r.Capacity = make(map[string]int)
+	}
+
+	r.Hardware = v.HardwareFree
+	if r.Hardware == nil {
+		r.Hardware = make(map[string]int)
+		for hw, ok := range v.Hardware {
+			if ok {
+				r.Hardware[hw] = 1
+				if r.Capacity[hw] == 0 {
+					r.Capacity[hw] = 1
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// toolUnits returns the units of hardware a job of the tool uses
+func toolUnits(t common.Tool) int {
+	if t.Units < 1 {
+		return 1
+	}
+
+	return t.Units
+}
+
+// hasHardware checks the resource has enough free hardware to run the tool
+func (r Resource) hasHardware(t common.Tool) bool {
+	return r.Hardware[t.Requirements] >= toolUnits(t)
+}
+
+// takeHardware marks the hardware a job of the tool uses as in use
+func (r Resource) takeHardware(t common.Tool) {
+	r.Hardware[t.Requirements] -= toolUnits(t)
+}
+
+// freeHardware gives back the hardware a job of the tool was using
+func (r Resource) freeHardware(t common.Tool) {
+	free := r.Hardware[t.Requirements] + toolUnits(t)
+	if c, ok := r.Capacity[t.Requirements]; ok && free > c {
+		free = c
+	}
+
+	r.Hardware[t.Requirements] = free
+}
+
+// AllLabels returns the labels of the resource from both the resource server
+// and the resource manager
+func (r Resource) AllLabels() map[string]string {
+	labels := make(map[string]string, len(r.Labels)+len(r.ManagerLabels))
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	for k, v := range r.ManagerLabels {
+		labels[k] = v
+	}
+
+	return labels
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestResourceHardwareUnits(t *testing.T) {
+	res := NewResource()
+	res.Capacity[common.RES_GPU] = 8
+	res.Hardware[common.RES_GPU] = 8
+
+	quad := common.Tool{Requirements: common.RES_GPU, Units: 4}
+	single := common.Tool{Requirements: common.RES_GPU}
+
+	// Two jobs using four GPUs each fill the resource
+	for n := 0; n < 2; n++ {
+		if !res.hasHardware(quad) {
+			t.Fatalf("Expected room for job %d using 4 of 8 GPUs", n+1)
+		}
+		res.takeHardware(quad)
+	}
+
+	if res.hasHardware(single) {
+		t.Error("Expected no room left on a full resource")
+	}
+
+	res.freeHardware(quad)
+	if res.Hardware[common.RES_GPU] != 4 {
+		t.Errorf("Expected 4 free GPUs but had %d", res.Hardware[common.RES_GPU])
+	}
+
+	// Freeing more than was taken never goes over the capacity
+	res.freeHardware(quad)
+	res.freeHardware(quad)
+	if res.Hardware[common.RES_GPU] != 8 {
+		t.Errorf("Expected free GPUs to stop at the capacity of 8 but had %d", res.Hardware[common.RES_GPU])
+	}
+}
+
+func TestResourceHardwareJSON(t *testing.T) {
+	res := NewResource()
+	res.Capacity[common.RES_GPU] = 4
+	res.Hardware[common.RES_GPU] = 3
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wire struct {
+		Hardware     map[string]bool
+		HardwareFree map[string]int
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if !wire.Hardware[common.RES_GPU] || wire.HardwareFree[common.RES_GPU] != 3 {
+		t.Errorf("Expected Hardware to list the GPUs and HardwareFree to count them but got %s", data)
+	}
+
+	var back Resource
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Hardware[common.RES_GPU] != 3 || back.Capacity[common.RES_GPU] != 4 {
+		t.Errorf("Expected 3 of 4 GPUs free after a round trip but got %v of %v", back.Hardware, back.Capacity)
+	}
+
+	// Resources saved by older versions only list their hardware
+	var old Resource
+	if err := json.Unmarshal([]byte(`{"Name":"rig1","Hardware":{"gpu":true}}`), &old); err != nil {
+		t.Fatal(err)
+	}
+	if old.Name != "rig1" || old.Hardware[common.RES_GPU] != 1 || old.Capacity[common.RES_GPU] != 1 {
+		t.Errorf("Expected a single GPU from the old format but got %v of %v", old.Hardware, old.Capacity)
+	}
+}
@@ -0,0 +1,153 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// RetryMaxAttempts is the number of times a job is started on a resource before
+// a lost resource or failed start fails the job. One disables retries. Chunks
+// of a split job use MaxChunkRetries instead.
+var RetryMaxAttempts = 3
+
+// RetryBackoff is how long a job waits before its first retry. The wait doubles
+// with each further retry.
+var RetryBackoff = 30 * time.Second
+
+// RetryExcludeFailed keeps a retried job off the resources it has already failed
+// on, as long as another resource has the tool.
+var RetryExcludeFailed = true
+
+// startAttempt records that the job at index i is being started on a resource.
+// toolUUID is the Queue's UUID for the tool, not the resource's.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) startAttempt(i int, resUUID, toolUUID string) {
+	q.stack[i].Attempts = append(q.stack[i].Attempts, common.Attempt{
+		ResourceUUID: resUUID,
+		ToolUUID:     toolUUID,
+		Started:      time.Now(),
+	})
+}
+
+// endAttempt closes the latest attempt of the job at index i
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) endAttempt(i int, reason string) {
+	n := len(q.stack[i].Attempts)
+	if n == 0 || !q.stack[i].Attempts[n-1].Ended.IsZero() {
+		return
+	}
+
+	q.stack[i].Attempts[n-1].Ended = time.Now()
+	q.stack[i].Attempts[n-1].Error = reason
+}
+
+// retryBackoff returns how long to wait before the given retry
+func retryBackoff(retry int) time.Duration {
+	if retry > 16 {
+		retry = 16
+	}
+
+	return RetryBackoff << uint(retry)
+}
+
+// retryJob puts a job whose resource was lost or whose start failed back on the
+// stack so the keeper can start it again, possibly on another resource. Once
+// the job has used up its retries it is failed instead.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) retryJob(i int, reason string) {
+	job := &q.stack[i]
+	q.endAttempt(i, reason)
+
+	maxRetries := RetryMaxAttempts - 1
+	if job.ParentUUID != "" {
+		maxRetries = MaxChunkRetries
+	}
+
+	if job.Retries >= maxRetries {
+		job.Status = common.STATUS_FAILED
+		job.Error = fmt.Sprintf("Job failed after %d attempts: %s", len(job.Attempts), reason)
+		q.recordEvent(i, common.EVENT_FINISHED, job.ResAssigned, "", job.Error)
+		if job.ParentUUID == "" {
+			job.PurgeTime = time.Now().Add(time.Duration(q.jpurge*24) * time.Hour)
+			go HookOnJobFinish(Hooks.JobFinish, cloneJob(*job))
+		}
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"job":      job.UUID,
+		"resource": job.ResAssigned,
+		"retries":  job.Retries,
+		"reason":   reason,
+	}).Info("Re-queuing job.")
+
+	resKey := job.ResAssigned
+	job.RetryAfter = time.Now().Add(retryBackoff(job.Retries))
+	job.Retries++
+	q.requeue(i)
+	q.recordEvent(i, common.EVENT_RETRIED, resKey, "", reason)
+}
+
+// requeue resets the job at index i so the keeper will start it fresh
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) requeue(i int) {
+	job := &q.stack[i]
+
+	// The job may have been given a resource specific Tool UUID, so go back to
+	// the one the Queue knows it by
+	if n := len(job.Attempts); n > 0 && job.Attempts[n-1].ToolUUID != "" {
+		job.ToolUUID = job.Attempts[n-1].ToolUUID
+	} else if job.ParentUUID != "" {
+		if p := q.jobIndex(job.ParentUUID); p != -1 {
+			job.ToolUUID = q.stack[p].ToolUUID
+		}
+	}
+
+	job.Status = common.STATUS_CREATED
+	job.Error = ""
+	job.ResAssigned = ""
+	job.Progress = 0
+	job.ETC = ""
+	job.PerformanceData = make(map[string]string)
+}
+
+// failedOn returns the resources the job has failed on
+func failedOn(j common.Job) map[string]bool {
+	failed := map[string]bool{}
+	for _, a := range j.Attempts {
+		if a.Error != "" {
+			failed[a.ResourceUUID] = true
+		}
+	}
+
+	return failed
+}
+
+// retryExcluded checks if the job at index jobKey should be kept off a resource
+// because it has failed there before and another running resource has its tool.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) retryExcluded(jobKey int, resKey string) bool {
+	if !RetryExcludeFailed || len(q.stack[jobKey].Attempts) == 0 {
+		return false
+	}
+
+	failed := failedOn(q.stack[jobKey])
+	if !failed[resKey] {
+		return false
+	}
+
+	for r, res := range q.pool {
+		if failed[r] || res.Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		if _, ok := res.Tools[q.stack[jobKey].ToolUUID]; ok {
+			return true
+		}
+	}
+
+	return false
+}
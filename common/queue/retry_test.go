@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestRetryJob(t *testing.T) {
+	q := Queue{stack: []common.Job{{UUID: "job", Status: common.STATUS_RUNNING, ToolUUID: "queue-tool"}}}
+
+	q.startAttempt(0, "res1", "queue-tool")
+	q.stack[0].ToolUUID = "res1-tool"
+	q.stack[0].ResAssigned = "res1"
+
+	q.retryJob(0, "connection lost")
+
+	j := q.stack[0]
+	if j.Status != common.STATUS_CREATED {
+		t.Fatalf("Expected job to be re-queued but status was %s", j.Status)
+	}
+	if j.ToolUUID != "queue-tool" || j.ResAssigned != "" {
+		t.Errorf("Expected job to go back to the queue tool unassigned but had tool %s on %s", j.ToolUUID, j.ResAssigned)
+	}
+	if !j.RetryAfter.After(time.Now()) {
+		t.Error("Expected job to wait before being retried")
+	}
+	if j.Attempts[0].Ended.IsZero() || j.Attempts[0].Error != "connection lost" {
+		t.Errorf("Expected the attempt to be closed with its error but got %+v", j.Attempts[0])
+	}
+
+	// Use up the remaining retries
+	for n := 1; n < RetryMaxAttempts; n++ {
+		q.startAttempt(0, "res1", "queue-tool")
+		q.retryJob(0, "connection lost")
+	}
+
+	if q.stack[0].Status != common.STATUS_FAILED {
+		t.Errorf("Expected job to fail after %d attempts but status was %s", RetryMaxAttempts, q.stack[0].Status)
+	}
+}
+
+func TestRetryExcluded(t *testing.T) {
+	tools := map[string]common.Tool{"tool": {UUID: "tool"}}
+	q := Queue{
+		stack: []common.Job{{
+			UUID:     "job",
+			ToolUUID: "tool",
+			Attempts: []common.Attempt{{ResourceUUID: "bad", Error: "failed to start"}},
+		}},
+		pool: ResourcePool{
+			"bad":  Resource{Status: common.STATUS_RUNNING, Tools: tools},
+			"good": Resource{Status: common.STATUS_RUNNING, Tools: tools},
+		},
+	}
+
+	if !q.retryExcluded(0, "bad") {
+		t.Error("Expected the failed resource to be excluded while another has the tool")
+	}
+	if q.retryExcluded(0, "good") {
+		t.Error("Expected a resource the job has not failed on to be allowed")
+	}
+
+	// With nowhere else to go the failed resource is used again
+	delete(q.pool, "good")
+	if q.retryExcluded(0, "bad") {
+		t.Error("Expected the failed resource to be allowed when it is the only one with the tool")
+	}
+}
@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"errors"
+	"net/rpc"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// ErrTimeout is returned when a resource does not answer an RPC call within the
+// NetworkTimeout
+var ErrTimeout = errors.New("Resource did not respond in time.")
+
+// ErrNotConnected is returned for RPC calls to resources without a connection
+var ErrNotConnected = errors.New("Resource is not connected.")
+
+// callTimeout makes an RPC call to a resource, giving up after the
+// NetworkTimeout so a hung resource cannot hold up the Queue. If the call times
+// out the reply may still be written to later, so it should not be shared.
+func callTimeout(client *rpc.Client, method string, args interface{}, reply interface{}) error {
+	return callWithin(client, method, args, reply, NetworkTimeout)
+}
+
+// callWithin makes an RPC call to a resource, giving up after the timeout
+// given. A timeout of zero or less waits for as long as the call takes.
+func callWithin(client *rpc.Client, method string, args interface{}, reply interface{}, timeout time.Duration) error {
+	if client == nil {
+		return ErrNotConnected
+	}
+
+	start := time.Now()
+	call := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+
+	if timeout <= 0 {
+		<-call.Done
+		observeCall(client, method, start, call.Error)
+		return call.Error
+	}
+
+	select {
+	case <-call.Done:
+		observeCall(client, method, start, call.Error)
+		return call.Error
+	case <-time.After(timeout):
+		observeCall(client, method, start, ErrTimeout)
+		return ErrTimeout
+	}
+}
+
+// mergeTaskStatus copies the fields a tool reports on from a task into a job
+func mergeTaskStatus(j *common.Job, task common.Job) {
+	j.Status = task.Status
+	j.Error = task.Error
+	j.StartTime = task.StartTime
+	j.ETC = task.ETC
+	j.CrackedHashes = task.CrackedHashes
+	j.TotalHashes = task.TotalHashes
+	j.Progress = task.Progress
+	j.PerformanceTitle = task.PerformanceTitle
+	j.OutputTitles = task.OutputTitles
+
+	if task.PerformanceData != nil {
+		j.PerformanceData = task.PerformanceData
+	}
+
+	if task.OutputData != nil {
+		j.OutputData = task.OutputData
+	}
+
+	if task.Benchmarks != nil {
+		j.Benchmarks = task.Benchmarks
+	}
+}
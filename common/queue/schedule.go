@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/pborman/uuid"
+)
+
+// A Schedule is a recurring job definition. Each time the cron expression
+// matches the keeper adds a new job to the queue built from the schedule. Like
+// templates, schedules are tied to the name and version of their tool since its
+// UUID changes every time a resource connects.
+type Schedule struct {
+	UUID        string            `json:"uuid"`
+	Name        string            `json:"name"`
+	Owner       string            `json:"owner"`
+	OwnerRole   string            `json:"ownerrole"`
+	Cron        string            `json:"cron"`
+	Enabled     bool              `json:"enabled"`
+	ToolUUID    string            `json:"tooluuid"` // UUID of the tool when it was last found
+	ToolName    string            `json:"toolname"`
+	ToolVersion string            `json:"toolversion"`
+	JobName     string            `json:"jobname"`
+	Parameters  map[string]string `json:"parameters"`
+	Created     time.Time         `json:"created"`
+	NextRun     time.Time         `json:"nextrun"`
+	LastRun     time.Time         `json:"lastrun"`
+	LastJob     string            `json:"lastjob"`
+}
+
+// bindTool ties a schedule to the name and version of a tool
+func (s *Schedule) bindTool(tool common.Tool) {
+	s.ToolName = tool.Name
+	s.ToolVersion = tool.Version
+}
+
+// scheduleTool finds a connected tool for a schedule. Schedules saved before
+// they kept the name of their tool are bound to it the first time it is found.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) scheduleTool(s *Schedule) (common.Tool, bool) {
+	if s.ToolName == "" {
+		tool, ok := q.findTool(s.ToolUUID)
+		if !ok {
+			return common.Tool{}, false
+		}
+		s.bindTool(tool)
+	}
+
+	return q.findToolByName(s.ToolName, s.ToolVersion)
+}
+
+// AddSchedule validates and stores a new recurring job definition
+func (q *Queue) AddSchedule(s Schedule) (Schedule, error) {
+	spec, err := ParseCron(s.Cron)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if s.ToolUUID == "" {
+		return Schedule{}, errors.New("A tool must be provided for a schedule.")
+	}
+
+	s.UUID = uuid.New()
+	s.Created = time.Now()
+	s.NextRun = spec.Next(s.Created)
+
+	q.Lock()
+	defer q.Unlock()
+
+	tool, ok := q.findTool(s.ToolUUID)
+	if !ok {
+		return Schedule{}, errors.New("Tool does not exist!")
+	}
+	s.bindTool(tool)
+
+	if q.schedules == nil {
+		q.schedules = map[string]Schedule{}
+	}
+	q.schedules[s.UUID] = s
+
+	// Schedules are run by the keeper so make sure it is going
+	q.startKeeper()
+
+	log.WithFields(log.Fields{
+		"schedule": s.UUID,
+		"cron":     s.Cron,
+		"nextrun":  s.NextRun,
+	}).Info("Schedule created.")
+
+	return s, nil
+}
+
+// UpdateSchedule replaces the definition of an existing schedule. The run
+// history of the schedule is kept.
+func (q *Queue) UpdateSchedule(s Schedule) (Schedule, error) {
+	spec, err := ParseCron(s.Cron)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	old, ok := q.schedules[s.UUID]
+	if !ok {
+		return Schedule{}, errors.New("Schedule does not exist!")
+	}
+
+	tool, ok := q.findTool(s.ToolUUID)
+	if !ok {
+		return Schedule{}, errors.New("Tool does not exist!")
+	}
+	s.bindTool(tool)
+
+	s.Owner = old.Owner
+	s.OwnerRole = old.OwnerRole
+	s.Created = old.Created
+	s.LastRun = old.LastRun
+	s.LastJob = old.LastJob
+	s.NextRun = spec.Next(time.Now())
+
+	q.schedules[s.UUID] = s
+
+	log.WithField("schedule", s.UUID).Info("Schedule updated.")
+
+	return s, nil
+}
+
+// RemoveSchedule deletes a schedule. Jobs it already created are left alone.
+func (q *Queue) RemoveSchedule(scheduleuuid string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if _, ok := q.schedules[scheduleuuid]; !ok {
+		return errors.New("Schedule does not exist!")
+	}
+
+	delete(q.schedules, scheduleuuid)
+
+	log.WithField("schedule", scheduleuuid).Info("Schedule removed.")
+
+	return nil
+}
+
+// AllSchedules returns every schedule in the queue
+func (q *Queue) AllSchedules() []Schedule {
+	q.RLock()
+	defer q.RUnlock()
+
+	schedules := make([]Schedule, 0, len(q.schedules))
+	for _, s := range q.schedules {
+		schedules = append(schedules, s)
+	}
+
+	return schedules
+}
+
+// ScheduleInfo returns the schedule with the UUID given
+func (q *Queue) ScheduleInfo(scheduleuuid string) (Schedule, bool) {
+	q.RLock()
+	defer q.RUnlock()
+
+	s, ok := q.schedules[scheduleuuid]
+	return s, ok
+}
+
+// StartKeeper makes sure the keeper is running if schedules or unfinished jobs
+// were restored from the state file, since the keeper is otherwise only started
+// by AddJob.
+func (q *Queue) StartKeeper() {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.schedules) > 0 {
+		q.startKeeper()
+		return
+	}
+
+	for i := range q.stack {
+		if !common.IsDone(q.stack[i].Status) {
+			q.startKeeper()
+			return
+		}
+	}
+}
+
+// startKeeper starts the keeper if the queue is empty.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) startKeeper() {
+	if q.status != STATUS_EMPTY {
+		return
+	}
+
+	log.Debug("Keeper started")
+	q.qk = make(chan bool)
+	go q.keeper()
+
+	q.status = STATUS_RUNNING
+}
+
+// runSchedules adds a job for every enabled schedule that is due. Runs missed
+// while the queue was paused or down are only run once.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) runSchedules() {
+	now := time.Now()
+
+	for id, s := range q.schedules {
+		if !s.Enabled || s.NextRun.IsZero() || now.Before(s.NextRun) {
+			continue
+		}
+
+		spec, err := ParseCron(s.Cron)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"schedule": id,
+				"error":    err.Error(),
+			}).Error("Schedule has a bad cron expression.")
+			continue
+		}
+
+		// Skip this run if the job could not be started or is not allowed
+		skip := func(reason string) {
+			log.WithFields(log.Fields{
+				"schedule": id,
+				"error":    reason,
+			}).Warn("Scheduled job skipped.")
+
+			s.NextRun = spec.Next(now)
+			q.schedules[id] = s
+		}
+
+		// The tool's UUID changes when its resource reconnects so find it again
+		tool, ok := q.scheduleTool(&s)
+		if !ok {
+			skip("No connected resource has the " + s.ToolName + " tool.")
+			continue
+		}
+		s.ToolUUID = tool.UUID
+
+		params := make(map[string]string, len(s.Parameters))
+		for k, v := range s.Parameters {
+			params[k] = v
+		}
+
+		name := s.JobName
+		if name == "" {
+			name = s.Name
+		}
+
+		j := common.NewJob(s.ToolUUID, name+" ("+now.Format("2006-01-02 15:04")+")", s.Owner, params)
+		j.OwnerRole = s.OwnerRole
+		addEvent(&j, common.EVENT_CREATED, "", s.Owner, "Created by schedule "+s.Name+".")
+
+		// The tool may have changed since the schedule was saved
+		if err := q.validateJob(j); err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		// Skip this run if the owner already has as many jobs queued as allowed
+		if err := q.checkQueuedQuota(j); err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		q.stack = append(q.stack, j)
+		q.stats.jobCreated(j, tool.Name, now)
+
+		// Call out to the registered hooks to inform them of job creation
+		go HookOnJobCreate(Hooks.JobCreate, cloneJob(j))
+
+		s.LastRun = now
+		s.LastJob = j.UUID
+		s.NextRun = spec.Next(now)
+		q.schedules[id] = s
+
+		log.WithFields(log.Fields{
+			"schedule": id,
+			"job":      j.UUID,
+			"nextrun":  s.NextRun,
+		}).Info("Scheduled job created.")
+	}
+}
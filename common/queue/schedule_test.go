@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestRunSchedules(t *testing.T) {
+	// The resource reconnected so the tool has a new UUID
+	q := Queue{
+		pool: ResourcePool{
+			"res1": {Status: common.STATUS_RUNNING, Tools: map[string]common.Tool{
+				"new1": {Name: "Hashcat", Version: "3.30", UUID: "real1", Parameters: `{"form": [], "schema": {"properties": {"hashmode": {"type": "integer"}}}}`},
+			}},
+		},
+		schedules: map[string]Schedule{
+			"good": {Name: "Weekly", Owner: "alice", Cron: "0 9 * * 1", Enabled: true, ToolUUID: "old1", ToolName: "Hashcat", ToolVersion: "3.30",
+				Parameters: map[string]string{"hashmode": "1000"}, NextRun: time.Now().Add(-time.Minute)},
+			"bad": {Name: "Broken", Owner: "alice", Cron: "0 9 * * 1", Enabled: true, ToolUUID: "old1", ToolName: "Hashcat", ToolVersion: "3.30",
+				Parameters: map[string]string{"hashmode": "NTLM"}, NextRun: time.Now().Add(-time.Minute)},
+			"gone": {Name: "Gone", Owner: "alice", Cron: "0 9 * * 1", Enabled: true, ToolUUID: "old2", ToolName: "John",
+				NextRun: time.Now().Add(-time.Minute)},
+		},
+	}
+
+	q.runSchedules()
+
+	if len(q.stack) != 1 {
+		t.Fatalf("Expected one scheduled job but got %d", len(q.stack))
+	}
+	if q.stack[0].ToolUUID != "new1" || q.schedules["good"].LastJob != q.stack[0].UUID {
+		t.Errorf("Expected the job to use the tool's new UUID but got %+v", q.stack[0])
+	}
+
+	// Skipped runs wait for the next time the schedule matches
+	for _, id := range []string{"bad", "gone"} {
+		if s := q.schedules[id]; s.LastJob != "" || !s.NextRun.After(time.Now()) {
+			t.Errorf("Expected schedule %s to be skipped but got %+v", id, s)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package queue
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+const (
+	POLICY_FIFO       = "fifo"
+	POLICY_PRIORITY   = "priority"
+	POLICY_ROUNDROBIN = "roundrobin"
+	POLICY_FAIRSHARE  = "fairshare"
+)
+
+// Scheduler is the policy the keeper consults when picking the next job to run
+var Scheduler SchedulingPolicy = FIFOPolicy{}
+
+// SchedulingPolicy decides the order in which waiting jobs are offered free
+// hardware by the keeper. Order is given the whole stack and returns the
+// indexes of the created and paused jobs, best candidate first. Policies should
+// fall back to stack order on ties so StackReorder remains a manual override.
+type SchedulingPolicy interface {
+	Name() string
+	Order(stack []common.Job) []int
+}
+
+// NewSchedulingPolicy returns the policy with the name given. Weights are only
+// used by the fair share policy and map an Owner to their share of the queue.
+func NewSchedulingPolicy(name string, weights map[string]int) (SchedulingPolicy, error) {
+	switch strings.ToLower(name) {
+	case "", POLICY_FIFO:
+		return FIFOPolicy{}, nil
+	case POLICY_PRIORITY:
+		return PriorityPolicy{}, nil
+	case POLICY_ROUNDROBIN:
+		return RoundRobinPolicy{}, nil
+	case POLICY_FAIRSHARE:
+		return FairSharePolicy{Weights: weights}, nil
+	}
+
+	return nil, errors.New("Unknown scheduling policy " + name)
+}
+
+// waitingJobs returns the index of every job that is waiting on hardware
+func waitingJobs(stack []common.Job) []int {
+	now := time.Now()
+
+	var idx []int
+	for i := range stack {
+		if stack[i].Chunked || now.Before(stack[i].NotBefore) || now.Before(stack[i].RetryAfter) {
+			continue
+		}
+
+		if stack[i].Status == common.STATUS_CREATED || stack[i].Status == common.STATUS_PAUSED {
+			idx = append(idx, i)
+		}
+	}
+
+	return idx
+}
+
+// byPriority sorts the waiting job indexes by priority keeping stack order on ties
+func byPriority(stack []common.Job, idx []int) {
+	sort.SliceStable(idx, func(a, b int) bool {
+		return stack[idx[a]].Priority > stack[idx[b]].Priority
+	})
+}
+
+// byOwner groups the waiting jobs by Owner, in order of each Owner's first job
+func byOwner(stack []common.Job, idx []int) (owners []string, jobs map[string][]int) {
+	jobs = map[string][]int{}
+	for _, i := range idx {
+		owner := stack[i].Owner
+		if _, ok := jobs[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		jobs[owner] = append(jobs[owner], i)
+	}
+
+	return owners, jobs
+}
+
+// runningByOwner counts the jobs each Owner currently has on the resources
+func runningByOwner(stack []common.Job) map[string]int {
+	running := map[string]int{}
+	for i := range stack {
+		if stack[i].Status == common.STATUS_RUNNING && !stack[i].Chunked {
+			running[stack[i].Owner]++
+		}
+	}
+
+	return running
+}
+
+// FIFOPolicy runs jobs in the order of the stack
+type FIFOPolicy struct{}
+
+func (p FIFOPolicy) Name() string {
+	return POLICY_FIFO
+}
+
+func (p FIFOPolicy) Order(stack []common.Job) []int {
+	return waitingJobs(stack)
+}
+
+// PriorityPolicy runs the highest priority jobs first
+type PriorityPolicy struct{}
+
+func (p PriorityPolicy) Name() string {
+	return POLICY_PRIORITY
+}
+
+func (p PriorityPolicy) Order(stack []common.Job) []int {
+	idx := waitingJobs(stack)
+	byPriority(stack, idx)
+
+	return idx
+}
+
+// RoundRobinPolicy takes turns between Owners, starting with those who have the
+// fewest jobs running. Each Owner's own jobs are run by priority.
+type RoundRobinPolicy struct{}
+
+func (p RoundRobinPolicy) Name() string {
+	return POLICY_ROUNDROBIN
+}
+
+func (p RoundRobinPolicy) Order(stack []common.Job) []int {
+	idx := waitingJobs(stack)
+	byPriority(stack, idx)
+
+	owners, jobs := byOwner(stack, idx)
+	running := runningByOwner(stack)
+	sort.SliceStable(owners, func(a, b int) bool {
+		return running[owners[a]] < running[owners[b]]
+	})
+
+	var order []int
+	for len(order) < len(idx) {
+		for _, owner := range owners {
+			if len(jobs[owner]) > 0 {
+				order = append(order, jobs[owner][0])
+				jobs[owner] = jobs[owner][1:]
+			}
+		}
+	}
+
+	return order
+}
+
+// FairSharePolicy gives each Owner a share of the running jobs in proportion to
+// their weight. Owners without a weight have a weight of 1. Each Owner's own
+// jobs are run by priority.
+type FairSharePolicy struct {
+	Weights map[string]int
+}
+
+func (p FairSharePolicy) Name() string {
+	return POLICY_FAIRSHARE
+}
+
+func (p FairSharePolicy) weight(owner string) float64 {
+	if w, ok := p.Weights[owner]; ok && w > 0 {
+		return float64(w)
+	}
+
+	return 1
+}
+
+func (p FairSharePolicy) Order(stack []common.Job) []int {
+	idx := waitingJobs(stack)
+	byPriority(stack, idx)
+
+	owners, jobs := byOwner(stack, idx)
+	running := runningByOwner(stack)
+
+	// Repeatedly hand the next slot to the Owner furthest below their share
+	var order []int
+	for len(order) < len(idx) {
+		var best string
+		var bestShare float64
+		var found bool
+		for _, owner := range owners {
+			if len(jobs[owner]) == 0 {
+				continue
+			}
+
+			share := float64(running[owner]) / p.weight(owner)
+			if !found || share < bestShare {
+				best = owner
+				bestShare = share
+				found = true
+			}
+		}
+
+		order = append(order, jobs[best][0])
+		jobs[best] = jobs[best][1:]
+		running[best]++
+	}
+
+	return order
+}
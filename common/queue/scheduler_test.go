@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func testStack() []common.Job {
+	return []common.Job{
+		{UUID: "0", Owner: "alice", Status: common.STATUS_RUNNING},
+		{UUID: "1", Owner: "alice", Status: common.STATUS_CREATED},
+		{UUID: "2", Owner: "alice", Status: common.STATUS_CREATED, Priority: 5},
+		{UUID: "3", Owner: "bob", Status: common.STATUS_CREATED},
+		{UUID: "4", Owner: "bob", Status: common.STATUS_DONE},
+		{UUID: "5", Owner: "carol", Status: common.STATUS_PAUSED},
+	}
+}
+
+func TestSchedulingPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		order  []int
+	}{
+		{POLICY_FIFO, []int{1, 2, 3, 5}},
+		{POLICY_PRIORITY, []int{2, 1, 3, 5}},
+		{POLICY_ROUNDROBIN, []int{3, 5, 2, 1}},
+		{POLICY_FAIRSHARE, []int{3, 5, 2, 1}},
+	}
+
+	for _, test := range tests {
+		p, err := NewSchedulingPolicy(test.policy, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		order := p.Order(testStack())
+		if !reflect.DeepEqual(order, test.order) {
+			t.Errorf("Policy %s: expected order %v but got %v", test.policy, test.order, order)
+		}
+	}
+
+	if _, err := NewSchedulingPolicy("random", nil); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestFairShareWeights(t *testing.T) {
+	// alice has nothing running and bob has one job running
+	stack := testStack()
+	stack[0].Status = common.STATUS_DONE
+	stack[4].Status = common.STATUS_RUNNING
+	stack = append(stack, common.Job{UUID: "6", Owner: "alice", Status: common.STATUS_CREATED})
+
+	p, _ := NewSchedulingPolicy(POLICY_FAIRSHARE, nil)
+	order := p.Order(stack)
+	if !reflect.DeepEqual(order, []int{2, 5, 1, 3, 6}) {
+		t.Errorf("Expected order [2 5 1 3 6] but got %v", order)
+	}
+
+	// A higher weight lets alice run more jobs before bob gets another turn
+	p, _ = NewSchedulingPolicy(POLICY_FAIRSHARE, map[string]int{"alice": 4})
+	order = p.Order(stack)
+	if !reflect.DeepEqual(order, []int{2, 5, 1, 6, 3}) {
+		t.Errorf("Expected order [2 5 1 6 3] but got %v", order)
+	}
+}
@@ -0,0 +1,231 @@
+package queue
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// A Snapshot is a consistent view of the jobs and resources of the Queue. A new
+// one is published every time the write lock is released, so readers never wait
+// on the keeper or see the stack half way through an update. A Snapshot is
+// shared between readers and must not be changed.
+type Snapshot struct {
+	Version   uint64 // Increases with every snapshot published
+	Taken     time.Time
+	Jobs      []common.Job
+	Resources ResourcePool
+	Stats     Stats // Copy of the usage figures the stats report is built from
+}
+
+// Snapshot returns the latest view of the Queue
+func (q *Queue) Snapshot() *Snapshot {
+	if snap, ok := q.snap.Load().(*Snapshot); ok {
+		return snap
+	}
+
+	return &Snapshot{Resources: NewResourcePool()}
+}
+
+// Job returns the job with the UUID given or an empty job if it is not found
+func (s *Snapshot) Job(jobUUID string) common.Job {
+	for _, job := range s.Jobs {
+		if job.UUID == jobUUID {
+			return job
+		}
+	}
+
+	return common.Job{}
+}
+
+// Unlock publishes a snapshot of the stack and pool before releasing the write
+// lock so readers see every change made while it was held. The calls set up
+// meanwhile are handed to the resource workers (See runDispatches).
+func (q *Queue) Unlock() {
+	q.runDispatches()
+	q.publish()
+	q.RWMutex.Unlock()
+}
+
+// publish stores a copy of the stack and pool as the latest Snapshot. Jobs and
+// resources that have not changed since the last Snapshot share the copy made
+// for it, so only what changed is cloned.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) publish() {
+	prev := q.Snapshot()
+	q.version++
+
+	snap := &Snapshot{
+		Version:   q.version,
+		Taken:     time.Now(),
+		Jobs:      make([]common.Job, len(q.stack)),
+		Resources: make(ResourcePool, len(q.pool)),
+		Stats:     q.stats.counters(),
+	}
+
+	// The stack is usually in the same order as last time, so jobs are only
+	// looked up by UUID when it is not
+	var prevJobs map[string]int
+	for i := range q.stack {
+		p := i
+		if p >= len(prev.Jobs) || prev.Jobs[p].UUID != q.stack[i].UUID {
+			if prevJobs == nil {
+				prevJobs = make(map[string]int, len(prev.Jobs))
+				for n := range prev.Jobs {
+					prevJobs[prev.Jobs[n].UUID] = n
+				}
+			}
+
+			var ok bool
+			if p, ok = prevJobs[q.stack[i].UUID]; !ok {
+				snap.Jobs[i] = cloneJob(q.stack[i])
+				continue
+			}
+		}
+
+		if sameJob(q.stack[i], prev.Jobs[p]) {
+			snap.Jobs[i] = prev.Jobs[p]
+		} else {
+			snap.Jobs[i] = cloneJob(q.stack[i])
+		}
+	}
+
+	for k, res := range q.pool {
+		if old, ok := prev.Resources[k]; ok && sameResource(res, old) {
+			snap.Resources[k] = old
+		} else {
+			snap.Resources[k] = cloneResource(res)
+		}
+	}
+
+	q.snap.Store(snap)
+}
+
+// sameJob checks if a job is unchanged from the copy of it published before
+func sameJob(j, published common.Job) bool {
+	// Output is only ever added to or replaced, never changed in place, so it
+	// is unchanged if it is still the same rows (See cloneJob)
+	if len(j.OutputData) != len(published.OutputData) {
+		return false
+	}
+	if len(j.OutputData) > 0 && &j.OutputData[0] != &published.OutputData[0] {
+		return false
+	}
+
+	j.OutputData, published.OutputData = nil, nil
+
+	return reflect.DeepEqual(j, published)
+}
+
+// sameResource checks if a resource is unchanged from the copy of it published
+// before. The connection is only compared by identity.
+func sameResource(r, published Resource) bool {
+	if r.Client != published.Client {
+		return false
+	}
+
+	r.Client, published.Client = nil, nil
+
+	return reflect.DeepEqual(r, published)
+}
+
+// cloneJob copies a job along with its maps and slices so the copy can be read
+// while the Queue keeps updating the original.
+func cloneJob(j common.Job) common.Job {
+	j.Parameters = cloneStrings(j.Parameters)
+	j.PerformanceData = cloneStrings(j.PerformanceData)
+	j.Placement.Require = cloneStrings(j.Placement.Require)
+	j.Placement.Prefer = cloneStrings(j.Placement.Prefer)
+	j.Benchmarks = cloneFloats(j.Benchmarks)
+
+	// Rows of output are only ever added to the end of the original, so the
+	// copy can share them as long as adding to it cannot write over them
+	if j.OutputData != nil {
+		j.OutputData = j.OutputData[:len(j.OutputData):len(j.OutputData)]
+	}
+	if j.OutputTitles != nil {
+		j.OutputTitles = append([]string(nil), j.OutputTitles...)
+	}
+	if j.Dependencies != nil {
+		j.Dependencies = append([]common.Dependency(nil), j.Dependencies...)
+	}
+	if j.Attempts != nil {
+		j.Attempts = append([]common.Attempt(nil), j.Attempts...)
+	}
+	if j.Events != nil {
+		j.Events = append([]common.Event(nil), j.Events...)
+	}
+	if j.Placement.Avoid != nil {
+		j.Placement.Avoid = append([]string(nil), j.Placement.Avoid...)
+	}
+
+	return j
+}
+
+// cloneJobs copies every job in a stack (See cloneJob)
+func cloneJobs(stack []common.Job) []common.Job {
+	jobs := make([]common.Job, len(stack))
+	for i := range stack {
+		jobs[i] = cloneJob(stack[i])
+	}
+
+	return jobs
+}
+
+// cloneResource copies a resource along with its maps
+func cloneResource(r Resource) Resource {
+	r.Hardware = cloneInts(r.Hardware)
+	r.Capacity = cloneInts(r.Capacity)
+	r.Labels = cloneStrings(r.Labels)
+	r.ManagerLabels = cloneStrings(r.ManagerLabels)
+
+	if r.Tools != nil {
+		tools := make(map[string]common.Tool, len(r.Tools))
+		for k, v := range r.Tools {
+			tools[k] = v
+		}
+		r.Tools = tools
+	}
+
+	return r
+}
+
+func cloneStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
+
+func cloneInts(m map[string]int) map[string]int {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
+
+func cloneFloats(m map[string]float64) map[string]float64 {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]float64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// TestSnapshotConcurrentAccess runs API style readers and writers against a
+// Queue while a keeper loop updates it. Run with -race to check readers never
+// touch the live stack or pool.
+func TestSnapshotConcurrentAccess(t *testing.T) {
+	q, err := NewQueue("", 30, 1, HookParameters{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.status = STATUS_RUNNING
+
+	res := NewResource()
+	res.Name = "rig1"
+	res.Status = common.STATUS_RUNNING
+	res.Capacity[common.RES_GPU] = 2
+	res.Hardware[common.RES_GPU] = 2
+	res.Tools["tool1"] = common.Tool{UUID: "tool1", Name: "tool", Requirements: common.RES_GPU}
+	q.pool["res1"] = res
+
+	const jobs = 20
+	var wg, writers sync.WaitGroup
+	stop := make(chan bool)
+
+	// Writers adding and changing jobs through the API
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for n := 0; n < jobs; n++ {
+			j := common.NewJob("tool1", fmt.Sprintf("job%d", n), "tester", map[string]string{"n": fmt.Sprint(n)})
+			if err := q.AddJob(j); err != nil {
+				t.Error(err)
+			}
+			q.SetJobPriority(j.UUID, n%3)
+		}
+	}()
+
+	// A keeper loop starting jobs on a resource that cannot be reached
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			q.Lock()
+			q.updateQueue(q.takePolled())
+			q.splitJobs()
+			q.startJobs()
+			q.pollJobs()
+			q.Unlock()
+		}
+	}()
+
+	// Readers walking every job and resource
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				snap := q.Snapshot()
+				if snap.Version < last {
+					t.Errorf("Snapshot version went back from %d to %d", last, snap.Version)
+				}
+				last = snap.Version
+
+				for _, j := range q.AllJobs() {
+					_ = len(j.Parameters) + len(j.Attempts) + len(j.PerformanceData)
+					q.JobInfo(j.UUID)
+				}
+				q.AllJobsByResource("res1")
+
+				if res, ok := q.GetResource("res1"); ok {
+					_ = res.Hardware[common.RES_GPU]
+				}
+			}
+		}()
+	}
+
+	writers.Wait()
+	close(stop)
+	wg.Wait()
+
+	if n := len(q.Snapshot().Jobs); n != jobs {
+		t.Errorf("Expected %d jobs in the snapshot but found %d", jobs, n)
+	}
+}
+
+func TestCloneJob(t *testing.T) {
+	j := common.NewJob("tool1", "job", "tester", map[string]string{"a": "1"})
+	j.Attempts = []common.Attempt{{ResourceUUID: "res1"}}
+
+	c := cloneJob(j)
+	j.Parameters["a"] = "2"
+	j.Attempts[0].Error = "failed"
+
+	if c.Parameters["a"] != "1" || c.Attempts[0].Error != "" {
+		t.Error("Expected the cloned job not to change with the original")
+	}
+}
+
+func TestPublishSharesUnchanged(t *testing.T) {
+	q := Queue{
+		pool: ResourcePool{"res1": NewResource()},
+		stack: []common.Job{
+			common.NewJob("tool1", "same", "tester", map[string]string{"a": "1"}),
+			common.NewJob("tool1", "changed", "tester", map[string]string{"a": "1"}),
+		},
+	}
+	q.stack[0].OutputData = [][]string{{"hash", "plain"}}
+	q.stack[1].Attempts = []common.Attempt{{ResourceUUID: "res1"}}
+
+	q.publish()
+	first := q.Snapshot()
+
+	// A change made in place is still picked up
+	q.stack[1].Attempts[0].Error = "failed"
+	q.publish()
+	second := q.Snapshot()
+
+	same := reflect.ValueOf(second.Jobs[0].Parameters).Pointer()
+	if same != reflect.ValueOf(first.Jobs[0].Parameters).Pointer() {
+		t.Error("Expected the unchanged job to share the copy already published")
+	}
+	if &second.Jobs[0].OutputData[0] != &q.stack[0].OutputData[0] {
+		t.Error("Expected the output to be shared rather than copied")
+	}
+	if second.Jobs[1].Attempts[0].Error != "failed" || first.Jobs[1].Attempts[0].Error != "" {
+		t.Error("Expected only the new snapshot to have the changed job")
+	}
+	if second.Resources["res1"].Hardware == nil || reflect.ValueOf(second.Resources["res1"].Hardware).Pointer() != reflect.ValueOf(first.Resources["res1"].Hardware).Pointer() {
+		t.Error("Expected the unchanged resource to share the copy already published")
+	}
+
+	// and output added to the end does not show up in the old copy
+	q.stack[0].OutputData = append(q.stack[0].OutputData, []string{"hash2", "plain2"})
+	q.publish()
+	if len(q.Snapshot().Jobs[0].OutputData) != 2 || len(second.Jobs[0].OutputData) != 1 {
+		t.Error("Expected new output in the new snapshot only")
+	}
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// SpeedModeParameter is the job parameter speeds are recorded under, such as
+// the hash type of a password cracking job. Jobs without it are not recorded.
+var SpeedModeParameter = "hashmode"
+
+const (
+	SPEED_SOURCE_JOB       = "job"       // Average speed of a job that ran on the resource
+	SPEED_SOURCE_BENCHMARK = "benchmark" // Measured by a benchmark job on the resource
+)
+
+// A Speed is how fast a resource has been seen to run a tool in one mode
+type Speed struct {
+	Tool    string    `json:"tool"`
+	Mode    string    `json:"mode"`
+	Speed   float64   `json:"speed"` // Hashes a second
+	Source  string    `json:"source"`
+	Updated time.Time `json:"updated"`
+}
+
+// Speeds are the speeds of every resource by resource name and then speedKey.
+// Resources are given a new UUID each time they connect, so the name is what
+// keeps their speeds across reconnects and restarts (See speedResource).
+type Speeds map[string]map[string]Speed
+
+// speedKey returns the key a speed is kept under for a resource
+func speedKey(tool, mode string) string {
+	return strings.ToLower(tool) + "/" + mode
+}
+
+// hashRates are the performance titles that are hash rates, with what each is
+// in hashes a second
+var hashRates = map[string]float64{
+	"h/s":  1,
+	"kh/s": 1e3,
+	"mh/s": 1e6,
+	"gh/s": 1e9,
+	"th/s": 1e12,
+}
+
+// averageRate returns the average of the non-zero performance samples of a
+// job in hashes a second, or false if the job does not report a hash rate
+func averageRate(j common.Job) (float64, bool) {
+	scale, ok := hashRates[strings.ToLower(strings.TrimSpace(j.PerformanceTitle))]
+	if !ok {
+		return 0, false
+	}
+
+	var total float64
+	var n int
+	for _, v := range j.PerformanceData {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			total += f
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0, false
+	}
+
+	return total / float64(n) * scale, true
+}
+
+// speedResource returns the key the speeds of the resource with the UUID given
+// are kept under, which is its name. Resources without one use their UUID.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) speedResource(resUUID string) string {
+	if res, ok := q.pool[resUUID]; ok && res.Name != "" {
+		return res.Name
+	}
+
+	return resUUID
+}
+
+// setSpeed stores the speed of a resource, replacing any older one
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) setSpeed(resUUID string, s Speed) {
+	key := q.speedResource(resUUID)
+
+	if q.speeds == nil {
+		q.speeds = Speeds{}
+	}
+	if q.speeds[key] == nil {
+		q.speeds[key] = map[string]Speed{}
+	}
+
+	q.speeds[key][speedKey(s.Tool, s.Mode)] = s
+}
+
+// speedOf returns the speed recorded for a resource running a tool in a mode
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) speedOf(resUUID, tool, mode string) (Speed, bool) {
+	s, ok := q.speeds[q.speedResource(resUUID)][speedKey(tool, mode)]
+	return s, ok
+}
+
+// ResourceSpeeds returns every speed recorded for a resource sorted by tool and
+// mode, or false if nothing has been recorded for it
+func (q *Queue) ResourceSpeeds(resUUID string) ([]Speed, bool) {
+	q.RLock()
+	defer q.RUnlock()
+
+	recorded, ok := q.speeds[q.speedResource(resUUID)]
+	if !ok {
+		return nil, false
+	}
+
+	speeds := make([]Speed, 0, len(recorded))
+	for _, s := range recorded {
+		speeds = append(speeds, s)
+	}
+
+	sort.Slice(speeds, func(a, b int) bool {
+		if speeds[a].Tool != speeds[b].Tool {
+			return speeds[a].Tool < speeds[b].Tool
+		}
+		return speeds[a].Mode < speeds[b].Mode
+	})
+
+	return speeds, true
+}
+
+// recordSpeed keeps the speed of the job at index i once it has stopped
+// running on its resource. Benchmarks record every mode they measured while
+// other jobs record their average speed. Chunks are recorded on their own
+// resource.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) recordSpeed(i int) {
+	j := q.stack[i]
+	if j.Chunked {
+		return
+	}
+
+	res, ok := q.pool[j.ResAssigned]
+	if !ok {
+		return
+	}
+
+	// Find the tool by its real UUID since the Job's might have changed (See AddJob)
+	var toolName string
+	for _, tool := range res.Tools {
+		if tool.UUID == j.ToolUUID {
+			toolName = tool.Name
+			break
+		}
+	}
+	if toolName == "" {
+		return
+	}
+
+	if common.IsBenchmark(j) {
+		for mode, rate := range j.Benchmarks {
+			if rate <= 0 {
+				continue
+			}
+
+			q.setSpeed(j.ResAssigned, Speed{
+				Tool:    toolName,
+				Mode:    mode,
+				Speed:   rate,
+				Source:  SPEED_SOURCE_BENCHMARK,
+				Updated: time.Now(),
+			})
+		}
+		return
+	}
+
+	mode, ok := j.Parameters[SpeedModeParameter]
+	if !ok || mode == "" {
+		return
+	}
+
+	rate, ok := averageRate(j)
+	if !ok {
+		return
+	}
+
+	q.setSpeed(j.ResAssigned, Speed{
+		Tool:    toolName,
+		Mode:    mode,
+		Speed:   rate,
+		Source:  SPEED_SOURCE_JOB,
+		Updated: time.Now(),
+	})
+}
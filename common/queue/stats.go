@@ -1,17 +1,373 @@
 package queue
 
+import (
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// StatsBucket is the length of each period in the stats series
+var StatsBucket = time.Hour
+
+// StatsHistory is how far back the stats series is kept
+var StatsHistory = 30 * 24 * time.Hour
+
+// Stats keeps usage figures for the Queue. It is saved with the rest of the
+// state so the figures last across restarts.
+// A LOCK ON THE QUEUE SHOULD BE HELD TO USE IT.
 type Stats struct {
-	jobsCount int64
+	JobsCount   int64
+	JobsByTool  map[string]int64         // Jobs created by tool name
+	JobsByOwner map[string]int64         // Jobs created by owner
+	Finished    map[string]int64         // Jobs finished by their final status
+	RunTime     map[string]time.Duration // Time spent running jobs by resource UUID
+	Cracked     int64
+	WaitTotal   time.Duration // Time jobs spent in the queue before starting
+	WaitCount   int64
+	Series      []StatsBucketData
+	Seen        map[string]JobSeen       // What has been counted for each job in the stack
+	GPUDay      time.Time                // Day GPUTime is being counted for
+	GPUTime     map[string]time.Duration // GPU time used on GPUDay by owner
+}
+
+// StatsBucketData holds the figures for one period of the stats series
+type StatsBucketData struct {
+	Start     time.Time
+	Created   int64
+	Finished  int64
+	Cracked   int64
+	WaitTotal time.Duration
+	WaitCount int64
+	Used      map[string]int64 // Hardware units in use summed over every sample
+	Capacity  map[string]int64 // Hardware units available summed over every sample
+}
+
+// JobSeen is what the stats have already counted for a job
+type JobSeen struct {
+	Cracked  int64
+	Started  bool
+	Finished bool
+}
+
+// A StatsReport is the view of the stats given to the API
+type StatsReport struct {
+	JobsCreated   int64
+	JobsByTool    map[string]int64
+	JobsByOwner   map[string]int64
+	JobsByStatus  map[string]int64 // Jobs in the queue now by status
+	JobsFinished  map[string]int64 // Jobs finished by their final status
+	RunTime       map[string]time.Duration
+	CrackedHashes int64
+	AverageWait   time.Duration
+	Utilisation   map[string]float64 // Share of each hardware type in use now
+	Series        []StatsPoint
+}
+
+// A StatsPoint is one period of the stats series
+type StatsPoint struct {
+	Start         time.Time
+	JobsCreated   int64
+	JobsFinished  int64
+	CrackedHashes int64
+	AverageWait   time.Duration
+	Utilisation   map[string]float64 // Average share of each hardware type in use
 }
 
 func NewStats() Stats {
-	return Stats{}
+	return Stats{
+		JobsByTool:  map[string]int64{},
+		JobsByOwner: map[string]int64{},
+		Finished:    map[string]int64{},
+		RunTime:     map[string]time.Duration{},
+		Seen:        map[string]JobSeen{},
+		GPUTime:     map[string]time.Duration{},
+	}
+}
+
+// restoreStats picks up saved stats, filling in anything missing
+func restoreStats(saved Stats) Stats {
+	saved.fill()
+
+	for i := range saved.Series {
+		if saved.Series[i].Used == nil {
+			saved.Series[i].Used = map[string]int64{}
+		}
+		if saved.Series[i].Capacity == nil {
+			saved.Series[i].Capacity = map[string]int64{}
+		}
+	}
+
+	return saved
+}
+
+// fill makes any missing maps
+func (s *Stats) fill() {
+	if s.JobsByTool == nil {
+		s.JobsByTool = map[string]int64{}
+	}
+	if s.JobsByOwner == nil {
+		s.JobsByOwner = map[string]int64{}
+	}
+	if s.Finished == nil {
+		s.Finished = map[string]int64{}
+	}
+	if s.RunTime == nil {
+		s.RunTime = map[string]time.Duration{}
+	}
+	if s.Seen == nil {
+		s.Seen = map[string]JobSeen{}
+	}
+	if s.GPUTime == nil {
+		s.GPUTime = map[string]time.Duration{}
+	}
 }
 
 func (s *Stats) IncJob() {
-	s.jobsCount++
+	s.JobsCount++
 }
 
 func (s *Stats) JobCount() int64 {
-	return s.jobsCount
+	return s.JobsCount
+}
+
+// jobCreated counts a job added to the Queue
+func (s *Stats) jobCreated(j common.Job, tool string, now time.Time) {
+	s.fill()
+	s.IncJob()
+	s.JobsByTool[tool]++
+	s.JobsByOwner[j.Owner]++
+	s.bucket(now).Created++
+}
+
+// addRuntime counts time spent running a job on a resource
+func (s *Stats) addRuntime(resUUID string, d time.Duration) {
+	s.fill()
+	s.RunTime[resUUID] += d
+}
+
+// addGPUTime counts time spent using GPUs by an owner, starting again each day
+func (s *Stats) addGPUTime(owner string, d time.Duration, now time.Time) {
+	s.fill()
+
+	day := startOfDay(now)
+	if !s.GPUDay.Equal(day) {
+		s.GPUDay = day
+		s.GPUTime = map[string]time.Duration{}
+	}
+
+	s.GPUTime[owner] += d
+}
+
+// gpuTime returns the GPU time used by an owner on the day of the time given
+func (s *Stats) gpuTime(owner string, now time.Time) time.Duration {
+	if !s.GPUDay.Equal(startOfDay(now)) {
+		return 0
+	}
+
+	return s.GPUTime[owner]
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// sample counts what has changed for the jobs in the stack since the last
+// sample and records how much of the hardware of the pool is in use.
+func (s *Stats) sample(stack []common.Job, pool ResourcePool, now time.Time) {
+	s.fill()
+	b := s.bucket(now)
+
+	current := map[string]bool{}
+	for _, j := range stack {
+		// Chunks are counted through the job they were split from
+		if j.ParentUUID != "" {
+			continue
+		}
+		current[j.UUID] = true
+		seen := s.Seen[j.UUID]
+
+		if j.CrackedHashes > seen.Cracked {
+			s.Cracked += j.CrackedHashes - seen.Cracked
+			b.Cracked += j.CrackedHashes - seen.Cracked
+		}
+		seen.Cracked = j.CrackedHashes
+
+		if !seen.Started && !j.StartTime.IsZero() {
+			seen.Started = true
+			if !j.Created.IsZero() && j.StartTime.After(j.Created) {
+				wait := j.StartTime.Sub(j.Created)
+				s.WaitTotal += wait
+				s.WaitCount++
+				b.WaitTotal += wait
+				b.WaitCount++
+			}
+		}
+
+		if !seen.Finished && common.IsDone(j.Status) {
+			seen.Finished = true
+			s.Finished[j.Status]++
+			b.Finished++
+		}
+
+		s.Seen[j.UUID] = seen
+	}
+
+	for id := range s.Seen {
+		if !current[id] {
+			delete(s.Seen, id)
+		}
+	}
+
+	for _, res := range pool {
+		if res.Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		for hw, capacity := range res.Capacity {
+			b.Capacity[hw] += int64(capacity)
+			b.Used[hw] += int64(capacity - res.Hardware[hw])
+		}
+	}
+
+	// Forget periods past the history
+	var keep int
+	for keep < len(s.Series) && now.Sub(s.Series[keep].Start) > StatsHistory {
+		keep++
+	}
+	s.Series = s.Series[keep:]
+}
+
+// bucket returns the period of the series for the time given
+func (s *Stats) bucket(now time.Time) *StatsBucketData {
+	start := now.Truncate(StatsBucket)
+
+	if n := len(s.Series); n > 0 && !s.Series[n-1].Start.Before(start) {
+		return &s.Series[n-1]
+	}
+
+	s.Series = append(s.Series, StatsBucketData{
+		Start:    start,
+		Used:     map[string]int64{},
+		Capacity: map[string]int64{},
+	})
+	return &s.Series[len(s.Series)-1]
+}
+
+// counters returns a copy of the figures report uses so it can be published
+// with a Snapshot. Only the current period of the series is still being added
+// to, so the earlier ones share their maps.
+func (s *Stats) counters() Stats {
+	c := Stats{
+		JobsCount:   s.JobsCount,
+		JobsByTool:  copyCounts(s.JobsByTool),
+		JobsByOwner: copyCounts(s.JobsByOwner),
+		Finished:    copyCounts(s.Finished),
+		RunTime:     make(map[string]time.Duration, len(s.RunTime)),
+		Cracked:     s.Cracked,
+		WaitTotal:   s.WaitTotal,
+		WaitCount:   s.WaitCount,
+		Series:      append([]StatsBucketData(nil), s.Series...),
+	}
+
+	for k, v := range s.RunTime {
+		c.RunTime[k] = v
+	}
+
+	if n := len(c.Series); n > 0 {
+		c.Series[n-1].Used = copyCounts(c.Series[n-1].Used)
+		c.Series[n-1].Capacity = copyCounts(c.Series[n-1].Capacity)
+	}
+
+	return c
+}
+
+// report builds the view of the stats since the time given
+func (s *Stats) report(stack []common.Job, pool ResourcePool, since time.Time) StatsReport {
+	r := StatsReport{
+		JobsCreated:   s.JobsCount,
+		JobsByTool:    copyCounts(s.JobsByTool),
+		JobsByOwner:   copyCounts(s.JobsByOwner),
+		JobsByStatus:  map[string]int64{},
+		JobsFinished:  copyCounts(s.Finished),
+		RunTime:       map[string]time.Duration{},
+		CrackedHashes: s.Cracked,
+		AverageWait:   averageWait(s.WaitTotal, s.WaitCount),
+		Utilisation:   map[string]float64{},
+	}
+
+	for k, v := range s.RunTime {
+		r.RunTime[k] = v
+	}
+
+	for _, j := range stack {
+		if j.ParentUUID == "" {
+			r.JobsByStatus[j.Status]++
+		}
+	}
+
+	used, capacity := map[string]int64{}, map[string]int64{}
+	for _, res := range pool {
+		if res.Status != common.STATUS_RUNNING {
+			continue
+		}
+
+		for hw, c := range res.Capacity {
+			capacity[hw] += int64(c)
+			used[hw] += int64(c - res.Hardware[hw])
+		}
+	}
+	r.Utilisation = utilisation(used, capacity)
+
+	for _, b := range s.Series {
+		if b.Start.Add(StatsBucket).Before(since) {
+			continue
+		}
+
+		r.Series = append(r.Series, StatsPoint{
+			Start:         b.Start,
+			JobsCreated:   b.Created,
+			JobsFinished:  b.Finished,
+			CrackedHashes: b.Cracked,
+			AverageWait:   averageWait(b.WaitTotal, b.WaitCount),
+			Utilisation:   utilisation(b.Used, b.Capacity),
+		})
+	}
+
+	return r
+}
+
+// Stats returns a report of how the Queue has been used with the series
+// starting from the time given, as of the latest snapshot.
+func (q *Queue) Stats(since time.Time) StatsReport {
+	snap := q.Snapshot()
+
+	return snap.Stats.report(snap.Jobs, snap.Resources, since)
+}
+
+func copyCounts(m map[string]int64) map[string]int64 {
+	c := make(map[string]int64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
+
+func averageWait(total time.Duration, count int64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+
+	return total / time.Duration(count)
+}
+
+func utilisation(used, capacity map[string]int64) map[string]float64 {
+	u := map[string]float64{}
+	for hw, c := range capacity {
+		if c > 0 {
+			u[hw] = float64(used[hw]) / float64(c)
+		}
+	}
+
+	return u
 }
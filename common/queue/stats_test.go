@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestStatsSample(t *testing.T) {
+	s := NewStats()
+	now := time.Date(2016, 3, 1, 10, 15, 0, 0, time.UTC)
+
+	j := common.Job{
+		UUID:          "job1",
+		Owner:         "alice",
+		Status:        common.STATUS_RUNNING,
+		Created:       now.Add(-10 * time.Minute),
+		StartTime:     now.Add(-5 * time.Minute),
+		CrackedHashes: 3,
+	}
+	s.jobCreated(j, "Hashcat", now)
+
+	res := NewResource()
+	res.Status = common.STATUS_RUNNING
+	res.Capacity[common.RES_GPU] = 4
+	res.Hardware[common.RES_GPU] = 1
+	pool := ResourcePool{"res1": res}
+
+	s.sample([]common.Job{j}, pool, now)
+
+	// Later the job finishes having cracked more, in the next period
+	j.CrackedHashes = 5
+	j.Status = common.STATUS_DONE
+	s.sample([]common.Job{j}, pool, now.Add(time.Hour))
+	s.sample([]common.Job{j}, pool, now.Add(time.Hour))
+
+	r := s.report(nil, pool, time.Time{})
+	if r.CrackedHashes != 5 || r.JobsFinished[common.STATUS_DONE] != 1 || r.JobsByOwner["alice"] != 1 {
+		t.Errorf("Unexpected totals %+v", r)
+	}
+	if r.AverageWait != 5*time.Minute {
+		t.Errorf("Expected an average wait of 5m but got %v", r.AverageWait)
+	}
+	if r.Utilisation[common.RES_GPU] != 0.75 {
+		t.Errorf("Expected 75%% of GPUs in use but got %v", r.Utilisation[common.RES_GPU])
+	}
+
+	if len(r.Series) != 2 {
+		t.Fatalf("Expected 2 periods but got %d", len(r.Series))
+	}
+	if r.Series[0].JobsCreated != 1 || r.Series[0].CrackedHashes != 3 || r.Series[1].CrackedHashes != 2 || r.Series[1].JobsFinished != 1 {
+		t.Errorf("Unexpected series %+v", r.Series)
+	}
+}
+
+func TestStatsFromSnapshot(t *testing.T) {
+	q := Queue{stats: NewStats(), pool: NewResourcePool()}
+	now := time.Now()
+
+	q.Lock()
+	q.stack = append(q.stack, common.Job{UUID: "job1", Owner: "alice", Status: common.STATUS_CREATED})
+	q.stats.jobCreated(q.stack[0], "Hashcat", now)
+	q.Unlock()
+
+	r := q.Stats(time.Time{})
+	if r.JobsCreated != 1 || r.JobsByStatus[common.STATUS_CREATED] != 1 || len(r.Series) != 1 {
+		t.Fatalf("Unexpected report %+v", r)
+	}
+
+	// Changes to the live stats are not seen until the next snapshot
+	q.stats.jobCreated(common.Job{Owner: "bob"}, "Hashcat", now)
+	q.stats.bucket(now).Used[common.RES_GPU] = 4
+
+	snap := q.Snapshot()
+	if snap.Stats.JobsCount != 1 || snap.Stats.JobsByOwner["bob"] != 0 || snap.Stats.Series[0].Created != 1 || snap.Stats.Series[0].Used[common.RES_GPU] != 0 {
+		t.Errorf("Snapshot stats changed with the live stats %+v", snap.Stats)
+	}
+}
@@ -0,0 +1,315 @@
+package queue
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// StateStoreLocation is where the Queue keeps its state between restarts. If it
+// is empty the state is not saved.
+var StateStoreLocation string
+
+// StateBackend is the name of the entry in StateBackends used to open the store
+var StateBackend = "bolt"
+
+// StateBackends are the ways the Queue can save its state by name
+var StateBackends = map[string]func(path string) (Store, error){
+	"bolt": OpenBoltStore,
+}
+
+// A Store saves the state of the Queue so it can be picked back up after a
+// restart. Each Commit must be applied completely or not at all.
+type Store interface {
+	// Load returns everything that has been saved with the jobs in stack order
+	Load() (StateFile, error)
+	// Commit saves a set of changes
+	Commit(b StateBatch) error
+	Close() error
+}
+
+// A StateBatch holds the changes to the Queue's state since the last commit.
+// Results are kept apart from the jobs so large outputs are only written again
+// when they change.
+type StateBatch struct {
+	Order     []string              // UUIDs of every job in stack order, nil if the order is unchanged
+	Jobs      []common.Job          // Jobs that have changed, without their OutputData
+	Results   map[string][][]string // Output of jobs that have changed by job UUID
+	Deleted   []string              // UUIDs of jobs no longer in the stack
+	Pool      []byte                // JSON of the resource pool, nil if unchanged
+	Pipelines []byte                // JSON of the pipelines, nil if unchanged
+	Schedules []byte                // JSON of the schedules, nil if unchanged
+	Templates []byte                // JSON of the templates, nil if unchanged
+	Speeds    []byte                // JSON of the speeds, nil if unchanged
+	Stats     []byte                // JSON of the stats, nil if unchanged
+}
+
+// Empty checks if there is anything to commit
+func (b StateBatch) Empty() bool {
+	return b.Order == nil && len(b.Jobs) == 0 && len(b.Results) == 0 && len(b.Deleted) == 0 &&
+		b.Pool == nil && b.Pipelines == nil && b.Schedules == nil && b.Templates == nil && b.Speeds == nil && b.Stats == nil
+}
+
+// savedState is what was last committed, so saveState only writes what has changed
+type savedState struct {
+	jobs      map[string][]byte     // JSON of each job without its output
+	results   map[string]string     // Hash of the output rows of each job (See rowsHash)
+	seen      map[string]common.Job // Copy of each job as it was last saved (See sameJob)
+	order     string
+	pool      []byte
+	pipelines []byte
+	schedules []byte
+	templates []byte
+	speeds    []byte
+	stats     []byte
+}
+
+func newSavedState() savedState {
+	return savedState{
+		jobs:    map[string][]byte{},
+		results: map[string]string{},
+		seen:    map[string]common.Job{},
+	}
+}
+
+// openStore opens the state store, imports a state file left by an older
+// version if the store is new and then restores the Queue from it.
+func (q *Queue) openStore() error {
+	open, ok := StateBackends[StateBackend]
+	if !ok {
+		return errors.New("Unknown state backend " + StateBackend + ".")
+	}
+
+	store, err := open(StateStoreLocation)
+	if err != nil {
+		return err
+	}
+
+	s, err := store.Load()
+	if err != nil {
+		store.Close()
+		return err
+	}
+
+	if len(s.Stack) == 0 && len(s.Pool) == 0 && StateFileLocation != "" {
+		if _, err := os.Stat(StateFileLocation); err == nil {
+			s, err = migrateStateFile(store, StateFileLocation)
+			if err != nil {
+				store.Close()
+				return err
+			}
+		}
+	}
+
+	q.store = store
+	q.restoreState(s)
+
+	// Start from what was loaded so the first save only writes changes
+	q.saved = newSavedState()
+	q.saveState()
+
+	return nil
+}
+
+// migrateStateFile imports the JSON state file written by older versions into
+// a store. The file is renamed once it has been imported so it is only
+// imported once.
+func migrateStateFile(store Store, path string) (StateFile, error) {
+	var s StateFile
+
+	stateFile, err := os.Open(path)
+	if err != nil {
+		return s, err
+	}
+
+	err = json.NewDecoder(stateFile).Decode(&s)
+	stateFile.Close()
+	if err != nil {
+		return s, err
+	}
+
+	// Build a batch holding everything
+	var saved = newSavedState()
+	b := buildBatch(&saved, s)
+
+	if err := store.Commit(b); err != nil {
+		return s, err
+	}
+
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		log.WithField("error", err.Error()).Warn("Unable to rename the migrated state file.")
+	}
+
+	log.WithFields(log.Fields{
+		"statefile": path,
+		"jobs":      len(s.Stack),
+	}).Info("State file imported into the state store.")
+
+	return s, nil
+}
+
+// saveState commits everything that has changed since the last save to the store
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) saveState() error {
+	if q.store == nil {
+		return nil
+	}
+
+	var s StateFile
+	s.Stack = q.stack
+	s.Pool = q.pool
+	s.Pipelines = q.pipelines
+	s.Schedules = q.schedules
+	s.Templates = q.templates
+	s.Speeds = q.speeds
+	s.Stats = &q.stats
+
+	// Work out the changes against a copy so a failed commit is tried again
+	saved := q.saved.copy()
+	b := buildBatch(&saved, s)
+	if b.Empty() {
+		return nil
+	}
+
+	start := time.Now()
+	if err := q.store.Commit(b); err != nil {
+		log.WithField("error", err.Error()).Error("Unable to save the state of the queue.")
+		return err
+	}
+	q.saved = saved
+
+	log.WithFields(log.Fields{
+		"jobs":     len(b.Jobs),
+		"results":  len(b.Results),
+		"deleted":  len(b.Deleted),
+		"duration": time.Since(start),
+	}).Debug("Queue state saved.")
+
+	return nil
+}
+
+func (s savedState) copy() savedState {
+	c := newSavedState()
+	for k, v := range s.jobs {
+		c.jobs[k] = v
+	}
+	for k, v := range s.results {
+		c.results[k] = v
+	}
+	for k, v := range s.seen {
+		c.seen[k] = v
+	}
+	c.order = s.order
+	c.pool = s.pool
+	c.pipelines = s.pipelines
+	c.schedules = s.schedules
+	c.templates = s.templates
+	c.speeds = s.speeds
+	c.stats = s.stats
+
+	return c
+}
+
+// buildBatch compares the state given to what was last saved and returns the
+// changes, updating saved to match. Jobs are only encoded if they differ from
+// the copy kept when they were last saved.
+func buildBatch(saved *savedState, s StateFile) StateBatch {
+	var b StateBatch
+	b.Results = map[string][][]string{}
+
+	order := make([]string, 0, len(s.Stack))
+	current := map[string]bool{}
+	for _, j := range s.Stack {
+		order = append(order, j.UUID)
+		current[j.UUID] = true
+
+		if prev, ok := saved.seen[j.UUID]; ok && sameJob(j, prev) {
+			continue
+		}
+		seen := cloneJob(j)
+
+		// Tools can rewrite rows as well as add them, so compare all of them
+		if h := rowsHash(j.OutputData); h != saved.results[j.UUID] {
+			b.Results[j.UUID] = j.OutputData
+			saved.results[j.UUID] = h
+		}
+
+		j.OutputData = nil
+		data, err := json.Marshal(j)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"job":   j.UUID,
+				"error": err.Error(),
+			}).Error("Unable to encode job for the state store.")
+			continue
+		}
+		saved.seen[j.UUID] = seen
+
+		if !bytes.Equal(data, saved.jobs[j.UUID]) {
+			b.Jobs = append(b.Jobs, j)
+			saved.jobs[j.UUID] = data
+		}
+	}
+
+	for uuid := range saved.jobs {
+		if !current[uuid] {
+			b.Deleted = append(b.Deleted, uuid)
+			delete(saved.jobs, uuid)
+			delete(saved.results, uuid)
+			delete(saved.seen, uuid)
+		}
+	}
+
+	if o := strings.Join(order, ","); o != saved.order {
+		b.Order = order
+		saved.order = o
+	}
+
+	b.Pool = changedJSON(&saved.pool, s.Pool)
+	b.Pipelines = changedJSON(&saved.pipelines, s.Pipelines)
+	b.Schedules = changedJSON(&saved.schedules, s.Schedules)
+	b.Templates = changedJSON(&saved.templates, s.Templates)
+	b.Speeds = changedJSON(&saved.speeds, s.Speeds)
+	if s.Stats != nil {
+		b.Stats = changedJSON(&saved.stats, s.Stats)
+	}
+
+	return b
+}
+
+// rowsHash returns a hash of the output rows of a job. Each row and cell is
+// written with its length so different rows cannot give the same input.
+func rowsHash(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, row := range rows {
+		fmt.Fprintf(h, "%d|", len(row))
+		for _, cell := range row {
+			fmt.Fprintf(h, "%d:%s", len(cell), cell)
+		}
+	}
+
+	return string(h.Sum(nil))
+}
+
+// changedJSON encodes v and returns it if it differs from last, which is updated
+func changedJSON(last *[]byte, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil || bytes.Equal(data, *last) {
+		return nil
+	}
+
+	*last = data
+	return data
+}
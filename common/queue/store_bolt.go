@@ -0,0 +1,270 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltJobs    = []byte("jobs")
+	boltResults = []byte("results")
+	boltMeta    = []byte("meta")
+	boltArchive = []byte("archive")
+
+	boltOrder     = []byte("order")
+	boltPool      = []byte("pool")
+	boltPipelines = []byte("pipelines")
+	boltSchedules = []byte("schedules")
+	boltTemplates = []byte("templates")
+	boltSpeeds    = []byte("speeds")
+	boltStats     = []byte("stats")
+)
+
+// boltStore keeps the state of the Queue in an embedded bolt database. Every
+// commit is a single bolt transaction.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates a bolt database to keep the Queue's state in
+func OpenBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltJobs, boltResults, boltMeta, boltArchive} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Load() (StateFile, error) {
+	var state StateFile
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(boltJobs)
+		results := tx.Bucket(boltResults)
+		meta := tx.Bucket(boltMeta)
+
+		var order []string
+		if err := unmarshalIfSet(meta.Get(boltOrder), &order); err != nil {
+			return err
+		}
+
+		for _, uuid := range order {
+			data := jobs.Get([]byte(uuid))
+			if data == nil {
+				continue
+			}
+
+			var j common.Job
+			if err := json.Unmarshal(data, &j); err != nil {
+				return err
+			}
+			if err := unmarshalIfSet(results.Get([]byte(uuid)), &j.OutputData); err != nil {
+				return err
+			}
+
+			state.Stack = append(state.Stack, j)
+		}
+
+		if err := unmarshalIfSet(meta.Get(boltPool), &state.Pool); err != nil {
+			return err
+		}
+		if err := unmarshalIfSet(meta.Get(boltPipelines), &state.Pipelines); err != nil {
+			return err
+		}
+		if err := unmarshalIfSet(meta.Get(boltSchedules), &state.Schedules); err != nil {
+			return err
+		}
+		if err := unmarshalIfSet(meta.Get(boltTemplates), &state.Templates); err != nil {
+			return err
+		}
+		if err := unmarshalIfSet(meta.Get(boltSpeeds), &state.Speeds); err != nil {
+			return err
+		}
+		return unmarshalIfSet(meta.Get(boltStats), &state.Stats)
+	})
+
+	return state, err
+}
+
+func (s *boltStore) Commit(b StateBatch) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(boltJobs)
+		results := tx.Bucket(boltResults)
+		meta := tx.Bucket(boltMeta)
+
+		for _, j := range b.Jobs {
+			data, err := json.Marshal(j)
+			if err != nil {
+				return err
+			}
+			if err := jobs.Put([]byte(j.UUID), data); err != nil {
+				return err
+			}
+		}
+
+		for uuid, rows := range b.Results {
+			data, err := json.Marshal(rows)
+			if err != nil {
+				return err
+			}
+			if err := results.Put([]byte(uuid), data); err != nil {
+				return err
+			}
+		}
+
+		for _, uuid := range b.Deleted {
+			if err := jobs.Delete([]byte(uuid)); err != nil {
+				return err
+			}
+			if err := results.Delete([]byte(uuid)); err != nil {
+				return err
+			}
+		}
+
+		if b.Order != nil {
+			data, err := json.Marshal(b.Order)
+			if err != nil {
+				return err
+			}
+			if err := meta.Put(boltOrder, data); err != nil {
+				return err
+			}
+		}
+
+		for key, data := range map[string][]byte{
+			string(boltPool):      b.Pool,
+			string(boltPipelines): b.Pipelines,
+			string(boltSchedules): b.Schedules,
+			string(boltTemplates): b.Templates,
+			string(boltSpeeds):    b.Speeds,
+			string(boltStats):     b.Stats,
+		} {
+			if data == nil {
+				continue
+			}
+			if err := meta.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+// archiveKey orders the archive by when jobs were archived
+func archiveKey(archived time.Time, uuid string) []byte {
+	key := make([]byte, 8, 8+len(uuid))
+	binary.BigEndian.PutUint64(key, uint64(archived.UnixNano()))
+	return append(key, uuid...)
+}
+
+func (s *boltStore) Archive(jobs []ArchivedJob) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		archive := tx.Bucket(boltArchive)
+
+		for _, a := range jobs {
+			data, err := json.Marshal(a)
+			if err != nil {
+				return err
+			}
+			if err := archive.Put(archiveKey(a.Archived, a.UUID), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltStore) SearchArchive(f ArchiveFilter) ([]ArchivedJob, error) {
+	var found []ArchivedJob
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltArchive).Cursor()
+
+		// Newest first
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var a ArchivedJob
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+
+			if !f.Match(a) {
+				continue
+			}
+
+			found = append(found, a)
+			if f.Limit > 0 && len(found) >= f.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (s *boltStore) ExpireArchive(before time.Time) (int, error) {
+	end := archiveKey(before, "")
+
+	// Find the expired jobs first so nothing is written if there are none
+	var expired [][]byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltArchive).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil || len(expired) == 0 {
+		return 0, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		archive := tx.Bucket(boltArchive)
+		for _, k := range expired {
+			if err := archive.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(expired), nil
+}
+
+// unmarshalIfSet decodes JSON from the store if there was any
+func unmarshalIfSet(data []byte, v interface{}) error {
+	if data == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestBoltStoreIncremental(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cracklord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenBoltStore(filepath.Join(dir, "queue.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	a := common.NewJob("tool1", "a", "tester", map[string]string{})
+	b := common.NewJob("tool1", "b", "tester", map[string]string{})
+	a.OutputData = [][]string{{"hash1", "password"}}
+
+	saved := newSavedState()
+	s := StateFile{Stack: []common.Job{a, b}, Pool: NewResourcePool()}
+	if err := store.Commit(buildBatch(&saved, s)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed so nothing is written
+	if batch := buildBatch(&saved, s); !batch.Empty() {
+		t.Errorf("Expected an empty batch but got %+v", batch)
+	}
+
+	// Only the changed job is written and the removed one deleted
+	b.Progress = 50
+	s.Stack = []common.Job{b}
+	batch := buildBatch(&saved, s)
+	if len(batch.Jobs) != 1 || batch.Jobs[0].UUID != b.UUID || len(batch.Deleted) != 1 || len(batch.Results) != 0 {
+		t.Errorf("Unexpected batch %+v", batch)
+	}
+	if err := store.Commit(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Stack) != 1 || loaded.Stack[0].UUID != b.UUID || loaded.Stack[0].Progress != 50 {
+		t.Errorf("Unexpected jobs loaded %+v", loaded.Stack)
+	}
+}
+
+func TestMigrateStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cracklord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j := common.NewJob("tool1", "old", "tester", map[string]string{})
+	j.OutputData = [][]string{{"hash1", "password"}}
+
+	path := filepath.Join(dir, "queue.state")
+	data, _ := json.Marshal(StateFile{Stack: []common.Job{j}})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenBoltStore(filepath.Join(dir, "queue.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, err := migrateStateFile(store, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".migrated"); err != nil {
+		t.Error("Expected the state file to be renamed once imported")
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Stack) != 1 || loaded.Stack[0].UUID != j.UUID || len(loaded.Stack[0].OutputData) != 1 {
+		t.Errorf("Unexpected jobs loaded %+v", loaded.Stack)
+	}
+}
+
+func TestBuildBatchRewrittenResults(t *testing.T) {
+	a := common.NewJob("tool1", "a", "tester", map[string]string{})
+	a.OutputData = [][]string{{"hash1", ""}}
+
+	saved := newSavedState()
+	buildBatch(&saved, StateFile{Stack: []common.Job{a}})
+
+	// A row filled in without adding one is still written
+	a.OutputData = [][]string{{"hash1", "password"}}
+	batch := buildBatch(&saved, StateFile{Stack: []common.Job{a}})
+	if rows, ok := batch.Results[a.UUID]; !ok || rows[0][1] != "password" {
+		t.Errorf("Expected the rewritten results to be saved but got %+v", batch.Results)
+	}
+}
+
+func TestBuildBatchChangedInPlace(t *testing.T) {
+	a := common.NewJob("tool1", "a", "tester", map[string]string{"hashmode": "0"})
+	a.Attempts = []common.Attempt{{ResourceUUID: "res1"}}
+	s := StateFile{Stack: []common.Job{a}}
+
+	saved := newSavedState()
+	buildBatch(&saved, s)
+
+	// Changes made to the maps and slices of the job itself are still saved
+	a.Parameters["hashmode"] = "1000"
+	a.Attempts[0].Error = "failed"
+	batch := buildBatch(&saved, s)
+	if len(batch.Jobs) != 1 || batch.Jobs[0].Parameters["hashmode"] != "1000" || batch.Jobs[0].Attempts[0].Error != "failed" {
+		t.Errorf("Expected the changed job to be saved but got %+v", batch.Jobs)
+	}
+
+	if batch = buildBatch(&saved, s); !batch.Empty() {
+		t.Errorf("Expected an empty batch but got %+v", batch)
+	}
+}
@@ -0,0 +1,274 @@
+package queue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/pborman/uuid"
+)
+
+// A Template is a saved set of job parameters for a tool. Templates are tied to
+// the name and version of the tool rather than its UUID, which changes every
+// time a resource connects, so they can be used again later. Shared templates
+// can be used by every user, others only by their owner.
+type Template struct {
+	UUID        string            `json:"uuid"`
+	Name        string            `json:"name"`
+	Owner       string            `json:"owner"`
+	Shared      bool              `json:"shared"`
+	ToolName    string            `json:"toolname"`
+	ToolVersion string            `json:"toolversion"`
+	ToolParams  string            `json:"toolparams"` // Hash of the tool's parameter form when the template was saved
+	JobName     string            `json:"jobname"`    // Pattern for job names (See templateJobName)
+	Parameters  map[string]string `json:"parameters"`
+	Created     time.Time         `json:"created"`
+	Updated     time.Time         `json:"updated"`
+}
+
+// TemplateStatus describes whether a template still matches its tool
+type TemplateStatus struct {
+	ToolUUID string // UUID of a tool the template can be used with, empty if none is connected
+	Stale    bool   // The tool has changed since the template was saved
+	Reason   string
+}
+
+// hashParams returns a short hash of a tool's parameter form to see if it changes
+func hashParams(params string) string {
+	sum := sha256.Sum256([]byte(params))
+	return hex.EncodeToString(sum[:8])
+}
+
+// templateJobName fills in the placeholders of a job name pattern. {template},
+// {owner}, {date} and {time} are replaced, and an empty pattern uses the name
+// of the template.
+func templateJobName(t Template, owner string, now time.Time) string {
+	pattern := t.JobName
+	if pattern == "" {
+		pattern = "{template}"
+	}
+
+	return strings.NewReplacer(
+		"{template}", t.Name,
+		"{owner}", owner,
+		"{date}", now.Format("2006-01-02"),
+		"{time}", now.Format("15:04"),
+	).Replace(pattern)
+}
+
+// bindTool ties a template to the tool with the UUID given
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) bindTool(t *Template, toolUUID string) error {
+	tool, ok := q.findTool(toolUUID)
+	if !ok {
+		return errors.New("Tool does not exist!")
+	}
+
+	t.ToolName = tool.Name
+	t.ToolVersion = tool.Version
+	t.ToolParams = hashParams(tool.Parameters)
+
+	return nil
+}
+
+// findToolByName finds a connected tool by its name, picking one with the same
+// version over any other. The UUID of the tool returned is the Queue's.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) findToolByName(name, version string) (common.Tool, bool) {
+	var found *common.Tool
+	for _, res := range q.pool {
+		if res.Status == common.STATUS_QUIT {
+			continue
+		}
+
+		for id := range res.Tools {
+			tool := res.Tools[id]
+			if !strings.EqualFold(tool.Name, name) {
+				continue
+			}
+
+			if found == nil || (tool.Version == version && found.Version != version) {
+				tool.UUID = id
+				found = &tool
+			}
+		}
+	}
+
+	if found == nil {
+		return common.Tool{}, false
+	}
+
+	return *found, true
+}
+
+// templateStatus finds a tool for a template and checks whether it has changed.
+// A tool with the same name and version is picked over any other.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) templateStatus(t Template) TemplateStatus {
+	found, ok := q.findToolByName(t.ToolName, t.ToolVersion)
+	if !ok {
+		return TemplateStatus{Reason: "No connected resource has the " + t.ToolName + " tool."}
+	}
+
+	status := TemplateStatus{ToolUUID: found.UUID}
+	if found.Version != t.ToolVersion {
+		status.Stale = true
+		status.Reason = "Tool version changed from " + t.ToolVersion + " to " + found.Version + "."
+	} else if hashParams(found.Parameters) != t.ToolParams {
+		status.Stale = true
+		status.Reason = "Tool parameters have changed since the template was saved."
+	}
+
+	return status
+}
+
+// AddTemplate stores a new template for the tool with the UUID given
+func (q *Queue) AddTemplate(t Template, toolUUID string) (Template, error) {
+	if t.Name == "" {
+		return Template{}, errors.New("A name must be provided for a template.")
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if err := q.bindTool(&t, toolUUID); err != nil {
+		return Template{}, err
+	}
+
+	t.UUID = uuid.New()
+	t.Created = time.Now()
+	t.Updated = t.Created
+
+	if q.templates == nil {
+		q.templates = map[string]Template{}
+	}
+	q.templates[t.UUID] = t
+
+	log.WithFields(log.Fields{
+		"template": t.UUID,
+		"tool":     t.ToolName,
+		"version":  t.ToolVersion,
+	}).Info("Template created.")
+
+	return t, nil
+}
+
+// UpdateTemplate replaces an existing template. If a tool UUID is given the
+// template is tied to that tool again, which clears it being stale.
+func (q *Queue) UpdateTemplate(t Template, toolUUID string) (Template, error) {
+	if t.Name == "" {
+		return Template{}, errors.New("A name must be provided for a template.")
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	old, ok := q.templates[t.UUID]
+	if !ok {
+		return Template{}, errors.New("Template does not exist!")
+	}
+
+	t.Owner = old.Owner
+	t.Created = old.Created
+	t.Updated = time.Now()
+
+	if toolUUID != "" {
+		if err := q.bindTool(&t, toolUUID); err != nil {
+			return Template{}, err
+		}
+	} else {
+		t.ToolName = old.ToolName
+		t.ToolVersion = old.ToolVersion
+		t.ToolParams = old.ToolParams
+	}
+
+	q.templates[t.UUID] = t
+
+	log.WithField("template", t.UUID).Info("Template updated.")
+
+	return t, nil
+}
+
+// RemoveTemplate deletes a template. Jobs created from it are left alone.
+func (q *Queue) RemoveTemplate(templateuuid string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if _, ok := q.templates[templateuuid]; !ok {
+		return errors.New("Template does not exist!")
+	}
+
+	delete(q.templates, templateuuid)
+
+	log.WithField("template", templateuuid).Info("Template removed.")
+
+	return nil
+}
+
+// AllTemplates returns every template in the queue with whether each still
+// matches its tool, keyed by template UUID
+func (q *Queue) AllTemplates() ([]Template, map[string]TemplateStatus) {
+	q.RLock()
+	defer q.RUnlock()
+
+	templates := make([]Template, 0, len(q.templates))
+	status := make(map[string]TemplateStatus, len(q.templates))
+	for _, t := range q.templates {
+		templates = append(templates, t)
+		status[t.UUID] = q.templateStatus(t)
+	}
+
+	return templates, status
+}
+
+// TemplateInfo returns the template with the UUID given and whether it still
+// matches its tool
+func (q *Queue) TemplateInfo(templateuuid string) (Template, TemplateStatus, bool) {
+	q.RLock()
+	defer q.RUnlock()
+
+	t, ok := q.templates[templateuuid]
+	if !ok {
+		return Template{}, TemplateStatus{}, false
+	}
+
+	return t, q.templateStatus(t), true
+}
+
+// JobFromTemplate builds a new job for the owner given from a template, with
+// overrides replacing any of its parameters. An empty name uses the template's
+// job name pattern. Templates whose tool has changed are still used, the
+// parameters are checked by the tool when the job is added. The new job still
+// has to be added with AddJob.
+func (q *Queue) JobFromTemplate(templateuuid, owner, name string, overrides map[string]string) (common.Job, error) {
+	t, status, ok := q.TemplateInfo(templateuuid)
+	if !ok {
+		return common.Job{}, errors.New("Template does not exist!")
+	}
+
+	if status.ToolUUID == "" {
+		return common.Job{}, errors.New(status.Reason)
+	}
+
+	params := make(map[string]string, len(t.Parameters)+len(overrides))
+	for k, v := range t.Parameters {
+		params[k] = v
+	}
+	for k, v := range overrides {
+		params[k] = v
+	}
+
+	if name == "" {
+		name = templateJobName(t, owner, time.Now())
+	}
+
+	// Benchmarks are only made by Benchmark, never from saved parameters
+	j := common.NewJob(status.ToolUUID, name, owner, params)
+	j.Benchmark = false
+
+	return j, nil
+}
@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestTemplates(t *testing.T) {
+	tool := common.Tool{Name: "Hashcat", Version: "3.0", UUID: "tool1", Parameters: `{"form":1}`}
+	q := Queue{pool: ResourcePool{
+		"res1": {Status: common.STATUS_RUNNING, Tools: map[string]common.Tool{"tool1": tool}},
+	}}
+
+	tmpl, err := q.AddTemplate(Template{
+		Name:       "NTLM brute",
+		Owner:      "alice",
+		JobName:    "{template} for {owner}",
+		Parameters: map[string]string{"mode": "1000", "mask": "?a?a"},
+	}, "tool1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.ToolName != "Hashcat" || tmpl.ToolVersion != "3.0" || tmpl.ToolParams == "" {
+		t.Errorf("Expected the template to be tied to the tool but got %+v", tmpl)
+	}
+
+	j, err := q.JobFromTemplate(tmpl.UUID, "bob", "", map[string]string{"mask": "?d?d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Name != "NTLM brute for bob" || j.Owner != "bob" || j.ToolUUID != "tool1" {
+		t.Errorf("Unexpected job %+v", j)
+	}
+	if j.Parameters["mode"] != "1000" || j.Parameters["mask"] != "?d?d" {
+		t.Errorf("Unexpected job parameters %v", j.Parameters)
+	}
+
+	// A reconnected resource gives the tool a new UUID and a changed form
+	tool.UUID = "tool2"
+	tool.Parameters = `{"form":2}`
+	q.pool["res1"] = Resource{Status: common.STATUS_RUNNING, Tools: map[string]common.Tool{"tool2": tool}}
+
+	_, status, _ := q.TemplateInfo(tmpl.UUID)
+	if !status.Stale || status.ToolUUID != "tool2" {
+		t.Errorf("Expected the template to be stale on tool2 but got %+v", status)
+	}
+
+	// Saving the template against the new tool clears it
+	if _, err := q.UpdateTemplate(tmpl, "tool2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, status, _ = q.TemplateInfo(tmpl.UUID); status.Stale {
+		t.Errorf("Expected the template to match again but got %s", status.Reason)
+	}
+
+	delete(q.pool, "res1")
+	if _, err := q.JobFromTemplate(tmpl.UUID, "bob", "", nil); err == nil {
+		t.Error("Expected a template without a connected tool to fail")
+	}
+
+	if err := q.RemoveTemplate(tmpl.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := q.TemplateInfo(tmpl.UUID); ok {
+		t.Error("Expected the template to be removed")
+	}
+}
+
+func TestTemplateJobName(t *testing.T) {
+	now := time.Date(2016, 5, 4, 13, 30, 0, 0, time.UTC)
+
+	name := templateJobName(Template{Name: "Daily", JobName: "{template} {date} {time}"}, "alice", now)
+	if name != "Daily 2016-05-04 13:30" {
+		t.Errorf("Unexpected job name %q", name)
+	}
+
+	if name := templateJobName(Template{Name: "Daily"}, "alice", now); name != "Daily" {
+		t.Errorf("Expected the template name for an empty pattern but got %q", name)
+	}
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"errors"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// A ValidationError is returned when the parameters of a job do not match the
+// schema of its tool
+type ValidationError struct {
+	Fields []common.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+
+	return "Invalid parameters: " + strings.Join(msgs, " ")
+}
+
+// validateJob checks the parameters of a job against the schema of its tool.
+// Jobs for tools the queue does not know, or whose schema can not be read, are
+// left for the tool to refuse, as are benchmarks which have no attack to check.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) validateJob(j common.Job) error {
+	tool, ok := q.findTool(j.ToolUUID)
+	if !ok || common.IsBenchmark(j) {
+		return nil
+	}
+
+	// Jobs fed by another job get their hashes when it finishes (See checkDependencies)
+	var skip []string
+	if j.FeedFrom != "" {
+		skip = []string{common.PARAM_HASHES, common.PARAM_HASHES_MULTI, common.PARAM_HASHES_UPLOAD}
+	}
+
+	fields, err := common.ValidateParameters(tool.Parameters, j.Parameters, skip...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"tool":  tool.Name,
+			"error": err.Error(),
+		}).Warn("Unable to read the parameter schema of a tool, parameters were not checked.")
+		return nil
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+// ValidateJob checks a job the way AddJob would without adding it, and also
+// refuses jobs for tools that are not available.
+func (q *Queue) ValidateJob(j common.Job) error {
+	q.RLock()
+	defer q.RUnlock()
+
+	if _, ok := q.findTool(j.ToolUUID); !ok {
+		return errors.New("Tool does not exist!")
+	}
+
+	return q.validateJob(j)
+}
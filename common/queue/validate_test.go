@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestValidateJob(t *testing.T) {
+	tool := common.Tool{
+		Name:       "Hashcat",
+		UUID:       "tool1",
+		Parameters: `{"form": [], "schema": {"properties": {"hashmode": {"type": "integer"}}, "required": ["hashmode", "hashes_multiline"]}}`,
+	}
+	q := Queue{pool: ResourcePool{
+		"res1": {Status: common.STATUS_RUNNING, Tools: map[string]common.Tool{"tool1": tool}},
+	}}
+
+	j := common.NewJob("tool1", "NTLM", "alice", map[string]string{"hashmode": "NTLM"})
+	err, ok := q.ValidateJob(j).(*ValidationError)
+	if !ok || len(err.Fields) != 2 {
+		t.Fatalf("Expected two field errors but got %v", err)
+	}
+
+	// Hashes of a fed job come from the job feeding it
+	j.Parameters["hashmode"] = "1000"
+	j.FeedFrom = "other"
+	if err := q.ValidateJob(j); err != nil {
+		t.Errorf("Expected a fed job without hashes to be valid but got %v", err)
+	}
+
+	// Only the Queue can make a benchmark, which is not checked
+	j = common.NewJob("tool1", "NTLM", "alice", map[string]string{"benchmark": "true"})
+	if err := q.ValidateJob(j); err == nil {
+		t.Error("Expected a benchmark parameter not to skip validation")
+	}
+	j.Benchmark = true
+	if err := q.ValidateJob(j); err != nil {
+		t.Errorf("Expected a benchmark job not to be checked but got %v", err)
+	}
+
+	if err := q.ValidateJob(common.NewJob("missing", "NTLM", "alice", nil)); err == nil {
+		t.Error("Expected a job for a missing tool to fail")
+	}
+}
@@ -0,0 +1,503 @@
+package queue
+
+import (
+	"net/rpc"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// KeyspaceTimeout is how long a resource is given to work out the keyspace of a
+// job. This can take a while for large wordlists and no lock is held meanwhile.
+var KeyspaceTimeout = 2 * time.Minute
+
+// A taskCall is a task RPC call for a resource worker to make
+type taskCall struct {
+	ResKey string
+	Method string
+	Job    common.Job
+	Tool   common.Tool // Tool the job is using on the resource, for the hardware
+}
+
+// A taskResult is the answer to a taskCall
+type taskResult struct {
+	taskCall
+	Task     common.Job
+	Keyspace int64 // Reply to Queue.TaskKeyspace calls
+	Err      error
+}
+
+// A resourceWorker makes the task RPC calls for one resource, one at a time and
+// in the order they were queued, so a slow resource only holds up its own jobs.
+// Queuing a call never waits on the resource, so it can be done while the lock
+// is held. Each resource has one worker for as long as it is connected.
+type resourceWorker struct {
+	client *rpc.Client
+	wake   chan bool // Signalled when calls are queued or the worker is stopped
+	done   chan bool // Closed once the worker has stopped
+
+	mu      sync.Mutex
+	calls   []taskCall
+	stopped bool
+}
+
+// worker returns the worker for a resource, starting one if it has none yet
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) worker(resKey string) *resourceWorker {
+	if w, ok := q.workers[resKey]; ok {
+		return w
+	}
+
+	if q.workers == nil {
+		q.workers = map[string]*resourceWorker{}
+	}
+
+	w := &resourceWorker{
+		client: q.pool[resKey].Client,
+		wake:   make(chan bool, 1),
+		done:   make(chan bool),
+	}
+	q.workers[resKey] = w
+	go w.run(q)
+
+	return w
+}
+
+// stopWorker stops the worker of a resource once it has made the calls already
+// queued for it, after which the connection to the resource is closed. The
+// channel returned is closed once that is done.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) stopWorker(resKey string) chan bool {
+	// Calls set up for the resource are handed over first so they are still made
+	q.runDispatches()
+
+	w := q.worker(resKey)
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+	w.signal()
+
+	return w.done
+}
+
+// add queues a call for the worker to make. False is returned if the worker
+// has been stopped.
+func (w *resourceWorker) add(c taskCall) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return false
+	}
+
+	w.calls = append(w.calls, c)
+	w.signal()
+
+	return true
+}
+
+// signal wakes the worker up without waiting on it
+func (w *resourceWorker) signal() {
+	select {
+	case w.wake <- true:
+	default:
+	}
+}
+
+// next waits for the next call to make. False is returned once the worker has
+// been stopped and every call queued has been made.
+func (w *resourceWorker) next() (taskCall, bool) {
+	for {
+		w.mu.Lock()
+		if len(w.calls) > 0 {
+			c := w.calls[0]
+			w.calls = w.calls[1:]
+			w.mu.Unlock()
+			return c, true
+		}
+		stopped := w.stopped
+		w.mu.Unlock()
+
+		if stopped {
+			return taskCall{}, false
+		}
+		<-w.wake
+	}
+}
+
+// drain takes every call still waiting to be made
+func (w *resourceWorker) drain() []taskCall {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	calls := w.calls
+	w.calls = nil
+
+	return calls
+}
+
+// run makes the calls queued for the resource and applies each result to the
+// Queue until the worker is stopped, then closes the connection.
+// NO LOCK SHOULD BE HELD TO CALL THIS FUNCTION.
+func (w *resourceWorker) run(q *Queue) {
+	defer close(w.done)
+
+	for {
+		c, ok := w.next()
+		if !ok {
+			break
+		}
+
+		results := []taskResult{w.call(c)}
+
+		// A resource that timed out is unlikely to answer the calls queued
+		// behind it either, so they are failed rather than left to wait. Keyspace
+		// calls are slow anyway and have a timeout of their own.
+		if results[0].Err == ErrTimeout && c.Method != "Queue.TaskKeyspace" {
+			for _, c := range w.drain() {
+				results = append(results, taskResult{taskCall: c, Err: ErrTimeout})
+			}
+		}
+
+		q.Lock()
+		for _, r := range results {
+			q.applyResult(r)
+		}
+		q.Unlock()
+	}
+
+	if w.client != nil {
+		w.client.Close()
+		forgetClient(w.client)
+	}
+}
+
+// call makes a single call on the resource
+// NO LOCK SHOULD BE HELD TO CALL THIS FUNCTION.
+func (w *resourceWorker) call(c taskCall) taskResult {
+	r := taskResult{taskCall: c}
+
+	// A timed out call may still write its reply later, so each call gets its own
+	if c.Method == "Queue.TaskKeyspace" {
+		var keyspace int64
+		r.Err = callWithin(w.client, c.Method, common.RPCCall{Job: c.Job}, &keyspace, KeyspaceTimeout)
+		if r.Err == nil {
+			r.Keyspace = keyspace
+		}
+		return r
+	}
+
+	var task common.Job
+	r.Err = callTimeout(w.client, c.Method, common.RPCCall{Job: c.Job}, &task)
+	if r.Err == nil {
+		r.Task = task
+	}
+
+	return r
+}
+
+// runDispatches hands the calls set up while the lock was held to the workers
+// of their resources. It is called every time the write lock is released (See
+// Unlock). Calls for a resource whose worker has been stopped fail as if the
+// resource was not connected.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) runDispatches() {
+	calls := q.dispatches
+	q.dispatches = nil
+
+	for _, c := range calls {
+		if q.worker(c.ResKey).add(c) {
+			continue
+		}
+
+		// The result is applied once the lock has been released
+		go func(r taskResult) {
+			q.Lock()
+			q.applyResult(r)
+			q.Unlock()
+		}(taskResult{taskCall: c, Err: ErrNotConnected})
+	}
+}
+
+// applyResult updates the Queue with the answer to a call made by a worker
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) applyResult(r taskResult) {
+	switch r.Method {
+	case "Queue.TaskStatus":
+		q.applyPoll(r)
+	case "Queue.TaskKeyspace":
+		q.applyKeyspace(r)
+	default:
+		q.applyDispatch(r)
+	}
+}
+
+// pollJobs sets up a status call for every job running on a resource. The
+// answers are kept until the next run of the keeper merges them into the stack
+// (See takePolled). Jobs still waiting on an answer are not asked again.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) pollJobs() {
+	if q.polling == nil {
+		q.polling = map[string]bool{}
+	}
+
+	for i := range q.stack {
+		j := q.stack[i]
+
+		// Chunked jobs follow their chunks and restored jobs their resource reconnecting
+		if j.Status != common.STATUS_RUNNING || j.Chunked || q.restored[j.UUID] || q.starting[j.UUID] || q.polling[j.UUID] {
+			continue
+		}
+
+		// Jobs on a removed resource are waiting on their quit call (See RemoveResource)
+		if q.pool[j.ResAssigned].Status == common.STATUS_QUIT {
+			continue
+		}
+
+		q.polling[j.UUID] = true
+		q.dispatches = append(q.dispatches, taskCall{
+			ResKey: j.ResAssigned,
+			Method: "Queue.TaskStatus",
+			Job:    cloneJob(j),
+		})
+	}
+}
+
+// applyPoll keeps the status of a job a worker has polled for updateQueue. The
+// answer is dropped if the job has been stopped or restarted since the call
+// was set up.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) applyPoll(r taskResult) {
+	delete(q.polling, r.Job.UUID)
+
+	i := q.jobIndex(r.Job.UUID)
+	if i == -1 || q.stack[i].Status != common.STATUS_RUNNING || q.stack[i].ResAssigned != r.ResKey || q.starting[r.Job.UUID] {
+		return
+	}
+
+	if q.polled == nil {
+		q.polled = map[string]taskResult{}
+	}
+	q.polled[r.Job.UUID] = r
+}
+
+// takePolled returns the job statuses polled since it was last called
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) takePolled() map[string]taskResult {
+	polled := q.polled
+	q.polled = nil
+
+	return polled
+}
+
+// dispatch sets up a call to start or resume the job at index jobKey on a
+// resource. The hardware must already be taken. The call is made by a worker
+// once the lock is released (See runDispatches).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) dispatch(jobKey int, resKey, method string, tool common.Tool) {
+	if q.starting == nil {
+		q.starting = map[string]bool{}
+	}
+
+	q.starting[q.stack[jobKey].UUID] = true
+	delete(q.polled, q.stack[jobKey].UUID)
+	q.dispatches = append(q.dispatches, taskCall{
+		ResKey: resKey,
+		Method: method,
+		Job:    cloneJob(q.stack[jobKey]),
+		Tool:   tool,
+	})
+}
+
+// stopTask sets up a call to pause or quit the job at index i on its resource.
+// The job's status and hardware must already be updated. The call is made by a
+// worker once the lock is released, after any calls already set up for the
+// resource so it cannot overtake a start it is undoing.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) stopTask(i int, method string) {
+	delete(q.polled, q.stack[i].UUID)
+	q.dispatches = append(q.dispatches, taskCall{
+		ResKey: q.stack[i].ResAssigned,
+		Method: method,
+		Job:    cloneJob(q.stack[i]),
+	})
+}
+
+// applyDispatch updates a job that has been started, resumed or stopped by a worker
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) applyDispatch(r taskResult) {
+	if r.Method == "Queue.TaskPause" || r.Method == "Queue.TaskQuit" {
+		q.applyStop(r)
+		return
+	}
+
+	delete(q.starting, r.Job.UUID)
+
+	logger := log.WithFields(log.Fields{
+		"job":      r.Job.UUID,
+		"resource": r.ResKey,
+		"method":   r.Method,
+	})
+
+	// Make sure nothing happened to the job while it was being started
+	expect := common.STATUS_CREATED
+	if r.Method == "Queue.TaskRun" {
+		expect = common.STATUS_PAUSED
+	}
+
+	i := q.jobIndex(r.Job.UUID)
+	wanted := i != -1 && q.stack[i].ResAssigned == r.ResKey && q.stack[i].Status == expect
+
+	if !wanted {
+		logger.Info("Job changed while it was being started, stopping it.")
+
+		if r.Err == nil {
+			q.dispatches = append(q.dispatches, taskCall{ResKey: r.ResKey, Method: "Queue.TaskQuit", Job: r.Task})
+		}
+
+		if res, ok := q.pool[r.ResKey]; ok {
+			res.freeHardware(r.Tool)
+		}
+		return
+	}
+
+	if r.Err != nil {
+		// Something failed so let the job try again, possibly on another resource
+		logger.WithField("error", r.Err.Error()).Error("Error while attempting to start job on remote resource.")
+		q.recordEvent(i, common.EVENT_ERROR, r.ResKey, "", "Unable to start job: "+r.Err.Error())
+		q.releaseHardware(i)
+		q.retryJob(i, r.Err.Error())
+		return
+	}
+
+	mergeTaskStatus(&q.stack[i], r.Task)
+
+	if r.Method == "Queue.TaskRun" {
+		q.recordEvent(i, common.EVENT_RESUMED, r.ResKey, "", "")
+	} else {
+		q.recordEvent(i, common.EVENT_STARTED, r.ResKey, "", "")
+	}
+
+	// Call out to our registered hooks to note job has started
+	if r.Method == "Queue.AddTask" && q.stack[i].ParentUUID == "" {
+		go HookOnJobStart(Hooks.JobStart, cloneJob(q.stack[i]))
+	}
+}
+
+// applyStop updates a job that has been paused or quit by a worker. The job was
+// already given its new status when the call was set up (See stopTask).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) applyStop(r taskResult) {
+	i := q.jobIndex(r.Job.UUID)
+	if i == -1 || q.stack[i].ResAssigned != r.ResKey {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{
+		"job":      r.Job.UUID,
+		"resource": r.ResKey,
+		"method":   r.Method,
+	})
+
+	// Jobs still running on a removed resource are given to the remaining
+	// resources, unless the quit shows they finished first (See RemoveResource)
+	if q.pool[r.ResKey].Status == common.STATUS_QUIT && !common.IsDone(q.stack[i].Status) {
+		if r.Err == nil {
+			mergeTaskStatus(&q.stack[i], r.Task)
+		}
+		if q.stack[i].Status != common.STATUS_DONE {
+			q.retryJob(i, "Resource was removed.")
+		}
+		return
+	}
+
+	if r.Err != nil {
+		logger.WithField("error", r.Err.Error()).Error("An error occurred while trying to stop a remote job.")
+		q.recordEvent(i, common.EVENT_ERROR, r.ResKey, "", "Unable to stop job: "+r.Err.Error())
+
+		// Chunks are stopped through the job they were split from, so the
+		// error is shown there as well
+		if parent := q.stack[i].ParentUUID; parent != "" {
+			if p := q.jobIndex(parent); p != -1 {
+				q.recordEvent(p, common.EVENT_ERROR, r.ResKey, "", "Unable to stop chunk "+r.Job.UUID+": "+r.Err.Error())
+			}
+		}
+
+		// The resource quits a job it fails to pause, so start a paused job over
+		if r.Method == "Queue.TaskPause" && q.stack[i].Status == common.STATUS_PAUSED {
+			q.retryJob(i, "Unable to pause job: "+r.Err.Error())
+		}
+		return
+	}
+
+	// Keep the final output of the task but not its status, which may have
+	// changed since the call was set up
+	status, reason := q.stack[i].Status, q.stack[i].Error
+	mergeTaskStatus(&q.stack[i], r.Task)
+	q.stack[i].Status, q.stack[i].Error = status, reason
+}
+
+// fetchKeyspaces sets up a call for the keyspace of every job splitJobs would
+// like to split. The calls are slow so they are made by the worker of one of
+// the resources the job would be split between, and the job is held back from
+// starting until the answer is in (See splitJobs).
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) fetchKeyspaces() {
+	if q.fetching == nil {
+		q.fetching = map[string]bool{}
+	}
+
+	for i := range q.stack {
+		job := q.stack[i]
+		if !q.splitCandidate(job) || q.fetching[job.UUID] {
+			continue
+		}
+
+		if _, ok := q.keyspaces[job.UUID]; ok {
+			continue
+		}
+
+		resources := q.splitResources(job)
+		if len(resources) < 2 {
+			continue
+		}
+
+		// The resource only knows the tool by its own UUID (See AddJob)
+		job = cloneJob(job)
+		job.ToolUUID = q.pool[resources[0]].Tools[job.ToolUUID].UUID
+
+		q.fetching[job.UUID] = true
+		q.dispatches = append(q.dispatches, taskCall{
+			ResKey: resources[0],
+			Method: "Queue.TaskKeyspace",
+			Job:    job,
+		})
+	}
+}
+
+// applyKeyspace keeps the keyspace of a job fetched by a worker for splitJobs
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) applyKeyspace(r taskResult) {
+	delete(q.fetching, r.Job.UUID)
+
+	if r.Err != nil {
+		// The job can still run on a single resource so just don't try again
+		log.WithFields(log.Fields{
+			"job":   r.Job.UUID,
+			"error": r.Err.Error(),
+		}).Warn("Unable to get the keyspace of the job, it will not be split.")
+
+		if q.nosplit == nil {
+			q.nosplit = map[string]bool{}
+		}
+		q.nosplit[r.Job.UUID] = true
+		return
+	}
+
+	if q.keyspaces == nil {
+		q.keyspaces = map[string]int64{}
+	}
+	q.keyspaces[r.Job.UUID] = r.Keyspace
+}
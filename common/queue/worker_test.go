@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestCallWithinTimeout(t *testing.T) {
+	// A resource that reads requests and never answers them
+	local, remote := net.Pipe()
+	go io.Copy(ioutil.Discard, remote)
+	defer remote.Close()
+
+	client := rpc.NewClient(local)
+	defer client.Close()
+
+	var reply common.Job
+	start := time.Now()
+	err := callWithin(client, "Queue.TaskStatus", common.RPCCall{}, &reply, 50*time.Millisecond)
+	if err != ErrTimeout {
+		t.Fatalf("Expected ErrTimeout but got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Call took %v to time out", time.Since(start))
+	}
+}
+
+// waitPolled waits for the statuses of n jobs to be polled by the workers
+func waitPolled(q *Queue, n int) map[string]taskResult {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+		q.Lock()
+		if len(q.polled) == n {
+			polled := q.takePolled()
+			q.Unlock()
+			return polled
+		}
+		q.Unlock()
+	}
+
+	return nil
+}
+
+func TestWorkerNotConnected(t *testing.T) {
+	res := NewResource()
+	res.Status = common.STATUS_RUNNING
+
+	q := Queue{
+		pool: ResourcePool{"res1": res},
+		stack: []common.Job{
+			{UUID: "job1", ResAssigned: "res1", Status: common.STATUS_RUNNING},
+			{UUID: "job2", ResAssigned: "res1", Status: common.STATUS_RUNNING},
+		},
+	}
+
+	for _, stop := range []bool{false, true} {
+		// Calls for a stopped worker are answered without it
+		if stop {
+			q.Lock()
+			done := q.stopWorker("res1")
+			q.Unlock()
+			<-done
+		}
+
+		// The calls are made by the worker once the lock is released
+		q.Lock()
+		q.pollJobs()
+		q.Unlock()
+
+		polled := waitPolled(&q, 2)
+		if len(polled) != 2 {
+			t.Fatalf("Expected 2 statuses to be polled but got %d", len(polled))
+		}
+		for uuid, r := range polled {
+			if r.Err != ErrNotConnected {
+				t.Errorf("Expected job %s to fail with ErrNotConnected but got %v", uuid, r.Err)
+			}
+		}
+
+		// Every call goes through the one worker for the resource
+		if len(q.workers) != 1 {
+			t.Errorf("Expected 1 worker but %d were started", len(q.workers))
+		}
+	}
+}
+
+func TestQuitChunksQueuesCalls(t *testing.T) {
+	tool := common.Tool{UUID: "tool1", Requirements: common.RES_GPU, Units: 1}
+	res := NewResource()
+	res.Status = common.STATUS_RUNNING
+	res.Tools["tool1"] = tool
+	res.Capacity[common.RES_GPU] = 2
+	res.Hardware[common.RES_GPU] = 0
+
+	q := Queue{
+		pool: ResourcePool{"res1": res},
+		stack: []common.Job{
+			{UUID: "parent", Chunked: true, Status: common.STATUS_RUNNING},
+			{UUID: "running", ParentUUID: "parent", ToolUUID: "tool1", ResAssigned: "res1", Status: common.STATUS_RUNNING},
+			{UUID: "paused", ParentUUID: "parent", ToolUUID: "tool1", ResAssigned: "res1", Status: common.STATUS_PAUSED},
+			{UUID: "created", ParentUUID: "parent", ToolUUID: "tool1", Status: common.STATUS_CREATED},
+		},
+	}
+
+	q.quitChunks("parent")
+
+	for _, j := range q.stack[1:] {
+		if j.Status != common.STATUS_QUIT {
+			t.Errorf("Expected chunk %s to be quit but it is %s", j.UUID, j.Status)
+		}
+	}
+
+	// Only the chunks on a resource are quit there, by a worker
+	if len(q.dispatches) != 2 {
+		t.Fatalf("Expected 2 quit calls to be queued but got %d", len(q.dispatches))
+	}
+	for _, c := range q.dispatches {
+		if c.Method != "Queue.TaskQuit" || c.ResKey != "res1" {
+			t.Errorf("Unexpected call %s to %s queued for %s", c.Method, c.ResKey, c.Job.UUID)
+		}
+	}
+
+	// and the paused chunk had already given back its hardware
+	if free := q.pool["res1"].Hardware[common.RES_GPU]; free != 1 {
+		t.Errorf("Expected 1 GPU to be freed but %d are free", free)
+	}
+}
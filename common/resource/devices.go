@@ -0,0 +1,129 @@
+package resource
+
+import (
+	"errors"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// taskDevices are the units of hardware set aside for a task, numbered from 1.
+// The devices are kept after the task is paused so it can be given the same
+// ones when it resumes, but only count as in use while held is set.
+type taskDevices struct {
+	hardware string
+	ids      []int
+	held     bool
+}
+
+// freeDevices returns the units of a type of hardware no task is using, after
+// giving back the devices of tasks that have finished.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) freeDevices(hw string) []int {
+	used := map[int]bool{}
+	for uuid, d := range q.devices {
+		if !d.held || d.hardware != hw {
+			continue
+		}
+
+		if task, ok := q.stack[uuid]; ok {
+			switch task.Status().Status {
+			case common.STATUS_DONE, common.STATUS_FAILED, common.STATUS_QUIT:
+				d.held = false
+				q.devices[uuid] = d
+				continue
+			}
+		}
+
+		for _, id := range d.ids {
+			used[id] = true
+		}
+	}
+
+	var free []int
+	for id := 1; id <= q.hardware[hw]; id++ {
+		if !used[id] {
+			free = append(free, id)
+		}
+	}
+
+	return free
+}
+
+// pickDevices chooses units devices from those free, keeping to the devices
+// given in prefer if they are all free
+func pickDevices(free, prefer []int, units int) ([]int, bool) {
+	if len(free) < units {
+		return nil, false
+	}
+
+	isFree := map[int]bool{}
+	for _, id := range free {
+		isFree[id] = true
+	}
+
+	reuse := len(prefer) == units
+	for _, id := range prefer {
+		reuse = reuse && isFree[id]
+	}
+	if reuse {
+		return prefer, true
+	}
+
+	return append([]int(nil), free[:units]...), true
+}
+
+// holdDevices sets aside devices for a task whose Tasker can be told which to
+// use (See common.Devicer) and hands them to it. The devices the task had
+// before it was paused are used again if they are free, as tools such as
+// hashcat resume on the devices they started with.
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) holdDevices(uuid, hw string, units int, tasker common.Tasker) error {
+	devicer, ok := tasker.(common.Devicer)
+	if !ok {
+		return nil
+	}
+
+	prev := q.devices[uuid]
+	if prev.held {
+		return nil
+	}
+
+	ids, ok := pickDevices(q.freeDevices(hw), prev.ids, units)
+	if !ok {
+		log.WithFields(log.Fields{
+			"task":     uuid,
+			"hardware": hw,
+			"units":    units,
+		}).Error("Not enough free hardware for the task.")
+		return errors.New("Not enough free " + hw + " devices on the resource for the task.")
+	}
+
+	q.devices[uuid] = taskDevices{hardware: hw, ids: ids, held: true}
+	devicer.SetDevices(ids)
+
+	log.WithFields(log.Fields{
+		"task":    uuid,
+		"devices": ids,
+	}).Debug("Devices set aside for task")
+
+	return nil
+}
+
+// releaseDevices gives back the devices of a task that has been paused
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) releaseDevices(uuid string) {
+	if d, ok := q.devices[uuid]; ok {
+		d.held = false
+		q.devices[uuid] = d
+	}
+}
+
+// toolerUnits returns how many units of its hardware each job of a tool uses
+func toolerUnits(tooler common.Tooler) int {
+	if u, ok := tooler.(common.Unitser); ok && u.Units() > 0 {
+		return u.Units()
+	}
+
+	return 1
+}
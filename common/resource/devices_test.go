@@ -0,0 +1,60 @@
+package resource
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// deviceTask is a Tasker that records the devices it is given
+type deviceTask struct {
+	status  string
+	devices []int
+}
+
+func (t *deviceTask) Status() common.Job                     { return common.Job{Status: t.status} }
+func (t *deviceTask) Run() error                             { t.status = common.STATUS_RUNNING; return nil }
+func (t *deviceTask) Pause() error                           { t.status = common.STATUS_PAUSED; return nil }
+func (t *deviceTask) Quit() common.Job                       { t.status = common.STATUS_QUIT; return t.Status() }
+func (t *deviceTask) IOE() (io.Writer, io.Reader, io.Reader) { return nil, nil, nil }
+func (t *deviceTask) SetDevices(ids []int)                   { t.devices = ids }
+
+func TestHoldDevices(t *testing.T) {
+	q := NewResourceQueue()
+	q.hardware[common.RES_GPU] = 4
+
+	a, b, c := &deviceTask{}, &deviceTask{}, &deviceTask{}
+	q.stack["a"], q.stack["b"], q.stack["c"] = a, b, c
+
+	if err := q.holdDevices("a", common.RES_GPU, 2, a); err != nil {
+		t.Fatal(err)
+	}
+	a.Run()
+	if err := q.holdDevices("b", common.RES_GPU, 2, b); err != nil {
+		t.Fatal(err)
+	}
+	b.Run()
+
+	if !reflect.DeepEqual(a.devices, []int{1, 2}) || !reflect.DeepEqual(b.devices, []int{3, 4}) {
+		t.Fatalf("Tasks were given %v and %v, wanted separate devices", a.devices, b.devices)
+	}
+
+	if err := q.holdDevices("c", common.RES_GPU, 1, c); err == nil {
+		t.Fatal("A task was given devices already in use")
+	}
+
+	// A paused task gets its devices back if they are still free
+	a.Pause()
+	q.releaseDevices("a")
+	if err := q.holdDevices("a", common.RES_GPU, 2, a); err != nil || !reflect.DeepEqual(a.devices, []int{1, 2}) {
+		t.Fatalf("Resumed task was given %v (%v), wanted its old devices", a.devices, err)
+	}
+
+	// and finished tasks give theirs back
+	b.status = common.STATUS_DONE
+	if err := q.holdDevices("c", common.RES_GPU, 1, c); err != nil || !reflect.DeepEqual(c.devices, []int{3}) {
+		t.Fatalf("Task was given %v (%v), wanted the devices of the finished task", c.devices, err)
+	}
+}
@@ -0,0 +1,74 @@
+package resource
+
+import (
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/jmmcatee/cracklord/common/metrics"
+)
+
+var taskStartFailures = metrics.NewCounter("cracklord_task_start_failures_total",
+	"Tasks that could not be created or started by tool.", "tool")
+
+// RegisterMetrics adds gauges for the tasks on the resource, gathered from each
+// task when they are scraped. It should only be called once.
+func (q *Queue) RegisterMetrics() {
+	metrics.NewGaugeFunc("cracklord_tasks", "Tasks on the resource by status.",
+		[]string{"status"}, func() []metrics.Sample {
+			counts := map[string]int{}
+			for _, t := range q.taskMetrics() {
+				counts[t.job.Status]++
+			}
+
+			var samples []metrics.Sample
+			for status, n := range counts {
+				samples = append(samples, metrics.Sample{Labels: []string{status}, Value: float64(n)})
+			}
+			return samples
+		})
+
+	metrics.NewGaugeFunc("cracklord_tool_speed", "Latest speed reported by each running task.",
+		[]string{"tool", "task"}, func() []metrics.Sample {
+			var samples []metrics.Sample
+			for _, t := range q.taskMetrics() {
+				if t.job.Status != common.STATUS_RUNNING {
+					continue
+				}
+
+				samples = append(samples, metrics.Sample{
+					Labels: []string{t.tool, t.job.UUID},
+					Value:  common.LatestPerformance(t.job.PerformanceData),
+				})
+			}
+			return samples
+		})
+}
+
+type taskMetric struct {
+	tool string
+	job  common.Job
+}
+
+// taskMetrics gathers the status of every task with the name of its tool
+func (q *Queue) taskMetrics() []taskMetric {
+	q.Lock()
+	defer q.Unlock()
+
+	var tasks []taskMetric
+	for _, t := range q.stack {
+		j := t.Status()
+		tasks = append(tasks, taskMetric{tool: q.toolName(j.ToolUUID), job: j})
+	}
+
+	return tasks
+}
+
+// toolName returns the name of the tool with the UUID given
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) toolName(toolUUID string) string {
+	for _, t := range q.tools {
+		if t.UUID() == toolUUID {
+			return t.Name()
+		}
+	}
+
+	return "unknown"
+}
@@ -19,20 +19,26 @@ type Queue struct {
 	stack map[string]common.Tasker
 	tools []common.Tooler
 	sync.RWMutex
-	hardware map[string]bool
+	hardware map[string]int         // Units of each type of hardware
+	labels   map[string]string      // Labels the control queue can place jobs by
+	devices  map[string]taskDevices // Devices set aside for each task (See holdDevices)
 }
 
 func NewResourceQueue() Queue {
 	return Queue{
 		stack:    map[string]common.Tasker{},
 		tools:    []common.Tooler{},
-		hardware: map[string]bool{},
+		hardware: map[string]int{},
+		labels:   map[string]string{},
+		devices:  map[string]taskDevices{},
 	}
 }
 
 func (q *Queue) AddTool(tooler common.Tooler) {
-	// Add the hardware used by the tool
-	q.hardware[tooler.Requirements()] = true
+	// Add the hardware used by the tool, with a single unit unless told otherwise
+	if _, ok := q.hardware[tooler.Requirements()]; !ok {
+		q.hardware[tooler.Requirements()] = 1
+	}
 
 	tooler.SetUUID(uuid.New())
 	q.tools = append(q.tools, tooler)
@@ -43,6 +49,18 @@ func (q *Queue) AddTool(tooler common.Tooler) {
 	}).Debug("Tool added")
 }
 
+// tooler returns the tool with the UUID given, or nil if there is none
+// A LOCK SHOULD ALREADY BE HELD TO CALL THIS FUNCTION.
+func (q *Queue) tooler(toolUUID string) common.Tooler {
+	for i := range q.tools {
+		if q.tools[i].UUID() == toolUUID {
+			return q.tools[i]
+		}
+	}
+
+	return nil
+}
+
 // Task RPC functions
 func (q *Queue) Ping(ping int, pong *int) error {
 	q.Lock()
@@ -53,7 +71,42 @@ func (q *Queue) Ping(ping int, pong *int) error {
 	return nil
 }
 
-func (q *Queue) ResourceHardware(rpc common.RPCCall, hw *map[string]bool) error {
+// SetHardware sets the number of units of a type of hardware the resource has,
+// such as the number of GPUs.
+func (q *Queue) SetHardware(hw string, units int) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.hardware[hw] = units
+	log.WithFields(log.Fields{
+		"hardware": hw,
+		"units":    units,
+	}).Debug("Hardware capacity set")
+}
+
+// SetLabel sets a label on the resource, such as site=lab2, which jobs can
+// require or prefer when the control queue picks a resource for them.
+func (q *Queue) SetLabel(key, value string) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.labels[key] = value
+	log.WithFields(log.Fields{
+		"label": key,
+		"value": value,
+	}).Debug("Label set")
+}
+
+func (q *Queue) ResourceLabels(rpc common.RPCCall, labels *map[string]string) error {
+	q.RLock()
+	defer q.RUnlock()
+
+	*labels = q.labels
+
+	return nil
+}
+
+func (q *Queue) ResourceHardware(rpc common.RPCCall, hw *map[string]int) error {
 	q.RLock()
 	defer q.RUnlock()
 
@@ -83,27 +136,54 @@ func (q *Queue) AddTask(rpc common.RPCCall, rj *common.Job) error {
 	// variable to hold the tasker
 	var tasker common.Tasker
 	var err error
+	var hw string
+	var units int
 	// loop through common.Toolers for matching tool
 	q.Lock()
 	defer q.Unlock()
 	for i, _ := range q.tools {
 		if q.tools[i].UUID() == rpc.Job.ToolUUID {
-			tasker, err = q.tools[i].NewTask(rpc.Job)
+			// Benchmarks are only run by tools implementing the Benchmarker interface
+			if common.IsBenchmark(rpc.Job) {
+				benchmarker, ok := q.tools[i].(common.Benchmarker)
+				if !ok {
+					taskStartFailures.Inc(q.tools[i].Name())
+					return errors.New("Tool specified does not support benchmarks.")
+				}
+
+				tasker, err = benchmarker.NewBenchmark(rpc.Job)
+			} else {
+				tasker, err = q.tools[i].NewTask(rpc.Job)
+			}
 			if err != nil {
+				taskStartFailures.Inc(q.tools[i].Name())
 				return err
 			}
+
+			hw, units = q.tools[i].Requirements(), toolerUnits(q.tools[i])
 		}
 	}
 
 	// Check if no tool was found and return error
 	if tasker == nil {
 		log.Warn("An error occured, we could not find the tool requested")
+		taskStartFailures.Inc("unknown")
 		return errors.New(ERROR_NO_TOOL)
 	}
 	log.WithFields(log.Fields{
 		"task": rpc.Job.UUID,
 	}).Debug("Tasker created")
 
+	// Give the task its own devices so it does not share them with other tasks
+	if q.devices == nil {
+		q.devices = map[string]taskDevices{}
+	}
+	err = q.holdDevices(rpc.Job.UUID, hw, units, tasker)
+	if err != nil {
+		taskStartFailures.Inc(q.toolName(rpc.Job.ToolUUID))
+		return err
+	}
+
 	// Looks good so lets add to the stack
 	if q.stack == nil {
 		q.stack = make(map[string]common.Tasker)
@@ -115,6 +195,8 @@ func (q *Queue) AddTask(rpc common.RPCCall, rj *common.Job) error {
 	err = q.stack[rpc.Job.UUID].Run()
 	if err != nil {
 		log.Debug("Error starting task on resource")
+		q.releaseDevices(rpc.Job.UUID)
+		taskStartFailures.Inc(q.toolName(rpc.Job.ToolUUID))
 		return errors.New("Error starting task on the resource: " + err.Error())
 	}
 
@@ -124,6 +206,90 @@ func (q *Queue) AddTask(rpc common.RPCCall, rj *common.Job) error {
 	return nil
 }
 
+func (q *Queue) TaskKeyspace(rpc common.RPCCall, keyspace *int64) error {
+	log.WithField("task", rpc.Job.UUID).Debug("Attempting to calculate task keyspace")
+
+	// Add a defered catch for panic from within the tools
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Recovered from Panic in Resource.TaskKeyspace: %v", err)
+		}
+	}()
+
+	// Look up the tool under the lock but run it without, as tools such as
+	// hashcat can take a while to go through large word lists
+	q.RLock()
+	tooler := q.tooler(rpc.Job.ToolUUID)
+	q.RUnlock()
+
+	if tooler == nil {
+		log.Warn("An error occured, we could not find the tool requested")
+		return errors.New(ERROR_NO_TOOL)
+	}
+
+	// Only tools implementing the Keyspacer interface can be split
+	keyspacer, ok := tooler.(common.Keyspacer)
+	if !ok {
+		return errors.New("Tool specified does not support keyspace splitting.")
+	}
+
+	ks, err := keyspacer.Keyspace(rpc.Job)
+	if err != nil {
+		return err
+	}
+
+	*keyspace = ks
+
+	log.WithFields(log.Fields{
+		"task":     rpc.Job.UUID,
+		"keyspace": ks,
+	}).Debug("Task keyspace calculated")
+
+	return nil
+}
+
+func (q *Queue) TaskCandidates(rpc common.RPCCall, candidates *float64) error {
+	log.WithField("task", rpc.Job.UUID).Debug("Attempting to count task candidates")
+
+	// Add a defered catch for panic from within the tools
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Recovered from Panic in Resource.TaskCandidates: %v", err)
+		}
+	}()
+
+	// Look up the tool under the lock but run it without, as tools such as
+	// hashcat can take a while to go through large word lists
+	q.RLock()
+	tooler := q.tooler(rpc.Job.ToolUUID)
+	q.RUnlock()
+
+	if tooler == nil {
+		log.Warn("An error occured, we could not find the tool requested")
+		return errors.New(ERROR_NO_TOOL)
+	}
+
+	// Only tools implementing the Estimator interface can count candidates
+	estimator, ok := tooler.(common.Estimator)
+	if !ok {
+		return errors.New("Tool specified does not support estimating jobs.")
+	}
+
+	c, err := estimator.Candidates(rpc.Job)
+	if err != nil {
+		return err
+	}
+
+	*candidates = c
+
+	log.WithFields(log.Fields{
+		"task":       rpc.Job.UUID,
+		"candidates": c,
+	}).Debug("Task candidates counted")
+
+	return nil
+}
+
 func (q *Queue) TaskStatus(rpc common.RPCCall, j *common.Job) error {
 	log.WithField("task", rpc.Job.UUID).Debug("Attempting to gather task status")
 
@@ -177,9 +343,13 @@ func (q *Queue) TaskPause(rpc common.RPCCall, j *common.Job) error {
 		// return the error but quit the job with status Failed
 		// This is a definied behavior that we will not for all tools
 		q.stack[rpc.Job.UUID].Quit()
+		q.releaseDevices(rpc.Job.UUID)
 		return err
 	}
 
+	// The task is not using its devices while paused
+	q.releaseDevices(rpc.Job.UUID)
+
 	*j = q.stack[rpc.Job.UUID].Status()
 
 	log.WithField("task", j.UUID).Debug("Task paused successfully")
@@ -209,9 +379,18 @@ func (q *Queue) TaskRun(rpc common.RPCCall, j *common.Job) error {
 		return errors.New("Task with UUID provided does not exist.")
 	}
 
+	// Set aside devices for the task again, ideally the ones it had before
+	if d, ok := q.devices[rpc.Job.UUID]; ok {
+		err := q.holdDevices(rpc.Job.UUID, d.hardware, len(d.ids), q.stack[rpc.Job.UUID])
+		if err != nil {
+			return err
+		}
+	}
+
 	// Start or resume the task
 	err := q.stack[rpc.Job.UUID].Run()
 	if err != nil {
+		q.releaseDevices(rpc.Job.UUID)
 		return err
 	}
 
@@ -251,6 +430,7 @@ func (q *Queue) TaskQuit(rpc common.RPCCall, j *common.Job) error {
 
 	// Remove quit job from stack
 	delete(q.stack, rpc.Job.UUID)
+	delete(q.devices, rpc.Job.UUID)
 
 	log.WithField("task", rpc.Job.UUID).Debug("Task quit and removed successfully")
 
@@ -283,6 +463,15 @@ func (q *Queue) ResourceTools(rpc common.RPCCall, tools *[]common.Tool) error {
 		tool.Parameters = q.tools[i].Parameters()
 		tool.Requirements = q.tools[i].Requirements()
 
+		// Let the control queue know if this tool can split jobs by keyspace
+		_, tool.Splittable = q.tools[i].(common.Keyspacer)
+
+		// and if it can benchmark the resource
+		_, tool.Benchmarks = q.tools[i].(common.Benchmarker)
+
+		// and how much of its hardware each job takes
+		tool.Units = toolerUnits(q.tools[i])
+
 		log.WithFields(log.Fields{
 			"UUID": tool.UUID,
 			"name": tool.Name,
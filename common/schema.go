@@ -0,0 +1,307 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A FieldError is a problem with a single parameter of a job
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// schemaProperty is the part of a JSON schema property the queue checks
+type schemaProperty struct {
+	Type              string        `json:"type"`
+	Enum              []interface{} `json:"enum"`
+	Pattern           string        `json:"pattern"`
+	Minimum           *float64      `json:"minimum"`
+	Maximum           *float64      `json:"maximum"`
+	MinLength         *int          `json:"minLength"`
+	MaxLength         *int          `json:"maxLength"`
+	ValidationMessage string        `json:"validationMessage"`
+}
+
+type paramSchema struct {
+	Properties map[string]schemaProperty `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+// formItem is the part of an angular schema form element the queue checks
+type formItem struct {
+	Key       interface{}       `json:"key"`
+	Condition string            `json:"condition"`
+	Items     []json.RawMessage `json:"items"`
+	Tabs      []formItem        `json:"tabs"`
+}
+
+// ValidateParameters checks the parameters of a job against the JSON schema a
+// tool gives in Parameters() (See JSONSchemaForm). Parameters are strings by the
+// time they reach the tools, so numbers and booleans are checked by whether they
+// parse. Parameters the schema does not list are allowed, and empty values are
+// only checked when they are required. Required fields the form only shows
+// under a condition are only required when it is met, and the fields in skip
+// are not required. An error is returned if the schema itself can not be read.
+func ValidateParameters(toolParams string, params map[string]string, skip ...string) ([]FieldError, error) {
+	var form JSONSchemaForm
+	if err := json.Unmarshal([]byte(toolParams), &form); err != nil {
+		return nil, err
+	}
+
+	// Tools without a schema take anything
+	if len(form.Schema) == 0 {
+		return nil, nil
+	}
+
+	var schema paramSchema
+	if err := json.Unmarshal(form.Schema, &schema); err != nil {
+		return nil, err
+	}
+
+	// Forms that can not be read show every field
+	var items []json.RawMessage
+	json.Unmarshal(form.Form, &items)
+	shown := map[string][][]string{}
+	formConditions(items, nil, shown)
+
+	var errs []FieldError
+
+	for _, field := range schema.Required {
+		if params[field] == "" && !containsString(skip, field) && fieldShown(shown[field], params) {
+			errs = append(errs, FieldError{Field: field, Message: "This field is required."})
+		}
+	}
+
+	for field, value := range params {
+		prop, ok := schema.Properties[field]
+		if !ok || value == "" {
+			continue
+		}
+
+		if msg := prop.check(value); msg != "" {
+			errs = append(errs, FieldError{Field: field, Message: msg})
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+
+	return errs, nil
+}
+
+// formConditions adds the conditions each field of a form is shown under to
+// shown, including those of the sections and tabs it is in. A field in the form
+// more than once has the conditions of each place it is.
+func formConditions(items []json.RawMessage, parent []string, shown map[string][][]string) {
+	for _, raw := range items {
+		// Items can also be just the key of a field, which has no conditions
+		var item formItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			continue
+		}
+
+		conds := parent
+		if item.Condition != "" {
+			conds = append(append([]string(nil), parent...), item.Condition)
+		}
+
+		if key, ok := item.Key.(string); ok {
+			shown[key] = append(shown[key], conds)
+		}
+
+		formConditions(item.Items, conds, shown)
+		for _, tab := range item.Tabs {
+			tabConds := conds
+			if tab.Condition != "" {
+				tabConds = append(append([]string(nil), conds...), tab.Condition)
+			}
+			formConditions(tab.Items, tabConds, shown)
+		}
+	}
+}
+
+// fieldShown checks if a field with the conditions given by formConditions is
+// shown in the form for the parameters. Fields not in the form are shown.
+func fieldShown(places [][]string, params map[string]string) bool {
+	if len(places) == 0 {
+		return true
+	}
+
+	for _, conds := range places {
+		met := true
+		for _, cond := range conds {
+			if !conditionMet(cond, params) {
+				met = false
+				break
+			}
+		}
+
+		if met {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conditionMet evaluates the simple angular expressions forms use as
+// conditions, such as "model.a", "!model.a && model.b" or "model.a == 'true'".
+// Conditions that can not be read are not met so the tool is left to check the
+// field.
+func conditionMet(cond string, params map[string]string) bool {
+	for _, any := range strings.Split(cond, "||") {
+		all := true
+		for _, term := range strings.Split(any, "&&") {
+			met, ok := termMet(term, params)
+			if !ok {
+				return false
+			}
+			if !met {
+				all = false
+			}
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+var conditionField = regexp.MustCompile(`^(model\.)?([A-Za-z0-9_]+)$`)
+
+// termMet evaluates one term of a condition, returning false for ok if it can
+// not be read
+func termMet(term string, params map[string]string) (met bool, ok bool) {
+	term = strings.TrimSpace(term)
+
+	var negate bool
+	for strings.HasPrefix(term, "!") && !strings.HasPrefix(term, "!=") {
+		negate = !negate
+		term = strings.TrimSpace(term[1:])
+	}
+
+	var value string
+	compare, equal := "", true
+	if i := strings.Index(term, "!="); i >= 0 {
+		term, value, equal = term[:i], term[i+2:], false
+		compare = "!="
+	} else if i := strings.Index(term, "=="); i >= 0 {
+		term, value = term[:i], term[i+2:]
+		compare = "=="
+	}
+
+	m := conditionField.FindStringSubmatch(strings.TrimSpace(term))
+	if m == nil {
+		return false, false
+	}
+	param := params[m[2]]
+
+	if compare != "" {
+		value = strings.Trim(strings.TrimSpace(strings.TrimLeft(value, "=")), `'"`)
+		met = (param == value) == equal
+	} else if b, err := strconv.ParseBool(param); err == nil {
+		met = b
+	} else {
+		met = param != ""
+	}
+
+	return met != negate, true
+}
+
+// check returns why a value does not match the property, or an empty string if
+// it does
+func (p schemaProperty) check(value string) string {
+	var num float64
+	var isNum bool
+
+	switch p.Type {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "Must be a number."
+		}
+		num, isNum = n, true
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "Must be a whole number."
+		}
+		num, isNum = float64(n), true
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "Must be true or false."
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		var found bool
+		options := make([]string, 0, len(p.Enum))
+		for _, e := range p.Enum {
+			option := enumString(e)
+			options = append(options, option)
+			if option == value {
+				found = true
+			}
+		}
+
+		if !found {
+			return "Must be one of: " + strings.Join(options, ", ") + "."
+		}
+	}
+
+	if isNum && p.Minimum != nil && num < *p.Minimum {
+		return "Must be at least " + strconv.FormatFloat(*p.Minimum, 'g', -1, 64) + "."
+	}
+	if isNum && p.Maximum != nil && num > *p.Maximum {
+		return "Must be at most " + strconv.FormatFloat(*p.Maximum, 'g', -1, 64) + "."
+	}
+
+	if p.MinLength != nil && len([]rune(value)) < *p.MinLength {
+		return fmt.Sprintf("Must be at least %d characters.", *p.MinLength)
+	}
+	if p.MaxLength != nil && len([]rune(value)) > *p.MaxLength {
+		return fmt.Sprintf("Must be at most %d characters.", *p.MaxLength)
+	}
+
+	if p.Pattern != "" {
+		// Patterns Go can not compile are left to the tool
+		if re, err := regexp.Compile(p.Pattern); err == nil && !re.MatchString(value) {
+			if p.ValidationMessage != "" {
+				return p.ValidationMessage
+			}
+			return "Does not match the pattern " + p.Pattern + "."
+		}
+	}
+
+	return ""
+}
+
+// enumString formats an enum option the way the API turns parameters into strings
+func enumString(v interface{}) string {
+	switch e := v.(type) {
+	case string:
+		return e
+	case float64:
+		return strconv.FormatFloat(e, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(e)
+	default:
+		return fmt.Sprint(e)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+const testToolParams = `{
+	"form": [],
+	"schema": {
+		"type": "object",
+		"properties": {
+			"mode": {"type": "string", "enum": ["Fast", "Slow"]},
+			"threads": {"type": "integer", "minimum": 1, "maximum": 16},
+			"verbose": {"type": "boolean"},
+			"ports": {"type": "string", "pattern": "^\\d+(,\\d+)*$", "validationMessage": "Comma separated ports only."},
+			"targets": {"type": "string", "minLength": 3}
+		},
+		"required": ["mode", "targets"]
+	}
+}`
+
+func TestValidateParameters(t *testing.T) {
+	errs, err := ValidateParameters(testToolParams, map[string]string{
+		"mode":    "Fast",
+		"threads": "8",
+		"verbose": "true",
+		"ports":   "22,80",
+		"targets": "10.0.0.0/8",
+		"extra":   "anything",
+	})
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("Expected valid parameters but got %v %v", errs, err)
+	}
+
+	errs, err = ValidateParameters(testToolParams, map[string]string{
+		"mode":    "Medium",
+		"threads": "32",
+		"verbose": "maybe",
+		"ports":   "ssh",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []FieldError{
+		{Field: "mode", Message: "Must be one of: Fast, Slow."},
+		{Field: "ports", Message: "Comma separated ports only."},
+		{Field: "targets", Message: "This field is required."},
+		{Field: "threads", Message: "Must be at most 16."},
+		{Field: "verbose", Message: "Must be true or false."},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors but got %v", len(expected), errs)
+	}
+	for i := range expected {
+		if errs[i] != expected[i] {
+			t.Errorf("Expected %v but got %v", expected[i], errs[i])
+		}
+	}
+
+	// Skipped fields are not required
+	errs, _ = ValidateParameters(testToolParams, map[string]string{"mode": "Slow"}, "targets")
+	if len(errs) != 0 {
+		t.Errorf("Expected skipped fields to be left out but got %v", errs)
+	}
+
+	if errs, err := ValidateParameters(`{"form": []}`, map[string]string{"a": "b"}); err != nil || errs != nil {
+		t.Errorf("Expected a tool without a schema to take anything but got %v %v", errs, err)
+	}
+	if _, err := ValidateParameters("not json", nil); err == nil {
+		t.Error("Expected an unreadable schema to return an error")
+	}
+}
+
+// Modelled on the hashcat3 form, where the hashes are typed in or uploaded
+const testConditionalParams = `{
+	"form": [
+		"hashmode",
+		{"type": "tabs", "tabs": [{"title": "Hashes", "items": [
+			{"key": "hashes_use_upload"},
+			{"key": "hashes_multiline", "condition": "!model.hashes_use_upload"},
+			{"key": "hashes_file_upload", "condition": "model.hashes_use_upload"}
+		]}]},
+		{"type": "fieldset", "condition": "model.use_adv_options", "items": [
+			{"key": "adv_timeout", "condition": "model.adv_mode == 'timeout' && !model.adv_off"}
+		]}
+	],
+	"schema": {
+		"properties": {"hashmode": {"type": "integer"}},
+		"required": ["hashmode", "hashes_multiline", "adv_timeout"]
+	}
+}`
+
+func TestValidateParametersConditions(t *testing.T) {
+	tests := []struct {
+		params   map[string]string
+		expected []string
+	}{
+		{map[string]string{"hashmode": "1000"}, []string{"hashes_multiline"}},
+		{map[string]string{"hashmode": "1000", "hashes_use_upload": "false"}, []string{"hashes_multiline"}},
+		{map[string]string{"hashmode": "1000", "hashes_use_upload": "true", "hashes_file_upload": "file:h.txt;data:text/plain;base64,"}, nil},
+		{map[string]string{"hashmode": "1000", "hashes_multiline": "abc", "use_adv_options": "true", "adv_mode": "timeout"}, []string{"adv_timeout"}},
+		{map[string]string{"hashmode": "1000", "hashes_multiline": "abc", "use_adv_options": "true", "adv_mode": "timeout", "adv_off": "true"}, nil},
+		{map[string]string{"hashmode": "1000", "hashes_multiline": "abc", "use_adv_options": "false", "adv_mode": "timeout"}, nil},
+	}
+
+	for _, test := range tests {
+		errs, err := ValidateParameters(testConditionalParams, test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var fields []string
+		for _, e := range errs {
+			fields = append(fields, e.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Expected %v to be required for %v but got %v", test.expected, test.params, errs)
+		}
+	}
+
+	// Conditions that can not be read leave the field to the tool
+	if conditionMet("model.list.indexOf('a') > -1", map[string]string{}) {
+		t.Error("Expected an unreadable condition not to be met")
+	}
+}
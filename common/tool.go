@@ -9,6 +9,9 @@ type Tool struct {
 	UUID         string
 	Parameters   string
 	Requirements string
+	Splittable   bool // Jobs can be split into keyspace chunks (see Keyspacer)
+	Units        int  // Units of the Requirements hardware a job uses (see Unitser)
+	Benchmarks   bool // Benchmark jobs can be run (see Benchmarker)
 }
 
 // Compare two Tools to see if they are the same
@@ -33,5 +36,17 @@ func CompareTools(t1, t2 Tool) bool {
 		return false
 	}
 
+	if t1.Splittable != t2.Splittable {
+		return false
+	}
+
+	if t1.Units != t2.Units {
+		return false
+	}
+
+	if t1.Benchmarks != t2.Benchmarks {
+		return false
+	}
+
 	return true
 }
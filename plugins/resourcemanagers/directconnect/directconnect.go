@@ -12,6 +12,7 @@ import (
 
 type resourceInfo struct {
 	notes         string
+	labels        map[string]string
 	lastGoodCheck time.Time
 }
 
@@ -90,6 +91,10 @@ func (this directResourceManager) ParametersForm() string {
 	return `[
 		"name",
 		"address",
+		{
+			"key": "labels",
+			"placeholder": "OPTIONAL: Labels jobs can be placed by (site=lab2, gpu=a100)"
+		},
 		{
 			"key": "notes",
 			"type": "textarea",
@@ -113,6 +118,11 @@ func (this directResourceManager) ParametersSchema() string {
 				"default": "localhost",
 				"description": "The full DNS name or IP address of the resource."
 			},
+			"labels": {
+				"title": "Labels",
+				"type": "string",
+				"description": "Comma separated key=value labels that jobs can require, prefer, or avoid."
+			},
 			"notes": {
 				"title": "Notes",
 				"type": "string"
@@ -137,6 +147,12 @@ func (this *directResourceManager) AddResource(params map[string]string) error {
 		return errors.New("Cannot add resource, name was not specified.")
 	}
 
+	//Make sure the rest of the parameters are good before touching the queue
+	info, err := this.parseParams(params)
+	if err != nil {
+		return err
+	}
+
 	//First, we attempt to add the resource into the queue itself
 	uuid, err := this.q.AddResource(name)
 	if err != nil {
@@ -149,10 +165,10 @@ func (this *directResourceManager) AddResource(params map[string]string) error {
 		return err
 	}
 
-	//Finally, set the resource into our map
-	this.resources.Set(uuid, this.parseParams(params))
+	//Finally, set the resource into our map and give the queue its labels
+	this.resources.Set(uuid, info)
 
-	return nil
+	return this.q.SetResourceLabels(uuid, info.labels)
 }
 
 func (this *directResourceManager) DeleteResource(resourceid string) error {
@@ -191,6 +207,7 @@ func (this directResourceManager) GetResource(resourceid string) (*queue.Resourc
 	//Parse our parameters struct back into a common string map
 	parameters := make(map[string]string)
 	parameters["notes"] = localres.notes
+	parameters["labels"] = queue.FormatLabels(localres.labels)
 
 	return resource, parameters, nil
 }
@@ -203,7 +220,16 @@ func (this *directResourceManager) UpdateResource(resourceid string, newstatus s
 	}
 
 	//Set the internal parameters within the direct connect manager to the new data
-	this.resources.Set(resourceid, this.parseParams(newparams))
+	info, err := this.parseParams(newparams)
+	if err != nil {
+		return err
+	}
+	this.resources.Set(resourceid, info)
+
+	err = this.q.SetResourceLabels(resourceid, info.labels)
+	if err != nil {
+		return err
+	}
 
 	//Check to see if the old status matches the new one, if not, we need to make a change
 	if oldresource.Status != newstatus {
@@ -280,10 +306,18 @@ func (this *directResourceManager) Keep() {
 	log.Info("Direct connect resource manager has successfully updated resources.")
 }
 
-func (this *directResourceManager) parseParams(params map[string]string) resourceInfo {
+func (this *directResourceManager) parseParams(params map[string]string) (resourceInfo, error) {
 	//Let's create a temporary resource to hold the info
 	tempresource := resourceInfo{
 		notes: params["notes"],
 	}
-	return tempresource
+
+	labels, err := queue.ParseLabels(params["labels"])
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Unable to parse the labels for a resource.")
+		return tempresource, err
+	}
+	tempresource.labels = labels
+
+	return tempresource, nil
 }
@@ -0,0 +1,160 @@
+package hashcat3
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+	"github.com/jmmcatee/goschemaform"
+)
+
+// Parameter prefixes of the word lists used by the combinator and hybrid
+// attacks. Each has the inputs added by addWordlistInputs.
+const (
+	COMB_LEFT_PREFIX  = "comb_left_"
+	COMB_RIGHT_PREFIX = "comb_right_"
+	HYBRID_DM_PREFIX  = "hybrid_dm_" // Dictionary + mask (-a 6)
+	HYBRID_MD_PREFIX  = "hybrid_md_" // Mask + dictionary (-a 7)
+)
+
+// addWordlistInputs adds the inputs for choosing a word list of a combinator or
+// hybrid attack to a tab. A configured dictionary or an uploaded file can be
+// used, with custom words prepended as on the dictionary tab. Rule files are
+// left out as hashcat only applies them to dictionary attacks.
+func addWordlistInputs(tab *goschemaform.Tab, prefix, title string) {
+	// Setup checkbox to upload a word list instead of a configured dictionary
+	uploadCheckbox := goschemaform.NewCheckBoxInput(prefix + "use_upload")
+	uploadCheckbox.SetTitle("Upload a custom word list for the " + title)
+	tab.AddElement(uploadCheckbox)
+
+	// Setup the dropdown for choosing a dictionary to use
+	dictionaryDropDown := goschemaform.NewDropDownInput(prefix + "dictionary")
+	dictionaryDropDown.SetTitle("Select dictionary to use for the " + title)
+	dictionaryDropDown.SetCondition(prefix+"use_upload", true)
+	sort.Sort(config.Dictionaries)
+	for i := range config.Dictionaries {
+		option := goschemaform.NewDropDownInputOption(config.Dictionaries[i].Name)
+		dictionaryDropDown.AddOption(option)
+	}
+	tab.AddElement(dictionaryDropDown)
+
+	// Build the word list upload control
+	upload := goschemaform.NewFileInput(prefix + "file")
+	upload.SetTitle("Custom Word List")
+	upload.SetPlaceHolder("Click here or drop file to upload")
+	upload.SetCondition(prefix+"use_upload", false)
+	tab.AddElement(upload)
+
+	// Setup checkbox to determine if we are prepending words to the word list
+	prependCheckbox := goschemaform.NewCheckBoxInput(prefix + "use_custom_prepend")
+	prependCheckbox.SetTitle("Prepend custom words to the " + title)
+	tab.AddElement(prependCheckbox)
+
+	// Setup conditional multiline if we want to prepend words to the word list
+	prependMultiline := goschemaform.NewTextInput(prefix + "custom_prepend")
+	prependMultiline.SetTitle("Custom Words to Prepend")
+	prependMultiline.SetPlaceHolder("One word per line")
+	prependMultiline.SetMultiline(true)
+	prependMultiline.SetCondition(prefix+"use_custom_prepend", false)
+	tab.AddElement(prependMultiline)
+}
+
+// addMaskInput adds the input for the mask of a hybrid attack to a tab
+func addMaskInput(tab *goschemaform.Tab, prefix string) {
+	mask := goschemaform.NewTextInput(prefix + "mask")
+	mask.SetTitle("Mask (?1=?l?d, ?2=?u?l?d, ?3=?d?s, ?4=?l?d?s)")
+	mask.SetPlaceHolder("Mask to combine with each word...")
+	mask.SetMultiline(false)
+	tab.AddElement(mask)
+}
+
+// wordlistSet checks if the parameters choose a word list with the prefix given
+func wordlistSet(params map[string]string, prefix string) bool {
+	_, dictOk := params[prefix+"dictionary"]
+	upload, _ := paramBool(params, prefix+"use_upload")
+
+	return dictOk || upload
+}
+
+// findDictionary returns the path of a configured dictionary
+func findDictionary(name string) (string, error) {
+	dictIndex := sort.Search(len(config.Dictionaries), func(i int) bool { return config.Dictionaries[i].Name >= name })
+	if dictIndex == len(config.Dictionaries) || config.Dictionaries[dictIndex].Name != name {
+		log.WithField("dictionary", name).Error("Dictionary provided does not exist.")
+		return "", errors.New("Dictionary provided does not exist.")
+	}
+
+	return config.Dictionaries[dictIndex].Path, nil
+}
+
+// wordlistPath returns the path of the word list chosen by the parameters with
+// the prefix given, writing any upload and prepended words to the working directory
+func wordlistPath(wd string, params map[string]string, prefix string) (string, error) {
+	upload, err := paramBool(params, prefix+"use_upload")
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	if upload {
+		uploadBytes, err := decodeBase64Upload(params[prefix+"file"])
+		if err != nil {
+			return "", err
+		}
+
+		path = filepath.Join(wd, prefix+"uploaded-dict.txt")
+		if err = ioutil.WriteFile(path, uploadBytes, 0660); err != nil {
+			log.WithField("error", err).Error("Error writing the uploaded word list to disk.")
+			return "", err
+		}
+	} else if path, err = findDictionary(params[prefix+"dictionary"]); err != nil {
+		return "", err
+	}
+
+	prepend, err := paramBool(params, prefix+"use_custom_prepend")
+	if err != nil {
+		return "", err
+	}
+
+	if custom, ok := params[prefix+"custom_prepend"]; prepend && ok {
+		customDictPath := filepath.Join(wd, prefix+"custom-prepend-dict.txt")
+		if err = common.CopyPrepend(customDictPath, path, custom); err != nil {
+			log.WithField("Copy Error", err).Error("Error copying dictionary")
+			return "", err
+		}
+
+		path = customDictPath
+	}
+
+	log.WithFields(log.Fields{
+		"prefix": prefix,
+		"path":   path,
+	}).Debug("Word list selected.")
+
+	return path, nil
+}
+
+// hybridMask returns the mask of a hybrid attack
+func hybridMask(params map[string]string, prefix string) (string, error) {
+	mask := strings.TrimSpace(params[prefix+"mask"])
+	if mask == "" {
+		log.WithField("prefix", prefix).Error("No mask was provided for the hybrid attack.")
+		return "", errors.New("No mask was provided for the hybrid attack.")
+	}
+
+	return mask, nil
+}
+
+// predefinedCharsetOpts are the custom character sets the predefined masks use
+func predefinedCharsetOpts() []string {
+	return []string{
+		"--custom-charset1=" + CharSetPreDefCustom1,
+		"--custom-charset2=" + CharSetPreDefCustom2,
+		"--custom-charset3=" + CharSetPreDefCustom3,
+		"--custom-charset4=" + CharSetPreDefCustom4,
+	}
+}
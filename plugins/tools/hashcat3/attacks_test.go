@@ -0,0 +1,47 @@
+package hashcat3
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWordlistPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hashcat3-attacks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dictPath := filepath.Join(dir, "words.txt")
+	ioutil.WriteFile(dictPath, []byte("word\n"), 0600)
+
+	defer func(c Config) { config = c }(config)
+	config.Dictionaries = Dictionaries{{Name: "words", Path: dictPath}}
+
+	path, err := wordlistPath(dir, map[string]string{"comb_left_dictionary": "words"}, COMB_LEFT_PREFIX)
+	if err != nil || path != dictPath {
+		t.Errorf("Expected the configured dictionary but got %q (%v)", path, err)
+	}
+
+	// Custom words go before the uploaded ones
+	params := map[string]string{
+		"comb_right_use_upload":         "true",
+		"comb_right_file":               "file:up.txt;data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("uploaded\n")),
+		"comb_right_use_custom_prepend": "true",
+		"comb_right_custom_prepend":     "custom",
+	}
+	path, err = wordlistPath(dir, params, COMB_RIGHT_PREFIX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "custom\nuploaded\n" {
+		t.Errorf("Unexpected word list %q", data)
+	}
+
+	if _, err := wordlistPath(dir, map[string]string{"hybrid_dm_dictionary": "missing"}, HYBRID_DM_PREFIX); err == nil {
+		t.Error("Expected a missing dictionary to fail")
+	}
+}
@@ -0,0 +1,242 @@
+package hashcat3
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// benchmarkTasker runs hashcat's benchmark on the resource rather than an attack
+type benchmarkTasker struct {
+	mux     sync.Mutex
+	job     common.Job
+	wd      string
+	args    []string
+	devices []int // Devices the resource set aside for the benchmark (See SetDevices)
+	exec    *exec.Cmd
+	stdout  bytes.Buffer
+	stderr  bytes.Buffer
+	done    chan struct{} // Closed once hashcat has exited
+	stopTo  string        // Status to report once hashcat has exited, if it was stopped
+}
+
+// NewBenchmark returns a Tasker that benchmarks the mode given by the hashmode
+// parameter of the job, or hashcat's default set of modes when it is not set.
+func (h *hashcat3Tooler) NewBenchmark(job common.Job) (common.Tasker, error) {
+	t := &benchmarkTasker{job: job}
+
+	args := []string{"--benchmark", "--machine-readable"}
+	if mode := job.Parameters["hashmode"]; mode != "" {
+		if _, err := strconv.Atoi(mode); err != nil {
+			return nil, errors.New("The hash mode to benchmark is not a number.")
+		}
+		args = append(args, "--hash-type="+mode)
+	}
+	t.args = append(args, config.Args...)
+
+	var err error
+	t.wd, err = createWorkingDir(config.WorkingDir, job.UUID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Status returns the job with the results of the benchmark once it is done
+func (t *benchmarkTasker) Status() common.Job {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.job
+}
+
+// Run starts the benchmark
+func (t *benchmarkTasker) Run() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.job.Status == common.STATUS_RUNNING {
+		return nil
+	}
+
+	// A paused benchmark is started over rather than resumed
+	if t.job.Status != common.STATUS_CREATED && t.job.Status != common.STATUS_PAUSED {
+		return errors.New("Job already finished.")
+	}
+
+	t.exec = exec.Command(config.BinPath, append(deviceOpts(t.devices), t.args...)...)
+	t.exec.Dir = t.wd
+	t.exec.Stdout = &t.stdout
+	t.exec.Stderr = &t.stderr
+	t.stdout.Reset()
+	t.stderr.Reset()
+
+	log.WithField("argument", t.exec.Args).Debug("Running benchmark command.")
+	if err := t.exec.Start(); err != nil {
+		t.job.Status = common.STATUS_FAILED
+		t.job.Error = err.Error()
+		log.Errorf("There was an error starting the benchmark: %v", err)
+		return err
+	}
+
+	t.job.StartTime = time.Now()
+	t.job.Status = common.STATUS_RUNNING
+	t.stopTo = ""
+	t.done = make(chan struct{})
+
+	go t.wait(t.exec, t.done)
+
+	return nil
+}
+
+// wait records the results of the benchmark once hashcat exits
+func (t *benchmarkTasker) wait(cmd *exec.Cmd, done chan struct{}) {
+	err := cmd.Wait()
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	defer close(done)
+
+	if t.stopTo != "" {
+		t.job.Status = t.stopTo
+		return
+	}
+
+	speeds := ParseBenchmarkOutput(t.stdout.String())
+	if len(speeds) == 0 {
+		t.job.Status = common.STATUS_FAILED
+		t.job.Error = strings.TrimSpace(t.stderr.String())
+		if t.job.Error == "" && err != nil {
+			t.job.Error = err.Error()
+		}
+		if t.job.Error == "" {
+			t.job.Error = "No benchmark results were returned by hashcat."
+		}
+		return
+	}
+
+	t.job.Benchmarks = speeds
+	t.job.OutputTitles = []string{"Mode", "Name", "Speed"}
+	t.job.OutputData = benchmarkRows(speeds)
+	t.job.Progress = 100
+	t.job.Status = common.STATUS_DONE
+
+	log.WithFields(log.Fields{
+		"task":  t.job.UUID,
+		"modes": len(speeds),
+	}).Debug("Benchmark finished.")
+}
+
+// stop kills hashcat, waits for it to exit and leaves the job with the status
+// given if it was running
+func (t *benchmarkTasker) stop(status string) {
+	t.mux.Lock()
+	if t.job.Status != common.STATUS_RUNNING {
+		if t.job.Status != common.STATUS_DONE && t.job.Status != common.STATUS_FAILED {
+			t.job.Status = status
+		}
+		t.mux.Unlock()
+		return
+	}
+
+	t.stopTo = status
+	t.exec.Process.Kill()
+	done := t.done
+	t.mux.Unlock()
+
+	<-done
+}
+
+// Pause stops the benchmark, which starts over when it is run again
+func (t *benchmarkTasker) Pause() error {
+	t.stop(common.STATUS_PAUSED)
+	return nil
+}
+
+// Quit stops the benchmark and returns the job
+func (t *benchmarkTasker) Quit() common.Job {
+	t.stop(common.STATUS_QUIT)
+	return t.Status()
+}
+
+// IOE is not used
+func (t *benchmarkTasker) IOE() (io.Writer, io.Reader, io.Reader) {
+	return nil, nil, nil
+}
+
+// ParseBenchmarkOutput returns the speed of every mode in the output of
+// hashcat --benchmark --machine-readable in hashes a second. Each line is
+// device:mode:...:speed and the speeds of every device are added together.
+func ParseBenchmarkOutput(out string) map[string]float64 {
+	speeds := map[string]float64{}
+
+	lineScanner := bufio.NewScanner(strings.NewReader(out))
+	for lineScanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(lineScanner.Text()), ":")
+		if len(fields) < 3 {
+			continue
+		}
+
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			continue
+		}
+
+		mode := fields[1]
+		if _, err := strconv.Atoi(mode); err != nil {
+			continue
+		}
+
+		speed, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil || speed < 0 {
+			continue
+		}
+
+		speeds[mode] += speed
+	}
+
+	return speeds
+}
+
+// benchmarkRows returns the output rows for the speeds of a benchmark
+func benchmarkRows(speeds map[string]float64) [][]string {
+	modes := make([]string, 0, len(speeds))
+	for mode := range speeds {
+		modes = append(modes, mode)
+	}
+	sort.Slice(modes, func(a, b int) bool {
+		aNum, _ := strconv.Atoi(modes[a])
+		bNum, _ := strconv.Atoi(modes[b])
+		return aNum < bNum
+	})
+
+	rows := make([][]string, 0, len(modes))
+	for _, mode := range modes {
+		rows = append(rows, []string{mode, hashModeName(mode), fmt.Sprintf("%.2f MH/s", speeds[mode]/1e6)})
+	}
+
+	return rows
+}
+
+// hashModeName returns the name of a hash mode from the configuration
+func hashModeName(mode string) string {
+	for _, m := range config.HashModes {
+		if m.Number == mode {
+			return m.Name
+		}
+	}
+
+	return ""
+}
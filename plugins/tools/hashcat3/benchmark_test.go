@@ -0,0 +1,31 @@
+package hashcat3
+
+import (
+	"testing"
+)
+
+func TestParseBenchmarkOutput(t *testing.T) {
+	out := `hashcat (v3.30) starting in benchmark mode...
+
+1:0:1695:3802:30.65:11044436789
+2:0:1695:3802:31.02:10955531234
+1:1000:1695:3802:25.10:19044436789.5
+Started: Mon Jan 1 00:00:00 2017
+`
+
+	speeds := ParseBenchmarkOutput(out)
+	if len(speeds) != 2 {
+		t.Fatalf("Expected 2 modes but got %v", speeds)
+	}
+	if speeds["0"] != 11044436789+10955531234 {
+		t.Errorf("Expected the speeds of both devices to be added but got %v", speeds["0"])
+	}
+	if speeds["1000"] != 19044436789.5 {
+		t.Errorf("Unexpected speed for mode 1000: %v", speeds["1000"])
+	}
+
+	rows := benchmarkRows(map[string]float64{"1000": 2e6, "100": 1e6})
+	if len(rows) != 2 || rows[0][0] != "100" || rows[1][2] != "2.00 MH/s" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+}
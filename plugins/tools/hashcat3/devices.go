@@ -0,0 +1,38 @@
+package hashcat3
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetDevices limits the task to the devices the resource set aside for it
+func (t *Tasker) SetDevices(ids []int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.devices = append([]int(nil), ids...)
+}
+
+// SetDevices limits the benchmark to the devices the resource set aside for it
+func (t *benchmarkTasker) SetDevices(ids []int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.devices = append([]int(nil), ids...)
+}
+
+// deviceOpts returns the argument limiting hashcat to the devices given, which
+// are numbered from 1 as hashcat numbers them. hashcat uses every device when
+// none are given.
+func deviceOpts(ids []int) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.Itoa(id)
+	}
+
+	return []string{"--opencl-devices=" + strings.Join(list, ",")}
+}
@@ -0,0 +1,504 @@
+package hashcat3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// Built in hashcat character sets
+var builtinCharsets = map[byte]string{
+	'l': "abcdefghijklmnopqrstuvwxyz",
+	'u': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'd': "0123456789",
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+func init() {
+	builtinCharsets['a'] = builtinCharsets['l'] + builtinCharsets['u'] + builtinCharsets['d'] + builtinCharsets['s']
+
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	builtinCharsets['b'] = string(all)
+}
+
+// charsetSize returns how many different characters a charset holds. Custom
+// charsets may use the built in ones but not each other.
+func charsetSize(set string) (int, error) {
+	var seen [256]bool
+	var n int
+	add := func(chars string) {
+		for i := 0; i < len(chars); i++ {
+			if !seen[chars[i]] {
+				seen[chars[i]] = true
+				n++
+			}
+		}
+	}
+
+	for i := 0; i < len(set); i++ {
+		if set[i] != '?' {
+			add(set[i : i+1])
+			continue
+		}
+
+		if i+1 == len(set) {
+			return 0, errors.New("Charset ends with an unfinished ?.")
+		}
+		i++
+
+		if set[i] == '?' {
+			add("?")
+			continue
+		}
+
+		chars, ok := builtinCharsets[set[i]]
+		if !ok {
+			return 0, errors.New("Charset uses unknown charset ?" + string(set[i]) + ".")
+		}
+		add(chars)
+	}
+
+	return n, nil
+}
+
+// maskPositions returns the number of characters tried at each position of a
+// mask, with custom holding the four custom charsets
+func maskPositions(mask string, custom [4]string) ([]float64, error) {
+	var positions []float64
+
+	for i := 0; i < len(mask); i++ {
+		if mask[i] != '?' {
+			positions = append(positions, 1)
+			continue
+		}
+
+		if i+1 == len(mask) {
+			return nil, errors.New("Mask ends with an unfinished ?.")
+		}
+		i++
+
+		switch c := mask[i]; {
+		case c == '?':
+			positions = append(positions, 1)
+		case c >= '1' && c <= '4':
+			if custom[c-'1'] == "" {
+				return nil, errors.New("Mask uses custom charset ?" + string(c) + " which is not set.")
+			}
+
+			n, err := charsetSize(custom[c-'1'])
+			if err != nil {
+				return nil, err
+			}
+			positions = append(positions, float64(n))
+		default:
+			chars, ok := builtinCharsets[c]
+			if !ok {
+				return nil, errors.New("Mask uses unknown charset ?" + string(c) + ".")
+			}
+			positions = append(positions, float64(len(chars)))
+		}
+	}
+
+	if len(positions) == 0 {
+		return nil, errors.New("Mask is empty.")
+	}
+
+	return positions, nil
+}
+
+// maskCandidates returns how many candidates a mask gives, adding up every
+// length from min to max when incrementing. Lengths past the end of the mask
+// are left out as hashcat does.
+func maskCandidates(positions []float64, increment bool, min, max int) float64 {
+	if !increment {
+		min, max = len(positions), len(positions)
+	}
+	if min < 1 {
+		min = 1
+	}
+	if max < 1 || max > len(positions) {
+		max = len(positions)
+	}
+
+	var total float64
+	product := 1.0
+	for length := 1; length <= max; length++ {
+		product *= positions[length-1]
+		if length >= min {
+			total += product
+		}
+	}
+
+	return total
+}
+
+// lineCount is a cached count of the lines in a file
+type lineCount struct {
+	size    int64
+	modTime time.Time
+	lines   int64
+}
+
+var lineCounts = struct {
+	sync.Mutex
+	files map[string]lineCount
+}{files: map[string]lineCount{}}
+
+// countLines returns the number of lines in a file. If rules is set blank lines
+// and comments are left out as hashcat does for rule files. Counts are kept
+// until the file changes since dictionaries can be large.
+func countLines(path string, rules bool) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	key := path
+	if rules {
+		key = "rules:" + path
+	}
+
+	lineCounts.Lock()
+	c, ok := lineCounts.files[key]
+	lineCounts.Unlock()
+	if ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		return c.lines, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	lines, err := readLines(f, rules)
+	if err != nil {
+		return 0, err
+	}
+
+	lineCounts.Lock()
+	lineCounts.files[key] = lineCount{size: info.Size(), modTime: info.ModTime(), lines: lines}
+	lineCounts.Unlock()
+
+	return lines, nil
+}
+
+// readLines counts the lines read, leaving out blank lines and comments for rules
+func readLines(r io.Reader, rules bool) (int64, error) {
+	var lines int64
+
+	if rules {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 && line[0] != '#' {
+				lines++
+			}
+		}
+
+		return lines, scanner.Err()
+	}
+
+	last := byte('\n')
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+		}
+
+		if err == io.EOF {
+			// A last line without a newline is still a word
+			if last != '\n' {
+				lines++
+			}
+			return lines, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// paramBool reads a checkbox parameter, which is false when it is missing
+func paramBool(params map[string]string, key string) (bool, error) {
+	v, ok := params[key]
+	if !ok || v == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(v)
+}
+
+// paramInt reads a number parameter, returning def when it is missing
+func paramInt(params map[string]string, key string, def int) (int, error) {
+	v, ok := params[key]
+	if !ok || v == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(v)
+}
+
+// dictionaryCandidates returns the number of words a dictionary attack tries
+// before rules, including any custom words prepended to the dictionary
+func dictionaryCandidates(params map[string]string) (float64, error) {
+	name := params["dict_dictionaries"]
+	dictIndex := sort.Search(len(config.Dictionaries), func(i int) bool { return config.Dictionaries[i].Name >= name })
+	if dictIndex == len(config.Dictionaries) || config.Dictionaries[dictIndex].Name != name {
+		return 0, errors.New("Dictionary provided does not exist.")
+	}
+
+	words, err := countLines(config.Dictionaries[dictIndex].Path, false)
+	if err != nil {
+		return 0, err
+	}
+
+	prepended, err := prependCount(params, "dict_use_custom_prepend", "dict_custom_prepend")
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(words + prepended), nil
+}
+
+// prependCount returns the number of custom words prepended to a dictionary
+func prependCount(params map[string]string, useKey, wordsKey string) (int64, error) {
+	prepend, err := paramBool(params, useKey)
+	if err != nil || !prepend {
+		return 0, err
+	}
+
+	var words int64
+	for _, w := range strings.Split(params[wordsKey], "\n") {
+		if strings.TrimSpace(w) != "" {
+			words++
+		}
+	}
+
+	return words, nil
+}
+
+// wordlistCandidates returns the number of words in the word list of a
+// combinator or hybrid attack with the prefix given (See wordlistPath)
+func wordlistCandidates(params map[string]string, prefix string) (float64, error) {
+	upload, err := paramBool(params, prefix+"use_upload")
+	if err != nil {
+		return 0, err
+	}
+
+	var words int64
+	if upload {
+		data, err := decodeBase64Upload(params[prefix+"file"])
+		if err != nil {
+			return 0, err
+		}
+
+		if words, err = readLines(bytes.NewReader(data), false); err != nil {
+			return 0, err
+		}
+	} else {
+		path, err := findDictionary(params[prefix+"dictionary"])
+		if err != nil {
+			return 0, err
+		}
+
+		if words, err = countLines(path, false); err != nil {
+			return 0, err
+		}
+	}
+
+	prepended, err := prependCount(params, prefix+"use_custom_prepend", prefix+"custom_prepend")
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(words + prepended), nil
+}
+
+// hybridCandidates returns the number of candidates a hybrid attack with the
+// prefix given tries, which is every word with every candidate of the mask
+func hybridCandidates(params map[string]string, prefix string) (float64, error) {
+	words, err := wordlistCandidates(params, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	mask, err := hybridMask(params, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	positions, err := maskPositions(mask, [4]string{CharSetPreDefCustom1, CharSetPreDefCustom2, CharSetPreDefCustom3, CharSetPreDefCustom4})
+	if err != nil {
+		return 0, err
+	}
+
+	return words * maskCandidates(positions, false, 0, 0), nil
+}
+
+// ruleCount returns how many rules are applied to each word of a dictionary
+// attack, which is 1 when no rules are used
+func ruleCount(params map[string]string) (float64, error) {
+	random, err := paramBool(params, "dict_rules_use_random")
+	if err != nil {
+		return 0, err
+	}
+	if random {
+		max, err := paramInt(params, "dict_rules_random_max", 0)
+		if err != nil {
+			return 0, err
+		}
+		if max <= 0 {
+			return 0, errors.New("The value given for the number of random rules to generate was not more than 0.")
+		}
+
+		return float64(max), nil
+	}
+
+	var rules int64
+	if name := params["dict_rules"]; name != "" {
+		ruleIndex := sort.Search(len(config.RuleFiles), func(i int) bool { return config.RuleFiles[i].Name >= name })
+		if ruleIndex == len(config.RuleFiles) || config.RuleFiles[ruleIndex].Name != name {
+			return 0, errors.New("Rule file provided does not exist.")
+		}
+
+		if rules, err = countLines(config.RuleFiles[ruleIndex].Path, true); err != nil {
+			return 0, err
+		}
+	} else if custom, _ := paramBool(params, "dict_rules_use_custom"); custom && params["dict_rules_custom_file"] != "" {
+		// Uploads look like file:[name];data:[type];base64,[data]
+		parts := strings.Split(params["dict_rules_custom_file"], ";")
+		if len(parts) != 3 || !strings.HasPrefix(parts[2], "base64,") {
+			return 0, errors.New("Error parsing the uploaded file.")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(parts[2][7:])
+		if err != nil {
+			return 0, err
+		}
+
+		if rules, err = readLines(bytes.NewReader(data), true); err != nil {
+			return 0, err
+		}
+	}
+
+	if rules == 0 {
+		return 1, nil
+	}
+
+	return float64(rules), nil
+}
+
+// bruteCandidates returns the number of candidates a brute force attack tries
+func bruteCandidates(params map[string]string) (float64, error) {
+	var mask string
+	var custom [4]string
+
+	useCustom, err := paramBool(params, "brute_use_custom_chars")
+	if err != nil {
+		return 0, err
+	}
+
+	if customMask, ok := params["brute_custom_mask"]; useCustom && ok {
+		mask = customMask
+		for i := range custom {
+			custom[i] = params["brute_custom_charset"+strconv.Itoa(i+1)]
+		}
+	} else {
+		name := params["brute_predefined_charset"]
+		charSetIndex := sort.Search(len(config.Charsets), func(i int) bool { return config.Charsets[i].Name >= name })
+		if charSetIndex == len(config.Charsets) || config.Charsets[charSetIndex].Name != name {
+			return 0, errors.New("Character Set provided does not exist.")
+		}
+
+		mask = config.Charsets[charSetIndex].Mask
+		custom = [4]string{CharSetPreDefCustom1, CharSetPreDefCustom2, CharSetPreDefCustom3, CharSetPreDefCustom4}
+	}
+
+	positions, err := maskPositions(mask, custom)
+	if err != nil {
+		return 0, err
+	}
+
+	increment, err := paramBool(params, "brute_increment")
+	if err != nil {
+		return 0, err
+	}
+
+	min, err := paramInt(params, "brute_min_length", 1)
+	if err != nil {
+		return 0, err
+	}
+	max, err := paramInt(params, "brute_max_length", len(positions))
+	if err != nil {
+		return 0, err
+	}
+
+	return maskCandidates(positions, increment, min, max), nil
+}
+
+// Candidates returns how many candidates the attack described by the job will
+// try, worked out from the dictionaries, rules and charsets configured for the
+// plugin rather than by running hashcat, so no hashes are needed.
+func (h *hashcat3Tooler) Candidates(job common.Job) (float64, error) {
+	params := job.Parameters
+
+	if _, ok := params["dict_dictionaries"]; ok {
+		words, err := dictionaryCandidates(params)
+		if err != nil {
+			return 0, err
+		}
+
+		rules, err := ruleCount(params)
+		if err != nil {
+			return 0, err
+		}
+
+		return words * rules, nil
+	}
+
+	useCustom, _ := paramBool(params, "brute_use_custom_chars")
+	_, custMaskOk := params["brute_custom_mask"]
+	_, preDefMaskOk := params["brute_predefined_charset"]
+	if (useCustom && custMaskOk) || preDefMaskOk {
+		return bruteCandidates(params)
+	}
+
+	if wordlistSet(params, COMB_LEFT_PREFIX) || wordlistSet(params, COMB_RIGHT_PREFIX) {
+		left, err := wordlistCandidates(params, COMB_LEFT_PREFIX)
+		if err != nil {
+			return 0, err
+		}
+
+		right, err := wordlistCandidates(params, COMB_RIGHT_PREFIX)
+		if err != nil {
+			return 0, err
+		}
+
+		return left * right, nil
+	}
+
+	for _, prefix := range []string{HYBRID_DM_PREFIX, HYBRID_MD_PREFIX} {
+		if _, maskOk := params[prefix+"mask"]; maskOk || wordlistSet(params, prefix) {
+			return hybridCandidates(params, prefix)
+		}
+	}
+
+	return 0, errors.New("No attack mode was set.")
+}
@@ -0,0 +1,103 @@
+package hashcat3
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jmmcatee/cracklord/common"
+)
+
+func TestMaskCandidates(t *testing.T) {
+	positions, err := maskPositions("?u?l?l?d?1pw", [4]string{"?d!@"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []float64{26, 26, 26, 10, 12, 1, 1}
+	if len(positions) != len(expected) {
+		t.Fatalf("Expected positions %v but got %v", expected, positions)
+	}
+	for i := range expected {
+		if positions[i] != expected[i] {
+			t.Errorf("Expected %v at position %d but got %v", expected[i], i, positions[i])
+		}
+	}
+
+	if c := maskCandidates([]float64{10, 10, 10}, false, 0, 0); c != 1000 {
+		t.Errorf("Expected 1000 candidates but got %v", c)
+	}
+	if c := maskCandidates([]float64{10, 10, 10}, true, 2, 0); c != 1100 {
+		t.Errorf("Expected 1100 candidates from length 2 but got %v", c)
+	}
+	if c := maskCandidates([]float64{95, 95}, true, 1, 5); c != 95+95*95 {
+		t.Errorf("Expected the increment to stop at the mask length but got %v", c)
+	}
+
+	for _, mask := range []string{"?1", "?z", "abc?", ""} {
+		if _, err := maskPositions(mask, [4]string{}); err == nil {
+			t.Errorf("Expected mask %q to fail", mask)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hashcat3-estimate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dictPath := filepath.Join(dir, "words.txt")
+	rulePath := filepath.Join(dir, "best.rule")
+	ioutil.WriteFile(dictPath, []byte(strings.Repeat("word\n", 99)+"last"), 0600)
+	ioutil.WriteFile(rulePath, []byte("# Comment\n:\n\nu\nc\n"), 0600)
+
+	defer func(c Config) { config = c }(config)
+	config.Dictionaries = Dictionaries{{Name: "words", Path: dictPath}}
+	config.RuleFiles = RuleFiles{{Name: "best", Path: rulePath}}
+	config.Charsets = Charsets{{Name: "Lower 4", Mask: "?l?l?l?l"}}
+
+	upload := "file:words.txt;data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("one\ntwo\nthree\n"))
+
+	h := &hashcat3Tooler{}
+	tests := []struct {
+		params   map[string]string
+		expected float64
+	}{
+		{map[string]string{"dict_dictionaries": "words"}, 100},
+		{map[string]string{"dict_dictionaries": "words", "dict_rules": "best"}, 300},
+		{map[string]string{"dict_dictionaries": "words", "dict_use_custom_prepend": "true", "dict_custom_prepend": "a\nb"}, 102},
+		{map[string]string{"dict_dictionaries": "words", "dict_rules_use_random": "true", "dict_rules_random_max": "50"}, 5000},
+		{map[string]string{"brute_predefined_charset": "Lower 4"}, 26 * 26 * 26 * 26},
+		{map[string]string{"brute_use_custom_chars": "true", "brute_custom_mask": "?1?1", "brute_custom_charset1": "abc"}, 9},
+		{map[string]string{"comb_left_dictionary": "words", "comb_right_dictionary": "words", "comb_right_use_custom_prepend": "true", "comb_right_custom_prepend": "a"}, 100 * 101},
+		{map[string]string{"comb_left_dictionary": "words", "comb_right_use_upload": "true", "comb_right_file": upload}, 100 * 3},
+		{map[string]string{"hybrid_dm_dictionary": "words", "hybrid_dm_mask": "?d?d"}, 100 * 100},
+		{map[string]string{"hybrid_md_mask": "?3", "hybrid_md_dictionary": "words"}, 100 * 43},
+	}
+
+	for _, test := range tests {
+		c, err := h.Candidates(common.Job{Parameters: test.params})
+		if err != nil {
+			t.Errorf("Unexpected error for %v: %v", test.params, err)
+			continue
+		}
+		if c != test.expected {
+			t.Errorf("Expected %v candidates for %v but got %v", test.expected, test.params, c)
+		}
+	}
+
+	if _, err := h.Candidates(common.Job{Parameters: map[string]string{"dict_dictionaries": "missing"}}); err == nil {
+		t.Error("Expected a missing dictionary to fail")
+	}
+	if _, err := h.Candidates(common.Job{Parameters: map[string]string{"hybrid_dm_dictionary": "words"}}); err == nil {
+		t.Error("Expected a hybrid attack without a mask to fail")
+	}
+	if _, err := h.Candidates(common.Job{Parameters: map[string]string{"hashmode": "0"}}); err == nil {
+		t.Error("Expected a job without an attack to fail")
+	}
+}
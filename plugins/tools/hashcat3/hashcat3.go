@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/vaughan0/go-ini"
@@ -22,6 +23,7 @@ type Config struct {
 	Dictionaries Dictionaries
 	RuleFiles    RuleFiles
 	Charsets     Charsets
+	Units        int // GPUs each job uses
 }
 
 var config Config
@@ -52,6 +54,16 @@ func Setup(confPath string) error {
 	config.BinPath = basicConfig["binPath"]
 	config.WorkingDir = basicConfig["workingdir"]
 
+	// Number of the resource's GPUs each job uses
+	config.Units = 1
+	if gpus, ok := basicConfig["gpus"]; ok {
+		config.Units, err = strconv.Atoi(gpus)
+		if err != nil || config.Units < 1 {
+			log.Error("The gpus option was provided, but is not a positive integer.")
+			config.Units = 1
+		}
+	}
+
 	log.WithFields(log.Fields{
 		"binpath": config.BinPath,
 		"WorkDir": config.WorkingDir,
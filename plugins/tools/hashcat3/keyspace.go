@@ -0,0 +1,78 @@
+package hashcat3
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/jmmcatee/cracklord/common"
+)
+
+// Keyspace returns the keyspace hashcat reports for the attack described by the
+// job. This is the same unit used by --skip and --limit, which allows the Queue
+// to split the job into chunks for multiple resources.
+func (h *hashcat3Tooler) Keyspace(job common.Job) (int64, error) {
+	// Build the task in a scratch working directory so the parameters are parsed
+	// exactly as they would be for the real job
+	job.UUID = job.UUID + "-keyspace"
+
+	tasker, err := h.NewTask(job)
+	if err != nil {
+		return 0, err
+	}
+	t := tasker.(*Tasker)
+	defer os.RemoveAll(t.wd)
+
+	// hashcat does not support --skip and --limit with increment mode
+	for _, arg := range t.keyspace {
+		if arg == "--increment" {
+			return 0, errors.New("Keyspace splitting is not supported with incremental mode.")
+		}
+	}
+
+	args := append([]string{"--keyspace"}, t.keyspace...)
+	keyspaceExec := exec.Command(config.BinPath, args...)
+	keyspaceExec.Dir = t.wd
+	log.WithField("Keyspace Command", keyspaceExec.Args).Debug("Executing Keyspace Command")
+
+	out, err := keyspaceExec.Output()
+	if err != nil {
+		log.WithField("execError", err).Error("Error running hashcat --keyspace command.")
+		return 0, err
+	}
+
+	return ParseKeyspaceOutput(string(out))
+}
+
+// ParseKeyspaceOutput returns the keyspace printed by hashcat --keyspace, which
+// is the last non-empty line of the output.
+func ParseKeyspaceOutput(out string) (int64, error) {
+	var last string
+
+	lineScanner := bufio.NewScanner(strings.NewReader(out))
+	for lineScanner.Scan() {
+		if line := strings.TrimSpace(lineScanner.Text()); line != "" {
+			last = line
+		}
+	}
+
+	if last == "" {
+		return 0, errors.New("No keyspace was returned by hashcat.")
+	}
+
+	keyspace, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		log.WithField("line", last).Error("Error parsing the keyspace.")
+		return 0, err
+	}
+
+	if keyspace <= 0 {
+		return 0, errors.New("The keyspace returned by hashcat was not more than 0.")
+	}
+
+	return keyspace, nil
+}
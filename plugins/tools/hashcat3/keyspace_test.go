@@ -0,0 +1,35 @@
+package hashcat3
+
+import (
+	"testing"
+)
+
+func TestParseKeyspaceOutput(t *testing.T) {
+	keyspace, err := ParseKeyspaceOutput("14344384\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if keyspace != 14344384 {
+		t.Errorf("Expected keyspace of 14344384 but got %d", keyspace)
+	}
+
+	keyspace, err = ParseKeyspaceOutput("nvmlDeviceGetFanSpeed(): Not Supported\n\n456976\n\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if keyspace != 456976 {
+		t.Errorf("Expected keyspace of 456976 but got %d", keyspace)
+	}
+
+	if _, err = ParseKeyspaceOutput(""); err == nil {
+		t.Error("Expected an error for empty output")
+	}
+
+	if _, err = ParseKeyspaceOutput("Usage: hashcat [options]... hash|hashfile|hccapxfile [dictionary|mask|directory]..."); err == nil {
+		t.Error("Expected an error for non-numeric output")
+	}
+
+	if _, err = ParseKeyspaceOutput("0"); err == nil {
+		t.Error("Expected an error for an empty keyspace")
+	}
+}
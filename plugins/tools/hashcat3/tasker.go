@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,9 +28,12 @@ type Tasker struct {
 	showPot       []string
 	showPotLeft   []string
 	showPotOutput [][]string
+	keyspace      []string
 	hashes        [][]byte
 	inputSplits   int
 	hashMode      string
+	devices       []int  // Devices the resource set aside for the task (See SetDevices)
+	sessionOn     string // Devices the hashcat session was started on
 
 	stderr     *bytes.Buffer
 	stderrCp   bool
@@ -202,9 +206,20 @@ func (t *Tasker) Run() error {
 	t.job.TotalHashes = leftCount + potCount
 	t.job.CrackedHashes = potCount
 
-	// Set commands for restore or start
-	if t.job.Status == common.STATUS_CREATED {
-		t.exec = *exec.Command(config.BinPath, t.start...)
+	// Set commands for restore or start. hashcat restores a session on the
+	// devices it was started on, so start over if the resource gave us others.
+	devices := deviceOpts(t.devices)
+	moved := t.job.Status != common.STATUS_CREATED && strings.Join(devices, " ") != t.sessionOn
+	if moved {
+		log.WithFields(log.Fields{
+			"task":    t.job.UUID,
+			"devices": t.devices,
+		}).Warn("Devices changed while the task was paused, starting the hashcat session over.")
+	}
+
+	if t.job.Status == common.STATUS_CREATED || moved {
+		t.exec = *exec.Command(config.BinPath, append(devices, t.start...)...)
+		t.sessionOn = strings.Join(devices, " ")
 	} else {
 		t.exec = *exec.Command(config.BinPath, t.resume...)
 	}
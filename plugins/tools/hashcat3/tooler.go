@@ -51,6 +51,11 @@ func (h *hashcat3Tooler) Requirements() string {
 	return common.RES_GPU
 }
 
+// Units returns the number of GPUs each job uses
+func (h *hashcat3Tooler) Units() int {
+	return config.Units
+}
+
 // NewTooler returns a hashcat3 impementation of the common.Tooler
 func NewTooler() common.Tooler {
 	// Get the version from hashcat
@@ -252,6 +257,27 @@ func (h *hashcat3Tooler) Parameters() string {
 	// Add the tab to the Attack Type fieldset
 	attackTypeFieldset.AddTab(bruteForceTab)
 
+	// Build the combinator attack tab, which joins every word of the left word
+	// list with every word of the right
+	combinatorTab := goschemaform.NewTab()
+	combinatorTab.SetTitle("Combinator")
+	addWordlistInputs(combinatorTab, COMB_LEFT_PREFIX, "left side")
+	addWordlistInputs(combinatorTab, COMB_RIGHT_PREFIX, "right side")
+	attackTypeFieldset.AddTab(combinatorTab)
+
+	// Build the hybrid attack tabs, which add a mask after or before each word
+	hybridDictMaskTab := goschemaform.NewTab()
+	hybridDictMaskTab.SetTitle("Hybrid Dictionary + Mask")
+	addWordlistInputs(hybridDictMaskTab, HYBRID_DM_PREFIX, "dictionary")
+	addMaskInput(hybridDictMaskTab, HYBRID_DM_PREFIX)
+	attackTypeFieldset.AddTab(hybridDictMaskTab)
+
+	hybridMaskDictTab := goschemaform.NewTab()
+	hybridMaskDictTab.SetTitle("Hybrid Mask + Dictionary")
+	addMaskInput(hybridMaskDictTab, HYBRID_MD_PREFIX)
+	addWordlistInputs(hybridMaskDictTab, HYBRID_MD_PREFIX, "dictionary")
+	attackTypeFieldset.AddTab(hybridMaskDictTab)
+
 	// Add the tab fieldset to the form
 	hashcatForm.AddElement(attackTypeFieldset)
 
@@ -350,10 +376,10 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 	}
 
 	// Build the arguements for hashcat
-	args := []string{} // all of it put together
-	opts := []string{} // [options]
-	var argHash string // hash|hashfile|hccapfile
-	var argDmD string  // [dictionary|mask|directory]
+	args := []string{}  // all of it put together
+	opts := []string{}  // [options]
+	var argHash string  // hash|hashfile|hccapfile
+	var argDmD []string // [dictionary|mask|directory], two of them for combinator and hybrid attacks
 
 	log.WithField("params", t.job.Parameters).Debug("Create Hashcat Job Parameters.")
 
@@ -413,11 +439,11 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 			}
 
 			// We now have our new dictionary file so append this to the arguments
-			argDmD = customDictPath
+			argDmD = []string{customDictPath}
 			log.WithField("Custom dictionary path", customDictPath)
 		} else {
 			// We are not using a custom dictionary so use the one provided that we know is valid
-			argDmD = config.Dictionaries[dictIndex].Path
+			argDmD = []string{config.Dictionaries[dictIndex].Path}
 		}
 
 		// Check if we are using any rule files or generating them randomly
@@ -581,7 +607,7 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 		}
 
 		// Append the custom mask
-		argDmD = bruCustomMask
+		argDmD = []string{bruCustomMask}
 	} else if preDefMaskOk {
 		log.Debug("Do not use custom character sets")
 		opts = append(opts, "--attack-mode", "3")
@@ -596,13 +622,10 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 		}
 
 		// Add the custom character sets used by the configured pre definied character masks
-		opts = append(opts, "--custom-charset1="+CharSetPreDefCustom1)
-		opts = append(opts, "--custom-charset2="+CharSetPreDefCustom2)
-		opts = append(opts, "--custom-charset3="+CharSetPreDefCustom3)
-		opts = append(opts, "--custom-charset4="+CharSetPreDefCustom4)
+		opts = append(opts, predefinedCharsetOpts()...)
 
 		// The mask provided is good so append to arguments
-		argDmD = config.Charsets[charSetIndex].Mask
+		argDmD = []string{config.Charsets[charSetIndex].Mask}
 	}
 
 	if (bruUseCustomMaskBool && custMaskOk) || preDefMaskOk {
@@ -659,11 +682,86 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 		}
 	}
 
+	/////////////////////////////////////////////////////////////////////////////////////////
+	// Check for Combinator Crack mode
+	if !modeSet && (wordlistSet(t.job.Parameters, COMB_LEFT_PREFIX) || wordlistSet(t.job.Parameters, COMB_RIGHT_PREFIX)) {
+		log.Debug("Combinator attack selected.")
+		opts = append(opts, "--attack-mode", "1")
+		modeSet = true
+
+		left, err := wordlistPath(t.wd, t.job.Parameters, COMB_LEFT_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := wordlistPath(t.wd, t.job.Parameters, COMB_RIGHT_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		argDmD = []string{left, right}
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////
+	// Check for Hybrid Crack modes
+	if _, maskOk := t.job.Parameters[HYBRID_DM_PREFIX+"mask"]; !modeSet && (maskOk || wordlistSet(t.job.Parameters, HYBRID_DM_PREFIX)) {
+		log.Debug("Hybrid dictionary + mask attack selected.")
+		opts = append(opts, "--attack-mode", "6")
+		opts = append(opts, predefinedCharsetOpts()...)
+		modeSet = true
+
+		dict, err := wordlistPath(t.wd, t.job.Parameters, HYBRID_DM_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		mask, err := hybridMask(t.job.Parameters, HYBRID_DM_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		argDmD = []string{dict, mask}
+	}
+
+	if _, maskOk := t.job.Parameters[HYBRID_MD_PREFIX+"mask"]; !modeSet && (maskOk || wordlistSet(t.job.Parameters, HYBRID_MD_PREFIX)) {
+		log.Debug("Hybrid mask + dictionary attack selected.")
+		opts = append(opts, "--attack-mode", "7")
+		opts = append(opts, predefinedCharsetOpts()...)
+		modeSet = true
+
+		mask, err := hybridMask(t.job.Parameters, HYBRID_MD_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		dict, err := wordlistPath(t.wd, t.job.Parameters, HYBRID_MD_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		argDmD = []string{mask, dict}
+	}
+
 	// Check that we set a mode, if not something is wrong so fail
 	if !modeSet {
 		log.Error("No attack mode was set.")
 	}
 
+	// Keep the attack arguments so the keyspace can be calculated without the hashes
+	t.keyspace = append(t.keyspace, opts...)
+	t.keyspace = append(t.keyspace, argDmD...)
+
+	// If the Queue split this job we only work on our chunk of the keyspace
+	if t.job.KeyspaceLimit > 0 {
+		log.WithFields(log.Fields{
+			"skip":  t.job.KeyspaceSkip,
+			"limit": t.job.KeyspaceLimit,
+		}).Debug("Keyspace chunk provided.")
+
+		opts = append(opts, "--skip="+strconv.FormatInt(t.job.KeyspaceSkip, 10))
+		opts = append(opts, "--limit="+strconv.FormatInt(t.job.KeyspaceLimit, 10))
+	}
+
 	// Start parsing the hash input
 	var hashUseUploadBool bool
 	if hashUseUploadString, hashUseUploadOk := t.job.Parameters["hashes_use_upload"]; hashUseUploadOk {
@@ -795,7 +893,8 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 
 	// Append the various inputs to the argument
 	args = append(args, opts...)
-	args = append(args, argHash, argDmD)
+	args = append(args, argHash)
+	args = append(args, argDmD...)
 
 	// Apply the args parsed from the parameters
 	t.start = append(t.start, args...)
@@ -810,6 +909,10 @@ func (h *hashcat3Tooler) NewTask(job common.Job) (common.Tasker, error) {
 	delete(t.job.Parameters, "hashes_file_upload")
 	delete(t.job.Parameters, "hashes_multiline")
 	delete(t.job.Parameters, "dict_custom_prepend")
+	for _, prefix := range []string{COMB_LEFT_PREFIX, COMB_RIGHT_PREFIX, HYBRID_DM_PREFIX, HYBRID_MD_PREFIX} {
+		delete(t.job.Parameters, prefix+"custom_prepend")
+		delete(t.job.Parameters, prefix+"file")
+	}
 
 	return &t, nil
 }